@@ -8,6 +8,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strings"
+	"sync"
 )
 
 const (
@@ -18,6 +21,7 @@ type Callback interface {
 	Call(OutputType, string) error
 	CleanUp() error
 	getFormat() OutputFormat
+	callWithTags(OutputType, string, map[string]string, *SampleMetadata) error
 }
 
 type OutputFormat int
@@ -28,23 +32,56 @@ const (
 )
 
 type AnalyserFormatType struct {
-	Data any    `json:"data"`
-	ID   string `json:"id"`
+	Data   any               `json:"data"`
+	Tags   map[string]string `json:"tags,omitempty"`
+	Sample *SampleMetadata   `json:"sample,omitempty"`
+	ID     string            `json:"id"`
 }
 
 type OutputType interface {
 	GetAnalyserFormat() ([]*AnalyserFormatType, error)
 }
 
+// formatTags returns the tags as a sorted comma separated list of key=value pairs in square brackets
+func formatTags(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(pairs)
+	return "[" + strings.Join(pairs, ",") + "]"
+}
+
+// formatSample returns the sample metadata to append to a raw line
+func formatSample(sample *SampleMetadata) (string, error) {
+	if sample == nil {
+		return "", nil
+	}
+	line, err := json.Marshal(sample)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal sample metadata %w", err)
+	}
+	return ", sample=" + string(line), nil
+}
+
 // getFormattedOutput returns the output in the format configured by on the callback
-func getFormattedOutput(c Callback, output OutputType, tag string) ([]byte, error) {
+//
+//nolint:lll // allow slightly long function definition
+func getFormattedOutput(c Callback, output OutputType, tag string, tags map[string]string, sample *SampleMetadata) ([]byte, error) {
 	switch c.getFormat() {
 	case Raw:
 		line, err := json.Marshal(output)
 		if err != nil {
 			return []byte{}, fmt.Errorf("failed to marshal %T %w", output, err)
 		}
-		return []byte(fmt.Sprintf("%T:%s, %s", output, tag, line)), nil
+		sampleStr, err := formatSample(sample)
+		if err != nil {
+			return []byte{}, err
+		}
+		return []byte(fmt.Sprintf("%T:%s%s, %s%s", output, tag, formatTags(tags), line, sampleStr)), nil
 	case AnalyserJSON:
 		outputs, err := output.GetAnalyserFormat()
 		if err != nil {
@@ -53,6 +90,10 @@ func getFormattedOutput(c Callback, output OutputType, tag string) ([]byte, erro
 		newline := []byte("\n")
 		lines := make([]byte, 0)
 		for count, obj := range outputs {
+			if len(tags) > 0 {
+				obj.Tags = tags
+			}
+			obj.Sample = sample
 			line, err := json.Marshal(obj)
 			if err != nil {
 				return []byte{}, fmt.Errorf("failed to marshal AnalyserFormat for %s %w", tag, err)
@@ -109,7 +150,16 @@ type FileCallBack struct {
 }
 
 func (c FileCallBack) Call(output OutputType, tag string) error {
-	formattedOutput, err := getFormattedOutput(c, output, tag)
+	return c.callWithTags(output, tag, nil, nil)
+}
+
+func (c FileCallBack) callWithTags(
+	output OutputType,
+	tag string,
+	tags map[string]string,
+	sample *SampleMetadata,
+) error {
+	formattedOutput, err := getFormattedOutput(c, output, tag, tags, sample)
 	if err != nil {
 		return err
 	}
@@ -132,3 +182,139 @@ func (c FileCallBack) CleanUp() error {
 	}
 	return nil
 }
+
+// NewTaggedCallback returns a callback which adds tags to every output
+// before passing it on to the wrapped callback.
+func NewTaggedCallback(callback Callback, tags map[string]string) *TaggedCallback {
+	return &TaggedCallback{callback: callback, tags: tags}
+}
+
+// TaggedCallback labels every output with a set of key value pairs,
+// for example the interface the output was collected from.
+type TaggedCallback struct {
+	callback Callback
+	tags     map[string]string
+}
+
+func (c *TaggedCallback) Call(output OutputType, tag string) error {
+	return c.callWithTags(output, tag, nil, nil)
+}
+
+func (c *TaggedCallback) callWithTags(
+	output OutputType,
+	tag string,
+	tags map[string]string,
+	sample *SampleMetadata,
+) error {
+	merged := make(map[string]string, len(c.tags)+len(tags))
+	for key, value := range c.tags {
+		merged[key] = value
+	}
+	for key, value := range tags {
+		merged[key] = value
+	}
+	return c.callback.callWithTags(output, tag, merged, sample)
+}
+
+func (c *TaggedCallback) getFormat() OutputFormat {
+	return c.callback.getFormat()
+}
+
+// CleanUp does nothing as the wrapped callback is shared and so
+// should be cleaned up by its owner
+func (c *TaggedCallback) CleanUp() error {
+	return nil
+}
+
+// NewCountingCallback returns a callback which counts the outputs
+// successfully passed to the wrapped callback by tag.
+func NewCountingCallback(callback Callback) *CountingCallback {
+	return &CountingCallback{callback: callback, counts: make(map[string]int)}
+}
+
+// CountingCallback keeps a count of the outputs written through it.
+type CountingCallback struct {
+	callback Callback
+	counts   map[string]int
+	lock     sync.Mutex
+}
+
+func (c *CountingCallback) Call(output OutputType, tag string) error {
+	return c.callWithTags(output, tag, nil, nil)
+}
+
+func (c *CountingCallback) callWithTags(
+	output OutputType,
+	tag string,
+	tags map[string]string,
+	sample *SampleMetadata,
+) error {
+	err := c.callback.callWithTags(output, tag, tags, sample)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.counts[tag]++
+	return nil
+}
+
+// GetCounts returns a copy of the number of outputs written for each tag
+func (c *CountingCallback) GetCounts() map[string]int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	counts := make(map[string]int, len(c.counts))
+	for tag, count := range c.counts {
+		counts[tag] = count
+	}
+	return counts
+}
+
+func (c *CountingCallback) getFormat() OutputFormat {
+	return c.callback.getFormat()
+}
+
+func (c *CountingCallback) CleanUp() error {
+	return c.callback.CleanUp()
+}
+
+// NewObservingCallback returns a callback which passes every output successfully
+// written by the wrapped callback to observer along with its tag and tags.
+func NewObservingCallback(callback Callback, observer func(OutputType, string, map[string]string)) *ObservingCallback {
+	return &ObservingCallback{callback: callback, observer: observer}
+}
+
+// ObservingCallback lets the outputs be inspected as they are written,
+// for example to decide when a run should stop.
+type ObservingCallback struct {
+	callback Callback
+	observer func(OutputType, string, map[string]string)
+}
+
+func (c *ObservingCallback) Call(output OutputType, tag string) error {
+	return c.callWithTags(output, tag, nil, nil)
+}
+
+func (c *ObservingCallback) callWithTags(
+	output OutputType,
+	tag string,
+	tags map[string]string,
+	sample *SampleMetadata,
+) error {
+	err := c.callback.callWithTags(output, tag, tags, sample)
+	if err != nil {
+		return err
+	}
+	c.observer(output, tag, tags)
+	return nil
+}
+
+func (c *ObservingCallback) getFormat() OutputFormat {
+	return c.callback.getFormat()
+}
+
+// CleanUp does nothing as the wrapped callback is shared and so
+// should be cleaned up by its owner
+func (c *ObservingCallback) CleanUp() error {
+	return nil
+}
@@ -4,8 +4,10 @@ package callbacks_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -72,6 +74,47 @@ var _ = Describe("Callbacks", func() {
 			Expect(mockedFile.ReadString('\n')).To(Equal("{\"data\":[\"Hello\"],\"id\":\"testOutput\"}\n"))
 		})
 	})
+	When("JSON TaggedCallback is called", func() {
+		It("should write the tags to the file", func() {
+			callback := callbacks.NewTaggedCallback(
+				callbacks.NewFileCallback(mockedFile, callbacks.AnalyserJSON),
+				map[string]string{"interface": "ens7f0"},
+			)
+			out := testOutputType{
+				Msg: "This is a test line",
+			}
+			err := callback.Call(&out, "testOut")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mockedFile.ReadString('\n')).To(
+				Equal("{\"data\":[\"Hello\"],\"tags\":{\"interface\":\"ens7f0\"},\"id\":\"testOutput\"}\n"),
+			)
+		})
+	})
+	When("Raw TaggedCallback is called", func() {
+		It("should write the tags to the file", func() {
+			callback := callbacks.NewTaggedCallback(
+				callbacks.NewFileCallback(mockedFile, callbacks.Raw),
+				map[string]string{"interface": "ens7f0", "cluster": "gm"},
+			)
+			out := testOutputType{
+				Msg: "This is a test line",
+			}
+			err := callback.Call(&out, "testOut")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mockedFile.ReadString('\n')).To(ContainSubstring("testOut[cluster=gm,interface=ens7f0]"))
+		})
+	})
+	When("A TaggedCallback is cleaned up", func() {
+		It("should not close the wrapped callbacks file", func() {
+			callback := callbacks.NewTaggedCallback(
+				callbacks.NewFileCallback(mockedFile, callbacks.Raw),
+				map[string]string{"interface": "ens7f0"},
+			)
+			err := callback.CleanUp()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mockedFile.open).To(BeTrue())
+		})
+	})
 	When("A FileCallback is cleaned up", func() {
 		It("should close the file", func() {
 			callback := callbacks.NewFileCallback(mockedFile, callbacks.Raw)
@@ -80,7 +123,86 @@ var _ = Describe("Callbacks", func() {
 			Expect(mockedFile.open).To(BeFalse())
 		})
 	})
-
+	When("A CountingCallback is called", func() {
+		It("should count the outputs by tag", func() {
+			callback := callbacks.NewCountingCallback(callbacks.NewFileCallback(mockedFile, callbacks.Raw))
+			tagged := callbacks.NewTaggedCallback(callback, map[string]string{"interface": "ens7f0"})
+			out := testOutputType{
+				Msg: "This is a test line",
+			}
+			Expect(callback.Call(&out, "testOut")).To(Succeed())
+			Expect(tagged.Call(&out, "testOut")).To(Succeed())
+			Expect(callback.Call(&out, "otherOut")).To(Succeed())
+			Expect(callback.GetCounts()).To(Equal(map[string]int{"testOut": 2, "otherOut": 1}))
+		})
+	})
+	When("An ObservingCallback is called", func() {
+		It("should pass the written outputs to the observer", func() {
+			observed := make([]string, 0)
+			callback := callbacks.NewObservingCallback(
+				callbacks.NewFileCallback(mockedFile, callbacks.Raw),
+				func(output callbacks.OutputType, tag string, tags map[string]string) {
+					observed = append(observed, tag+tags["interface"]+":"+output.(*testOutputType).Msg)
+				},
+			)
+			tagged := callbacks.NewTaggedCallback(callback, map[string]string{"interface": "ens7f0"})
+			out := testOutputType{
+				Msg: "This is a test line",
+			}
+			Expect(callback.Call(&out, "testOut")).To(Succeed())
+			Expect(tagged.Call(&out, "testOut")).To(Succeed())
+			Expect(observed).To(Equal([]string{"testOut:This is a test line", "testOutens7f0:This is a test line"}))
+			Expect(mockedFile.ReadString('\n')).To(ContainSubstring("This is a test line"))
+		})
+	})
+	When("CallWithContext is called with a sample", func() {
+		var ctx context.Context
+		BeforeEach(func() {
+			scheduled := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+			sample := callbacks.NewSampleMetadata(scheduled)
+			sample.RecordExec(scheduled.Add(2*time.Millisecond), scheduled.Add(5*time.Millisecond))
+			sample.SetNodeTimestamp("2023-09-01T12:00:00.003Z")
+			ctx = callbacks.WithSample(context.Background(), sample)
+		})
+		It("should write the sample metadata with the JSON output", func() {
+			callback := callbacks.NewTaggedCallback(
+				callbacks.NewFileCallback(mockedFile, callbacks.AnalyserJSON),
+				map[string]string{"interface": "ens7f0"},
+			)
+			out := testOutputType{Msg: "This is a test line"}
+			Expect(callbacks.CallWithContext(ctx, callback, &out, "testOut")).To(Succeed())
+			Expect(mockedFile.ReadString('\n')).To(Equal(
+				`{"data":["Hello"],"tags":{"interface":"ens7f0"},"sample":{"scheduled":"2023-09-01T12:00:00Z",` +
+					`"hostSend":"2023-09-01T12:00:00.002Z","hostReceive":"2023-09-01T12:00:00.005Z",` +
+					`"nodeTimestamp":"2023-09-01T12:00:00.003Z","latenessNs":2000000,"roundTripNs":3000000},` +
+					`"id":"testOutput"}` + "\n",
+			))
+		})
+		It("should append the sample metadata to the raw output", func() {
+			callback := callbacks.NewFileCallback(mockedFile, callbacks.Raw)
+			out := testOutputType{Msg: "This is a test line"}
+			Expect(callbacks.CallWithContext(ctx, callback, &out, "testOut")).To(Succeed())
+			Expect(mockedFile.ReadString('\n')).To(ContainSubstring(
+				`{"msg":"This is a test line"}, sample={"scheduled":"2023-09-01T12:00:00Z"`,
+			))
+		})
+		It("should not be changed by later execs in the same poll", func() {
+			callback := callbacks.NewFileCallback(mockedFile, callbacks.AnalyserJSON)
+			out := testOutputType{Msg: "This is a test line"}
+			Expect(callbacks.CallWithContext(ctx, callback, &out, "testOut")).To(Succeed())
+			sample := callbacks.GetSample(ctx)
+			sample.SetNodeTimestamp("later")
+			Expect(mockedFile.ReadString('\n')).NotTo(ContainSubstring("later"))
+		})
+	})
+	When("CallWithContext is called without a sample", func() {
+		It("should write the same output as Call", func() {
+			callback := callbacks.NewFileCallback(mockedFile, callbacks.AnalyserJSON)
+			out := testOutputType{Msg: "This is a test line"}
+			Expect(callbacks.CallWithContext(context.Background(), callback, &out, "testOut")).To(Succeed())
+			Expect(mockedFile.ReadString('\n')).To(Equal("{\"data\":[\"Hello\"],\"id\":\"testOutput\"}\n"))
+		})
+	})
 })
 
 func TestCommand(t *testing.T) {
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package callbacks
+
+import (
+	"context"
+	"time"
+)
+
+type sampleKey struct{}
+
+// SampleMetadata records when a sample was due and how long it took to fetch, so
+// that analysers can discard or weight samples which were collected late or slowly.
+type SampleMetadata struct {
+	// Scheduled is when the poll was due to start
+	Scheduled time.Time `json:"scheduled"`
+	// HostSend and HostReceive are when the commands were sent to the node and their output received
+	HostSend    *time.Time `json:"hostSend,omitempty"`
+	HostReceive *time.Time `json:"hostReceive,omitempty"`
+	// NodeTimestamp is the time reported by the node while running the commands
+	NodeTimestamp string `json:"nodeTimestamp,omitempty"`
+	// LatenessNs is how long after Scheduled the commands were sent
+	LatenessNs int64 `json:"latenessNs,omitempty"`
+	// RoundTripNs is the time between HostSend and HostReceive
+	RoundTripNs int64 `json:"roundTripNs,omitempty"`
+}
+
+// NewSampleMetadata returns the metadata for a sample which was due at scheduled
+func NewSampleMetadata(scheduled time.Time) *SampleMetadata {
+	return &SampleMetadata{Scheduled: scheduled}
+}
+
+// RecordExec stores when the commands for the sample were sent and their output received.
+// If a poll runs more than one exec the last one is kept. It is safe to call on a nil sample.
+func (sample *SampleMetadata) RecordExec(send, receive time.Time) {
+	if sample == nil {
+		return
+	}
+	sample.HostSend = &send
+	sample.HostReceive = &receive
+	sample.LatenessNs = send.Sub(sample.Scheduled).Nanoseconds()
+	sample.RoundTripNs = receive.Sub(send).Nanoseconds()
+}
+
+// SetNodeTimestamp stores the time reported by the node. It is safe to call on a nil sample.
+func (sample *SampleMetadata) SetNodeTimestamp(timestamp string) {
+	if sample == nil {
+		return
+	}
+	sample.NodeTimestamp = timestamp
+}
+
+// copy returns a snapshot of the sample so later execs in the same poll do not change outputs already written
+func (sample *SampleMetadata) copy() *SampleMetadata {
+	if sample == nil {
+		return nil
+	}
+	snapshot := *sample
+	return &snapshot
+}
+
+// WithSample returns a copy of ctx which carries sample to the fetcher and callbacks
+func WithSample(ctx context.Context, sample *SampleMetadata) context.Context {
+	return context.WithValue(ctx, sampleKey{}, sample)
+}
+
+// GetSample returns the sample carried by ctx or nil if there is not one
+func GetSample(ctx context.Context) *SampleMetadata {
+	sample, _ := ctx.Value(sampleKey{}).(*SampleMetadata)
+	return sample
+}
+
+// CallWithContext passes output to callback along with the metadata of the sample carried by ctx
+func CallWithContext(ctx context.Context, callback Callback, output OutputType, tag string) error {
+	return callback.callWithTags(output, tag, nil, GetSample(ctx).copy())
+}
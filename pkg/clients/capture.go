@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const captureFilePermissions = 0666
+
+// CapturedExec is a command which was run through an ExecContext along with everything it returned.
+// Captures are stored as a stream of JSON objects, one per line. Interface is only set for the
+// collectors which run the same script for every interface.
+type CapturedExec struct {
+	Time      time.Time     `json:"time"`
+	Namespace string        `json:"namespace,omitempty"`
+	Pod       string        `json:"pod,omitempty"`
+	Container string        `json:"container,omitempty"`
+	Interface string        `json:"interface,omitempty"`
+	Stdin     string        `json:"stdin,omitempty"`
+	Stdout    string        `json:"stdout"`
+	Stderr    string        `json:"stderr,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Command   []string      `json:"command"`
+	Duration  time.Duration `json:"durationNs"`
+}
+
+// CaptureReader reads CapturedExecs from a capture file
+type CaptureReader struct {
+	decoder *json.Decoder
+	read    int
+}
+
+func NewCaptureReader(reader io.Reader) *CaptureReader {
+	return &CaptureReader{decoder: json.NewDecoder(reader)}
+}
+
+// Next returns the next CapturedExec or io.EOF once all of them have been read
+func (reader *CaptureReader) Next() (*CapturedExec, error) {
+	capture := &CapturedExec{}
+	err := reader.decoder.Decode(capture)
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read capture %d: %w", reader.read+1, err)
+	}
+	reader.read++
+	return capture, nil
+}
+
+// LoadCaptures reads every CapturedExec from reader
+func LoadCaptures(reader io.Reader) ([]*CapturedExec, error) {
+	captures := make([]*CapturedExec, 0)
+	captureReader := NewCaptureReader(reader)
+	for {
+		capture, err := captureReader.Next()
+		if errors.Is(err, io.EOF) {
+			return captures, nil
+		}
+		if err != nil {
+			return captures, err
+		}
+		captures = append(captures, capture)
+	}
+}
+
+// CaptureWriter writes CapturedExecs to a capture file. It can be shared by many ExecContexts.
+type CaptureWriter struct {
+	writer  io.WriteCloser
+	encoder *json.Encoder
+	lock    sync.Mutex
+}
+
+func NewCaptureWriter(writer io.WriteCloser) *CaptureWriter {
+	return &CaptureWriter{writer: writer, encoder: json.NewEncoder(writer)}
+}
+
+// OpenCaptureFile creates or truncates the capture file at path
+func OpenCaptureFile(path string) (*CaptureWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, captureFilePermissions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open capture file: %w", err)
+	}
+	return NewCaptureWriter(file), nil
+}
+
+func (w *CaptureWriter) Write(capture *CapturedExec) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	err := w.encoder.Encode(capture)
+	if err != nil {
+		return fmt.Errorf("failed to write capture: %w", err)
+	}
+	return nil
+}
+
+func (w *CaptureWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	err := w.writer.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close capture file: %w", err)
+	}
+	return nil
+}
+
+// execTarget is implemented by the ExecContexts which run commands in a container
+type execTarget interface {
+	GetNamespace() string
+	GetPodName() string
+	GetContainerName() string
+}
+
+// RecordingExecContext wraps an ExecContext and writes every command it runs,
+// along with its outputs, to a CaptureWriter
+type RecordingExecContext struct {
+	execCtx      ExecContext
+	writer       *CaptureWriter
+	ptpInterface string
+}
+
+func NewRecordingExecContext(execCtx ExecContext, writer *CaptureWriter) *RecordingExecContext {
+	return &RecordingExecContext{execCtx: execCtx, writer: writer}
+}
+
+// NewInterfaceRecordingExecContext returns a RecordingExecContext which records ptpInterface
+// with every command so that the captures can be told apart from those of other interfaces
+func NewInterfaceRecordingExecContext(
+	execCtx ExecContext,
+	writer *CaptureWriter,
+	ptpInterface string,
+) *RecordingExecContext {
+	return &RecordingExecContext{execCtx: execCtx, writer: writer, ptpInterface: ptpInterface}
+}
+
+func (c *RecordingExecContext) record(
+	command []string,
+	stdin string,
+	run func() (string, string, error),
+) (stdout, stderr string, err error) {
+	capture := &CapturedExec{
+		Time:      time.Now().UTC(),
+		Command:   command,
+		Stdin:     stdin,
+		Interface: c.ptpInterface,
+	}
+	stdout, stderr, err = run()
+	capture.Duration = time.Since(capture.Time)
+	capture.Stdout, capture.Stderr = stdout, stderr
+	if err != nil {
+		capture.Error = err.Error()
+	}
+	// Ask for the target after running the command as the pod may have been refreshed
+	if target, ok := c.execCtx.(execTarget); ok {
+		capture.Namespace = target.GetNamespace()
+		capture.Pod = target.GetPodName()
+		capture.Container = target.GetContainerName()
+	}
+	if writeErr := c.writer.Write(capture); writeErr != nil {
+		log.Errorf("failed to record command: %s", writeErr.Error())
+	}
+	return stdout, stderr, err //nolint:wrapcheck // the error is from the wrapped ExecContext
+}
+
+func (c *RecordingExecContext) ExecCommand(ctx context.Context, command []string) (stdout, stderr string, err error) {
+	return c.record(command, "", func() (string, string, error) {
+		return c.execCtx.ExecCommand(ctx, command)
+	})
+}
+
+//nolint:lll // allow slightly long function definition
+func (c *RecordingExecContext) ExecCommandStdIn(ctx context.Context, command []string, buffIn bytes.Buffer) (stdout, stderr string, err error) {
+	return c.record(command, buffIn.String(), func() (string, string, error) {
+		return c.execCtx.ExecCommandStdIn(ctx, command, buffIn)
+	})
+}
+
+// ErrNoCapture is returned by a PlaybackExecContext when there is no capture left for a command
+var ErrNoCapture = errors.New("no capture left for command")
+
+// PlaybackExecContext returns the captured outputs of commands instead of running them.
+// Each capture is returned once, in the order they were captured, to the same command and stdin.
+type PlaybackExecContext struct {
+	captures map[string][]*CapturedExec
+	lock     sync.Mutex
+}
+
+func playbackKey(command []string, stdin string) string {
+	return strings.Join(command, "\x00") + "\x00" + stdin
+}
+
+func NewPlaybackExecContext(captures []*CapturedExec) *PlaybackExecContext {
+	playback := &PlaybackExecContext{captures: make(map[string][]*CapturedExec)}
+	for _, capture := range captures {
+		key := playbackKey(capture.Command, capture.Stdin)
+		playback.captures[key] = append(playback.captures[key], capture)
+	}
+	return playback
+}
+
+func (c *PlaybackExecContext) playback(command []string, stdin string) (stdout, stderr string, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := playbackKey(command, stdin)
+	captures := c.captures[key]
+	if len(captures) == 0 {
+		return "", "", fmt.Errorf("%w %s", ErrNoCapture, strings.Join(command, " "))
+	}
+	capture := captures[0]
+	c.captures[key] = captures[1:]
+	if capture.Error != "" {
+		return capture.Stdout, capture.Stderr, fmt.Errorf("captured command failed: %s", capture.Error)
+	}
+	return capture.Stdout, capture.Stderr, nil
+}
+
+func (c *PlaybackExecContext) ExecCommand(_ context.Context, command []string) (stdout, stderr string, err error) {
+	return c.playback(command, "")
+}
+
+//nolint:lll // allow slightly long function definition
+func (c *PlaybackExecContext) ExecCommandStdIn(_ context.Context, command []string, buffIn bytes.Buffer) (stdout, stderr string, err error) {
+	return c.playback(command, buffIn.String())
+}
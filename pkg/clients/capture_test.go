@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package clients_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/testutils"
+)
+
+type captureFile struct {
+	bytes.Buffer
+}
+
+func (f *captureFile) Close() error {
+	return nil
+}
+
+var _ = Describe("RecordingExecContext", func() {
+	When("a command is run in a container", func() {
+		It("should record the command, its outputs and where it ran", func() {
+			responder := func(method string, url *url.URL, options remotecommand.StreamOptions) ([]byte, []byte, error) {
+				return []byte("<x>\n1\n</x>\n"), []byte("warning"), nil
+			}
+			clients.NewSPDYExecutor = testutils.NewFakeNewSPDYExecutor(responder, nil)
+			containerCtx, err := clients.NewContainerContext(
+				testutils.GetMockedClientSet(testPod), "TestNamespace", "Test", "TestContainer",
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			file := &captureFile{}
+			ctx := clients.NewRecordingExecContext(containerCtx, clients.NewCaptureWriter(file))
+			stdin := bytes.Buffer{}
+			stdin.WriteString("echo '<x>';echo 1;echo '</x>';")
+			stdout, stderr, err := ctx.ExecCommandStdIn(context.Background(), []string{"/usr/bin/sh"}, stdin)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(Equal("<x>\n1\n</x>\n"))
+			Expect(stderr).To(Equal("warning"))
+
+			captures, err := clients.LoadCaptures(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(captures).To(HaveLen(1))
+			Expect(captures[0].Command).To(Equal([]string{"/usr/bin/sh"}))
+			Expect(captures[0].Stdin).To(Equal("echo '<x>';echo 1;echo '</x>';"))
+			Expect(captures[0].Stdout).To(Equal("<x>\n1\n</x>\n"))
+			Expect(captures[0].Stderr).To(Equal("warning"))
+			Expect(captures[0].Error).To(BeEmpty())
+			Expect(captures[0].Pod).To(Equal("TestPod-8292"))
+			Expect(captures[0].Container).To(Equal("TestContainer"))
+			Expect(captures[0].Time.IsZero()).To(BeFalse())
+			Expect(captures[0].Interface).To(BeEmpty())
+		})
+	})
+	When("the commands are run for an interface", func() {
+		It("should record the interface", func() {
+			file := &captureFile{}
+			playback := clients.NewPlaybackExecContext([]*clients.CapturedExec{{Command: []string{"date"}, Stdout: "now"}})
+			ctx := clients.NewInterfaceRecordingExecContext(playback, clients.NewCaptureWriter(file), "ens7f0")
+			_, _, err := ctx.ExecCommand(context.Background(), []string{"date"})
+			Expect(err).NotTo(HaveOccurred())
+
+			captures, err := clients.LoadCaptures(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(captures).To(HaveLen(1))
+			Expect(captures[0].Interface).To(Equal("ens7f0"))
+		})
+	})
+})
+
+var _ = Describe("PlaybackExecContext", func() {
+	When("captures are played back", func() {
+		It("should return them in order to the matching command", func() {
+			ctx := clients.NewPlaybackExecContext([]*clients.CapturedExec{
+				{Command: []string{"date"}, Stdout: "first"},
+				{Command: []string{"/usr/bin/sh"}, Stdin: "ls", Stdout: "files"},
+				{Command: []string{"date"}, Stdout: "second"},
+				{Command: []string{"date"}, Error: "timed out"},
+			})
+			stdout, _, err := ctx.ExecCommand(context.Background(), []string{"date"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(Equal("first"))
+			stdout, _, err = ctx.ExecCommand(context.Background(), []string{"date"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(Equal("second"))
+			_, _, err = ctx.ExecCommand(context.Background(), []string{"date"})
+			Expect(err).To(MatchError(ContainSubstring("timed out")))
+			_, _, err = ctx.ExecCommand(context.Background(), []string{"date"})
+			Expect(errors.Is(err, clients.ErrNoCapture)).To(BeTrue())
+
+			stdin := bytes.Buffer{}
+			stdin.WriteString("ls")
+			stdout, _, err = ctx.ExecCommandStdIn(context.Background(), []string{"/usr/bin/sh"}, stdin)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(Equal("files"))
+		})
+	})
+})
@@ -44,6 +44,25 @@ var _ = Describe("Client", func() {
 			Expect(clientset).NotTo(BeNil())
 		})
 	})
+	When("A new clientset is requested", func() {
+		It("should not be the singleton", func() {
+			singleton, err := clients.GetClientset(kubeconfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			first, err := clients.NewClientset(kubeconfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			second, err := clients.NewClientset(kubeconfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(first).NotTo(BeIdenticalTo(singleton))
+			Expect(first).NotTo(BeIdenticalTo(second))
+		})
+	})
+	When("The cluster name is requested", func() {
+		It("should return the cluster of the current context", func() {
+			name, err := clients.GetClusterName(kubeconfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("api-cormorant-wpc-test:6443"))
+		})
+	})
 })
 
 func TestCommand(t *testing.T) {
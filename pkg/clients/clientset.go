@@ -10,6 +10,7 @@ import (
 
 	ocpconfig "github.com/openshift/client-go/config/clientset/versioned"
 	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -38,23 +39,35 @@ func GetClientset(kubeconfigPaths ...string) (*Clientset, error) {
 		return &clientset, nil
 	}
 
+	newClientset, err := NewClientset(kubeconfigPaths...)
+	if err != nil {
+		return nil, err
+	}
+	clientset = *newClientset
+	return &clientset, nil
+}
+
+// NewClientset returns a new clientset using the provided kubeconfigPaths,
+// unlike GetClientset it does not touch the singleton so can be called once per cluster.
+func NewClientset(kubeconfigPaths ...string) (*Clientset, error) {
 	if len(kubeconfigPaths) == 0 {
 		return nil, utils.NewMissingInputError(
 			fmt.Errorf("must have at least one kubeconfig to initialise a new Clientset"),
 		)
 	}
-	clientset, err := newClientset(kubeconfigPaths...)
+	newClientset, err := newClientset(kubeconfigPaths...)
 	if err != nil {
 		return nil, utils.NewMissingInputError(
 			fmt.Errorf("failed to create k8s clients holder: %w", err),
 		)
 	}
-	return clientset, nil
+	return newClientset, nil
 }
 
-// newClientset will initialise the singleton clientset using provided kubeconfigPath
+// newClientset will initialise a clientset using provided kubeconfigPaths
 func newClientset(kubeconfigPaths ...string) (*Clientset, error) {
 	log.Infof("creating new Clientset from %v", kubeconfigPaths)
+	clientset := &Clientset{}
 	clientset.KubeConfigPaths = kubeconfigPaths
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 
@@ -91,7 +104,20 @@ func newClientset(kubeconfigPaths ...string) (*Clientset, error) {
 
 	clientset.K8sRestClient = clientset.K8sClient.CoreV1().RESTClient()
 	clientset.ready = true
-	return &clientset, nil
+	return clientset, nil
+}
+
+// GetClusterName returns the name of the cluster used by the current context of the kubeconfig
+func GetClusterName(kubeconfigPath string) (string, error) {
+	config, err := clientcmd.LoadFromFile(kubeconfigPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubeconfig %s: %w", kubeconfigPath, err)
+	}
+	currentContext, ok := config.Contexts[config.CurrentContext]
+	if !ok || currentContext.Cluster == "" {
+		return "", fmt.Errorf("kubeconfig %s does not have a current context", kubeconfigPath)
+	}
+	return currentContext.Cluster, nil
 }
 
 func ClearClientSet() {
@@ -103,22 +129,71 @@ func (clientsholder *Clientset) FindPodNameFromPrefix(namespace, prefix string)
 	if err != nil {
 		return "", fmt.Errorf("failed to getting pod list: %w", err)
 	}
-	podNames := make([]string, 0)
+	pods := make([]*corev1.Pod, 0)
 
 	for i := range podList.Items {
 		hasPrefix := strings.HasPrefix(podList.Items[i].Name, prefix)
 		isDebug := strings.HasSuffix(podList.Items[i].Name, "-debug")
 		if hasPrefix && !isDebug {
-			podNames = append(podNames, podList.Items[i].Name)
+			pods = append(pods, &podList.Items[i])
 		}
 	}
 
-	switch len(podNames) {
+	pod, err := selectSinglePod(pods, fmt.Sprintf("with prefix %v", prefix), namespace)
+	if err != nil {
+		return "", err
+	}
+	return pod.Name, nil
+}
+
+// FindPodFromLabels returns the pod matching the labelSelector in the namespace.
+// If nodeName is not empty only pods scheduled on that node are considered.
+func (clientsholder *Clientset) FindPodFromLabels(namespace, labelSelector, nodeName string) (*corev1.Pod, error) {
+	podList, err := clientsholder.K8sClient.CoreV1().Pods(namespace).List(
+		context.TODO(),
+		metav1.ListOptions{LabelSelector: labelSelector},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to getting pod list: %w", err)
+	}
+	pods := make([]*corev1.Pod, 0)
+	for i := range podList.Items {
+		if nodeName == "" || podList.Items[i].Spec.NodeName == nodeName {
+			pods = append(pods, &podList.Items[i])
+		}
+	}
+
+	description := fmt.Sprintf("matching %v", labelSelector)
+	if nodeName != "" {
+		description += fmt.Sprintf(" on node %v", nodeName)
+	}
+	return selectSinglePod(pods, description, namespace)
+}
+
+// FindPodNameFromLabels returns the name of the pod matching the labelSelector in the namespace.
+// If nodeName is not empty only pods scheduled on that node are considered.
+func (clientsholder *Clientset) FindPodNameFromLabels(namespace, labelSelector, nodeName string) (string, error) {
+	pod, err := clientsholder.FindPodFromLabels(namespace, labelSelector, nodeName)
+	if err != nil {
+		return "", err
+	}
+	return pod.Name, nil
+}
+
+func selectSinglePod(pods []*corev1.Pod, description, namespace string) (*corev1.Pod, error) {
+	switch len(pods) {
 	case 0:
-		return "", fmt.Errorf("no pod with prefix %v found in namespace %v", prefix, namespace)
+		return nil, fmt.Errorf("no pod %s found in namespace %v", description, namespace)
 	case 1:
-		return podNames[0], nil
+		return pods[0], nil
 	default:
-		return "", fmt.Errorf("too many (%v) pods with prefix %v found in namespace %v", len(podNames), prefix, namespace)
+		names := make([]string, 0, len(pods))
+		for _, pod := range pods {
+			names = append(names, fmt.Sprintf("%s (node: %s)", pod.Name, pod.Spec.NodeName))
+		}
+		return nil, fmt.Errorf(
+			"too many (%v) pods %s found in namespace %v, select a node to disambiguate: %s",
+			len(pods), description, namespace, strings.Join(names, ", "),
+		)
 	}
 }
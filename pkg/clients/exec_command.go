@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -23,11 +24,14 @@ import (
 const (
 	startTimeoutDefault    = 5 * time.Second
 	deletionTimeoutDefault = 10 * time.Minute
+	podStatusPollInterval  = 100 * time.Millisecond
 )
 
+// ExecContext runs commands somewhere on the cluster.
+// Cancelling the context.Context passed to a command stops it running.
 type ExecContext interface {
-	ExecCommand([]string) (string, string, error)
-	ExecCommandStdIn([]string, bytes.Buffer) (string, string, error)
+	ExecCommand(context.Context, []string) (string, string, error)
+	ExecCommandStdIn(context.Context, []string, bytes.Buffer) (string, string, error)
 }
 
 var NewSPDYExecutor = remotecommand.NewSPDYExecutor
@@ -39,10 +43,21 @@ type ContainerExecContext struct {
 	podName       string
 	containerName string
 	podNamePrefix string
+	labelSelector string
+	nodeName      string
+}
+
+// findPodName looks up the pod using the label selector if one was provided
+// otherwise it falls back to the pod name prefix
+func (c *ContainerExecContext) findPodName() (string, error) {
+	if c.labelSelector != "" {
+		return c.clientset.FindPodNameFromLabels(c.namespace, c.labelSelector, c.nodeName)
+	}
+	return c.clientset.FindPodNameFromPrefix(c.namespace, c.podNamePrefix)
 }
 
 func (c *ContainerExecContext) refresh() error {
-	newPodname, err := c.clientset.FindPodNameFromPrefix(c.namespace, c.podNamePrefix)
+	newPodname, err := c.findPodName()
 	if err != nil {
 		return err
 	}
@@ -68,6 +83,26 @@ func NewContainerContext(
 	return &ctx, nil
 }
 
+// NewContainerContextFromLabels returns a context for the container in the pod which matches
+// the labelSelector. If nodeName is not empty only pods on that node are considered.
+func NewContainerContextFromLabels(
+	clientset *Clientset,
+	namespace, labelSelector, nodeName, containerName string,
+) (*ContainerExecContext, error) {
+	ctx := ContainerExecContext{
+		namespace:     namespace,
+		containerName: containerName,
+		labelSelector: labelSelector,
+		nodeName:      nodeName,
+		clientset:     clientset,
+	}
+	err := ctx.refresh()
+	if err != nil {
+		return &ContainerExecContext{}, err
+	}
+	return &ctx, nil
+}
+
 func (c *ContainerExecContext) GetNamespace() string {
 	return c.namespace
 }
@@ -80,21 +115,14 @@ func (c *ContainerExecContext) GetContainerName() string {
 	return c.containerName
 }
 
-//nolint:lll,funlen // allow slightly long function definition and function length
-func (c *ContainerExecContext) execCommand(command []string, buffInPtr *bytes.Buffer) (stdout, stderr string, err error) {
-	commandStr := command
-	var buffOut bytes.Buffer
-	var buffErr bytes.Buffer
-
-	useBuffIn := buffInPtr != nil
-
-	log.Debugf(
-		"execute command on ns=%s, pod=%s container=%s, cmd: %s",
-		c.GetNamespace(),
-		c.GetPodName(),
-		c.GetContainerName(),
-		strings.Join(commandStr, " "),
-	)
+// streamCommand runs command in the container with its stdin, stdout and stderr connected to those provided.
+// It returns once the command has exited or ctx is cancelled.
+func (c *ContainerExecContext) streamCommand(
+	ctx context.Context,
+	command []string,
+	stdin io.Reader,
+	stdout, stderr io.Writer,
+) error {
 	req := c.clientset.K8sRestClient.Post().
 		Namespace(c.GetNamespace()).
 		Resource("pods").
@@ -103,7 +131,7 @@ func (c *ContainerExecContext) execCommand(command []string, buffInPtr *bytes.Bu
 		VersionedParams(&corev1.PodExecOptions{
 			Container: c.GetContainerName(),
 			Command:   command,
-			Stdin:     useBuffIn,
+			Stdin:     stdin != nil,
 			Stdout:    true,
 			Stderr:    true,
 			TTY:       false,
@@ -112,26 +140,14 @@ func (c *ContainerExecContext) execCommand(command []string, buffInPtr *bytes.Bu
 	exec, err := NewSPDYExecutor(c.clientset.RestConfig, "POST", req.URL())
 	if err != nil {
 		log.Debug(err)
-		return stdout, stderr, fmt.Errorf("error setting up remote command: %w", err)
+		return fmt.Errorf("error setting up remote command: %w", err)
 	}
 
-	var streamOptions remotecommand.StreamOptions
-
-	if useBuffIn {
-		streamOptions = remotecommand.StreamOptions{
-			Stdin:  buffInPtr,
-			Stdout: &buffOut,
-			Stderr: &buffErr,
-		}
-	} else {
-		streamOptions = remotecommand.StreamOptions{
-			Stdout: &buffOut,
-			Stderr: &buffErr,
-		}
-	}
-
-	err = exec.StreamWithContext(context.TODO(), streamOptions)
-	stdout, stderr = buffOut.String(), buffErr.String()
+	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
 	if err != nil {
 		if k8sErrors.IsNotFound(err) {
 			log.Debugf("Pod %s was not found, likely restarted so refreshing context", c.GetPodName())
@@ -140,16 +156,44 @@ func (c *ContainerExecContext) execCommand(command []string, buffInPtr *bytes.Bu
 				log.Debug("Failed to refresh container context", refreshErr)
 			}
 		}
-
 		log.Debug(err)
 		log.Debug(req.URL())
+		return fmt.Errorf("error running remote command: %w", err)
+	}
+	return nil
+}
+
+func (c *ContainerExecContext) execCommand(
+	ctx context.Context,
+	command []string,
+	buffInPtr *bytes.Buffer,
+) (stdout, stderr string, err error) {
+	var buffOut bytes.Buffer
+	var buffErr bytes.Buffer
+
+	log.Debugf(
+		"execute command on ns=%s, pod=%s container=%s, cmd: %s",
+		c.GetNamespace(),
+		c.GetPodName(),
+		c.GetContainerName(),
+		strings.Join(command, " "),
+	)
+
+	// A nil *bytes.Buffer must not be passed on as a non nil io.Reader
+	var stdin io.Reader
+	if buffInPtr != nil {
+		stdin = buffInPtr
+	}
+	err = c.streamCommand(ctx, command, stdin, &buffOut, &buffErr)
+	stdout, stderr = buffOut.String(), buffErr.String()
+	if err != nil {
 		log.Debug("command: ", command)
-		if useBuffIn {
+		if buffInPtr != nil {
 			log.Debug("stdin: ", buffInPtr.String())
 		}
 		log.Debug("stderr: ", stderr)
 		log.Debug("stdout: ", stdout)
-		return stdout, stderr, fmt.Errorf("error running remote command: %w", err)
+		return stdout, stderr, err
 	}
 	return stdout, stderr, nil
 }
@@ -157,13 +201,13 @@ func (c *ContainerExecContext) execCommand(command []string, buffInPtr *bytes.Bu
 // ExecCommand runs command in a container and returns output buffers
 //
 //nolint:lll,funlen // allow slightly long function definition and allow a slightly long function
-func (c *ContainerExecContext) ExecCommand(command []string) (stdout, stderr string, err error) {
-	return c.execCommand(command, nil)
+func (c *ContainerExecContext) ExecCommand(ctx context.Context, command []string) (stdout, stderr string, err error) {
+	return c.execCommand(ctx, command, nil)
 }
 
 //nolint:lll // allow slightly long function definition
-func (c *ContainerExecContext) ExecCommandStdIn(command []string, buffIn bytes.Buffer) (stdout, stderr string, err error) {
-	return c.execCommand(command, &buffIn)
+func (c *ContainerExecContext) ExecCommandStdIn(ctx context.Context, command []string, buffIn bytes.Buffer) (stdout, stderr string, err error) {
+	return c.execCommand(ctx, command, &buffIn)
 }
 
 // ContainerExecContext encapsulates the context in which a command is run; the namespace, pod, and container.
@@ -202,6 +246,7 @@ func (c *ContainerCreationExecContext) createPod() error {
 				},
 			},
 			HostNetwork: c.hostNetwork,
+			NodeName:    c.nodeName,
 		},
 	}
 	if len(c.command) > 0 {
@@ -282,7 +327,7 @@ func (c *ContainerCreationExecContext) waitForPodToStart() error {
 		if running {
 			return nil
 		}
-		time.Sleep(time.Microsecond)
+		time.Sleep(podStatusPollInterval)
 	}
 	return errors.New("timed out waiting for pod to start")
 }
@@ -335,7 +380,7 @@ func (c *ContainerCreationExecContext) waitForPodToDelete() error {
 		if !found {
 			return nil
 		}
-		time.Sleep(time.Microsecond)
+		time.Sleep(podStatusPollInterval)
 	}
 	return errors.New("pod has not terminated within the timeout")
 }
@@ -360,9 +405,11 @@ func fetchDurationEnv(key string, defaultValue time.Duration) (time.Duration, er
 	return defaultValue, nil
 }
 
+// NewContainerCreationExecContext returns a context for a pod which will be created by the tool.
+// If nodeName is not empty the pod will be scheduled on that node.
 func NewContainerCreationExecContext(
 	clientset *Clientset,
-	namespace, podName, containerName, containerImage string,
+	namespace, podName, containerName, containerImage, nodeName string,
 	labels map[string]string,
 	command []string,
 	containerSecurityContext *corev1.SecurityContext,
@@ -374,6 +421,7 @@ func NewContainerCreationExecContext(
 		podNamePrefix: podName,
 		podName:       podName,
 		containerName: containerName,
+		nodeName:      nodeName,
 		clientset:     clientset,
 	}
 
@@ -3,6 +3,7 @@
 package clients_test
 
 import (
+	"context"
 	"errors"
 	"net/url"
 
@@ -67,6 +68,55 @@ var _ = Describe("NewContainerContext", func() {
 	})
 })
 
+func newDaemonPod(name, nodeName string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "TestNamespace",
+			Labels:    map[string]string{"app": "linuxptp-daemon"},
+		},
+		Spec: v1.PodSpec{NodeName: nodeName},
+	}
+}
+
+var _ = Describe("NewContainerContextFromLabels", func() {
+	var clientset *clients.Clientset
+	BeforeEach(func() {
+		clientset = testutils.GetMockedClientSet(
+			notATestPod,
+			newDaemonPod("linuxptp-daemon-abcde", "node-a"),
+			newDaemonPod("linuxptp-daemon-fghij", "node-b"),
+		)
+	})
+
+	When("a node name is given", func() {
+		It("should return the context for the pod on that node", func() {
+			ctx, err := clients.NewContainerContextFromLabels(
+				clientset, "TestNamespace", "app=linuxptp-daemon", "node-b", "TestContainer",
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ctx.GetPodName()).To(Equal("linuxptp-daemon-fghij"))
+		})
+	})
+	When("no node name is given and several pods match", func() {
+		It("should return an error", func() {
+			_, err := clients.NewContainerContextFromLabels(
+				clientset, "TestNamespace", "app=linuxptp-daemon", "", "TestContainer",
+			)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("node-a"))
+		})
+	})
+	When("no pod matches the node", func() {
+		It("should return an error", func() {
+			_, err := clients.NewContainerContextFromLabels(
+				clientset, "TestNamespace", "app=linuxptp-daemon", "node-c", "TestContainer",
+			)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
 var _ = Describe("ExecCommandContainer", func() {
 	var clientset *clients.Clientset
 	BeforeEach(func() {
@@ -83,13 +133,30 @@ var _ = Describe("ExecCommandContainer", func() {
 			clients.NewSPDYExecutor = testutils.NewFakeNewSPDYExecutor(responder, nil)
 			ctx, _ := clients.NewContainerContext(clientset, "TestNamespace", "Test", "TestContainer")
 			cmd := []string{"my", "test", "command"}
-			stdout, stderr, err := ctx.ExecCommand(cmd)
+			stdout, stderr, err := ctx.ExecCommand(context.Background(), cmd)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(stdout).To(Equal(expectedStdOut))
 			Expect(stderr).To(Equal(expectedStdErr))
 		})
 	})
 
+	When("the context is cancelled", func() {
+		It("should return an error without running the command", func() {
+			called := false
+			responder := func(method string, url *url.URL, options remotecommand.StreamOptions) ([]byte, []byte, error) {
+				called = true
+				return []byte{}, []byte{}, nil
+			}
+			clients.NewSPDYExecutor = testutils.NewFakeNewSPDYExecutor(responder, nil)
+			ctx, _ := clients.NewContainerContext(clientset, "TestNamespace", "Test", "TestContainer")
+			cancelledCtx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, _, err := ctx.ExecCommand(cancelledCtx, []string{"my", "test", "command"})
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(called).To(BeFalse())
+		})
+	})
+
 	//nolint:dupl //it is incorrectly saying that this is a duplicate despite the aguments being in a different order
 	When("NewSPDYExecutor fails", func() {
 		It("should return an error", func() {
@@ -102,7 +169,7 @@ var _ = Describe("ExecCommandContainer", func() {
 			clients.NewSPDYExecutor = testutils.NewFakeNewSPDYExecutor(responder, expectedErr)
 			ctx, _ := clients.NewContainerContext(clientset, "TestNamespace", "Test", "TestContainer")
 			cmd := []string{"my", "test", "command"}
-			stdout, stderr, err := ctx.ExecCommand(cmd)
+			stdout, stderr, err := ctx.ExecCommand(context.Background(), cmd)
 			Expect(err).To(HaveOccurred())
 			Expect(expectedErr.Error()).To(ContainSubstring(expectedErr.Error()))
 			Expect(stdout).To(Equal(expectedStdOut))
@@ -121,7 +188,7 @@ var _ = Describe("ExecCommandContainer", func() {
 			clients.NewSPDYExecutor = testutils.NewFakeNewSPDYExecutor(responder, nil)
 			ctx, _ := clients.NewContainerContext(clientset, "TestNamespace", "Test", "TestContainer")
 			cmd := []string{"my", "test", "command"}
-			stdout, stderr, err := ctx.ExecCommand(cmd)
+			stdout, stderr, err := ctx.ExecCommand(context.Background(), cmd)
 			Expect(err).To(HaveOccurred())
 			Expect(expectedErr.Error()).To(ContainSubstring(expectedErr.Error()))
 			Expect(stdout).To(Equal(expectedStdOut))
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package clients
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LocalExecContext runs commands on the machine the tool is running on, for example
+// when it is run inside the linuxptp-daemon container or on a bare metal linuxptp host.
+type LocalExecContext struct{}
+
+func NewLocalExecContext() *LocalExecContext {
+	return &LocalExecContext{}
+}
+
+// streamCommand runs command with its stdin, stdout and stderr connected to those provided.
+// It returns once the command has exited or ctx is cancelled.
+func (c *LocalExecContext) streamCommand(
+	ctx context.Context,
+	command []string,
+	stdin io.Reader,
+	stdout, stderr io.Writer,
+) error {
+	if len(command) == 0 {
+		return errors.New("no command to run")
+	}
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...) //nolint:gosec // the commands come from the collectors
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// stdin is copied separately as exec would otherwise wait for
+	// stdin to be closed even after the command has exited
+	var stdinPipe io.WriteCloser
+	if stdin != nil {
+		var err error
+		stdinPipe, err = cmd.StdinPipe()
+		if err != nil {
+			return fmt.Errorf("failed to open stdin of local command: %w", err)
+		}
+	}
+	err := cmd.Start()
+	if err != nil {
+		return fmt.Errorf("error running local command: %w", err)
+	}
+	if stdinPipe != nil {
+		go copyStdin(stdinPipe, stdin)
+	}
+	err = cmd.Wait()
+	if err != nil {
+		return fmt.Errorf("error running local command: %w", err)
+	}
+	return nil
+}
+
+// copyStdin copies stdin to the command until either is closed
+func copyStdin(stdinPipe io.WriteCloser, stdin io.Reader) {
+	if _, err := io.Copy(stdinPipe, stdin); err != nil {
+		log.Debugf("stopped copying stdin: %s", err.Error())
+	}
+	stdinPipe.Close()
+}
+
+func (c *LocalExecContext) execCommand(
+	ctx context.Context,
+	command []string,
+	stdin io.Reader,
+) (stdout, stderr string, err error) {
+	log.Debugf("execute command locally, cmd: %s", strings.Join(command, " "))
+
+	var buffOut bytes.Buffer
+	var buffErr bytes.Buffer
+	err = c.streamCommand(ctx, command, stdin, &buffOut, &buffErr)
+	stdout, stderr = buffOut.String(), buffErr.String()
+	if err != nil {
+		log.Debug("stderr: ", stderr)
+		log.Debug("stdout: ", stdout)
+		return stdout, stderr, err
+	}
+	return stdout, stderr, nil
+}
+
+// ExecCommand runs command on the local machine and returns output buffers
+func (c *LocalExecContext) ExecCommand(ctx context.Context, command []string) (stdout, stderr string, err error) {
+	return c.execCommand(ctx, command, nil)
+}
+
+//nolint:lll // allow slightly long function definition
+func (c *LocalExecContext) ExecCommandStdIn(ctx context.Context, command []string, buffIn bytes.Buffer) (stdout, stderr string, err error) {
+	return c.execCommand(ctx, command, &buffIn)
+}
@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package clients_test
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+)
+
+var _ = Describe("LocalExecContext", func() {
+	When("a script is sent to the shell", func() {
+		It("should run it on the local machine", func() {
+			ctx := clients.NewLocalExecContext()
+			stdin := bytes.Buffer{}
+			stdin.WriteString("echo '<x>';echo hello;echo '</x>';echo oops >&2;")
+			stdout, stderr, err := ctx.ExecCommandStdIn(context.Background(), []string{"/bin/sh"}, stdin)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(Equal("<x>\nhello\n</x>\n"))
+			Expect(stderr).To(Equal("oops\n"))
+		})
+	})
+	When("the command fails", func() {
+		It("should return an error along with the outputs", func() {
+			ctx := clients.NewLocalExecContext()
+			stdout, _, err := ctx.ExecCommand(context.Background(), []string{"/bin/sh", "-c", "echo partial; exit 3"})
+			Expect(err).To(HaveOccurred())
+			Expect(stdout).To(Equal("partial\n"))
+		})
+	})
+})
@@ -0,0 +1,316 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package clients
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	sessionShell       = "/usr/bin/sh"
+	sessionTokenLength = 8
+)
+
+var errSessionEnded = errors.New("shell session ended")
+
+// streamer is implemented by the ExecContexts which can run a long lived command
+type streamer interface {
+	ExecContext
+	streamCommand(ctx context.Context, command []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+// shellSession is a shell which is kept running between commands. Each batch of commands is
+// written to its stdin and the outputs are read back up to delimiters which are unique to the batch.
+type shellSession struct {
+	stdin  *io.PipeWriter
+	stdout *bufio.Reader
+	stderr *bufio.Reader
+	close  func()
+	token  string
+	count  int
+}
+
+func newSessionToken() (string, error) {
+	token := make([]byte, sessionTokenLength)
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// startShellSession starts the shell in the background using base
+func startShellSession(base streamer) (*shellSession, error) {
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	stderrReader, stderrWriter := io.Pipe()
+
+	session := &shellSession{
+		stdin:  stdinWriter,
+		stdout: bufio.NewReader(stdoutReader),
+		stderr: bufio.NewReader(stderrReader),
+		token:  token,
+	}
+	// The pipes are closed before the stream is cancelled so that nothing is left waiting on them
+	var closeOnce sync.Once
+	session.close = func() {
+		closeOnce.Do(func() {
+			stdinWriter.CloseWithError(errSessionEnded)
+			stdinReader.CloseWithError(errSessionEnded)
+			stdoutReader.CloseWithError(errSessionEnded)
+			stderrReader.CloseWithError(errSessionEnded)
+			cancel()
+		})
+	}
+	go func() {
+		streamErr := base.streamCommand(ctx, []string{sessionShell}, stdinReader, stdoutWriter, stderrWriter)
+		if streamErr == nil {
+			streamErr = errSessionEnded
+		}
+		log.Debugf("shell session ended: %s", streamErr.Error())
+		stdoutWriter.CloseWithError(streamErr)
+		stderrWriter.CloseWithError(streamErr)
+		session.close()
+	}()
+	return session, nil
+}
+
+// readUntil reads up to a line starting with delimiter which follows the newline written before it.
+// It returns the output before that newline and the rest of the delimiter line.
+func readUntil(reader *bufio.Reader, delimiter string) (output, rest string, err error) {
+	var buff strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return buff.String(), "", fmt.Errorf("failed to read session output: %w", err)
+		}
+		if strings.HasPrefix(line, delimiter) {
+			// Remove the newline which was written before the delimiter
+			return strings.TrimSuffix(buff.String(), "\n"), strings.TrimSuffix(line[len(delimiter):], "\n"), nil
+		}
+		buff.WriteString(line)
+	}
+}
+
+// run runs script in a subshell which can not read the rest of the session from stdin.
+// err is only set if the session has failed, the exit code of the script is returned separately.
+func (session *shellSession) run(script string) (stdout, stderr string, exitCode int, err error) {
+	session.count++
+	delimiter := fmt.Sprintf("%s-%d", session.token, session.count)
+	if !strings.HasSuffix(script, "\n") {
+		script += "\n"
+	}
+	batch := fmt.Sprintf(
+		"(\n%s) </dev/null\nprintf '\\n%s:%%d\\n' \"$?\"\nprintf '\\n%s\\n' >&2\n",
+		script, delimiter, delimiter,
+	)
+
+	stderrDone := make(chan error, 1)
+	go func() {
+		var stderrErr error
+		stderr, _, stderrErr = readUntil(session.stderr, delimiter+"\n")
+		stderrDone <- stderrErr
+	}()
+
+	_, err = io.WriteString(session.stdin, batch)
+	if err != nil {
+		session.close()
+		<-stderrDone
+		return "", "", 0, fmt.Errorf("failed to write to session: %w", err)
+	}
+	stdout, exitCodeStr, err := readUntil(session.stdout, delimiter+":")
+	if err != nil {
+		session.close()
+		<-stderrDone
+		return stdout, stderr, 0, err
+	}
+	err = <-stderrDone
+	if err != nil {
+		session.close()
+		return stdout, stderr, 0, err
+	}
+	exitCode, err = strconv.Atoi(exitCodeStr)
+	if err != nil {
+		session.close()
+		return stdout, stderr, 0, fmt.Errorf("failed to read exit code from session: %w", err)
+	}
+	return stdout, stderr, exitCode, nil
+}
+
+// PersistentExecContext runs commands through a shell which is kept running between polls rather than
+// starting a new exec for each one. If the shell fails it is restarted the next time a command is run.
+// Commands are run one at a time so each collector should have its own PersistentExecContext.
+type PersistentExecContext struct {
+	base    streamer
+	session *shellSession
+	lock    sync.Mutex
+}
+
+func newPersistentExecContext(base streamer) *PersistentExecContext {
+	return &PersistentExecContext{base: base}
+}
+
+// getSession returns the running shell, starting a new one if needed
+func (c *PersistentExecContext) getSession() (*shellSession, error) {
+	if c.session != nil {
+		return c.session, nil
+	}
+	session, err := startShellSession(c.base)
+	if err != nil {
+		return nil, err
+	}
+	c.session = session
+	return session, nil
+}
+
+func (c *PersistentExecContext) closeSession() {
+	if c.session != nil {
+		c.session.close()
+		c.session = nil
+	}
+}
+
+// runScript runs script in the session, if the session has failed it is restarted and the script is run once more
+func (c *PersistentExecContext) runScript(ctx context.Context, script string) (stdout, stderr string, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var exitCode int
+	for attempt := 0; attempt < 2; attempt++ {
+		session, sessionErr := c.getSession()
+		if sessionErr != nil {
+			return "", "", sessionErr
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			stdout, stderr, exitCode, err = session.run(script)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			// The script can not be stopped on its own so the whole session is closed
+			c.closeSession()
+			<-done
+			return stdout, stderr, fmt.Errorf("error running command in session: %w", ctx.Err())
+		}
+		if err == nil {
+			break
+		}
+		log.Debugf("shell session failed, restarting: %s", err.Error())
+		c.closeSession()
+	}
+	if err != nil {
+		return stdout, stderr, fmt.Errorf("error running command in session: %w", err)
+	}
+	if exitCode != 0 {
+		log.Debug("stderr: ", stderr)
+		log.Debug("stdout: ", stdout)
+		return stdout, stderr, fmt.Errorf("command terminated with exit code %d", exitCode)
+	}
+	return stdout, stderr, nil
+}
+
+// ExecCommand runs command in the session and returns output buffers
+func (c *PersistentExecContext) ExecCommand(ctx context.Context, command []string) (stdout, stderr string, err error) {
+	return c.runScript(ctx, quoteCommand(command))
+}
+
+// ExecCommandStdIn runs the script in buffIn in the session if command is the shell,
+// anything else is run by the wrapped ExecContext
+//
+//nolint:lll // allow slightly long function definition
+func (c *PersistentExecContext) ExecCommandStdIn(ctx context.Context, command []string, buffIn bytes.Buffer) (stdout, stderr string, err error) {
+	if len(command) != 1 || command[0] != sessionShell {
+		return c.base.ExecCommandStdIn(ctx, command, buffIn) //nolint:wrapcheck // the error is from the wrapped ExecContext
+	}
+	return c.runScript(ctx, buffIn.String())
+}
+
+// Close stops the shell
+func (c *PersistentExecContext) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.closeSession()
+	return nil
+}
+
+// The target is passed through so that recorded commands show where they were run
+
+func (c *PersistentExecContext) GetNamespace() string {
+	if target, ok := c.base.(execTarget); ok {
+		return target.GetNamespace()
+	}
+	return ""
+}
+
+func (c *PersistentExecContext) GetPodName() string {
+	if target, ok := c.base.(execTarget); ok {
+		return target.GetPodName()
+	}
+	return ""
+}
+
+func (c *PersistentExecContext) GetContainerName() string {
+	if target, ok := c.base.(execTarget); ok {
+		return target.GetContainerName()
+	}
+	return ""
+}
+
+// PersistentSessions wraps ExecContexts in a PersistentExecContext and closes them all at the end of the run.
+// A nil *PersistentSessions leaves the ExecContexts unwrapped.
+type PersistentSessions struct {
+	contexts []*PersistentExecContext
+	lock     sync.Mutex
+}
+
+func NewPersistentSessions() *PersistentSessions {
+	return &PersistentSessions{}
+}
+
+// Wrap returns a PersistentExecContext for execCtx if it can run a long lived shell, otherwise execCtx
+//
+//nolint:ireturn // the type of context depends on what is wrapped
+func (sessions *PersistentSessions) Wrap(execCtx ExecContext) ExecContext {
+	if sessions == nil {
+		return execCtx
+	}
+	base, ok := execCtx.(streamer)
+	if !ok {
+		return execCtx
+	}
+	persistentCtx := newPersistentExecContext(base)
+	sessions.lock.Lock()
+	defer sessions.lock.Unlock()
+	sessions.contexts = append(sessions.contexts, persistentCtx)
+	return persistentCtx
+}
+
+// Close stops every shell which has been started
+func (sessions *PersistentSessions) Close() error {
+	sessions.lock.Lock()
+	defer sessions.lock.Unlock()
+	for _, persistentCtx := range sessions.contexts {
+		persistentCtx.Close() //nolint:errcheck // closing a session can not fail
+	}
+	sessions.contexts = nil
+	return nil
+}
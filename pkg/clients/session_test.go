@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package clients_test
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+)
+
+func runInShell(execCtx clients.ExecContext, script string) (string, string, error) {
+	stdin := bytes.Buffer{}
+	stdin.WriteString(script)
+	return execCtx.ExecCommandStdIn(context.Background(), []string{"/usr/bin/sh"}, stdin)
+}
+
+var _ = Describe("PersistentExecContext", func() {
+	var (
+		sessions *clients.PersistentSessions
+		execCtx  clients.ExecContext
+	)
+	BeforeEach(func() {
+		sessions = clients.NewPersistentSessions()
+		execCtx = sessions.Wrap(clients.NewLocalExecContext())
+		Expect(execCtx).To(BeAssignableToTypeOf(&clients.PersistentExecContext{}))
+	})
+	AfterEach(func() {
+		Expect(sessions.Close()).To(Succeed())
+	})
+
+	When("several scripts are run", func() {
+		It("should run them all in the same shell and split their outputs", func() {
+			firstPID, _, err := runInShell(execCtx, "echo $$")
+			Expect(err).NotTo(HaveOccurred())
+			stdout, stderr, err := runInShell(execCtx, "echo '<x>';echo hello;echo '</x>';printf oops >&2;")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(Equal("<x>\nhello\n</x>\n"))
+			Expect(stderr).To(Equal("oops"))
+
+			stdout, _, err = runInShell(execCtx, "printf abc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(Equal("abc"))
+
+			secondPID, _, err := runInShell(execCtx, "echo $$")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secondPID).To(Equal(firstPID))
+		})
+		It("should not let a script read the following scripts", func() {
+			stdout, _, err := runInShell(execCtx, "cat; echo done")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(Equal("done\n"))
+			stdout, _, err = execCtx.ExecCommand(context.Background(), []string{"echo", "it's next"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(Equal("it's next\n"))
+		})
+	})
+	When("a script fails", func() {
+		It("should return an error along with the output and keep the shell", func() {
+			firstPID, _, err := runInShell(execCtx, "echo $$")
+			Expect(err).NotTo(HaveOccurred())
+			stdout, _, err := runInShell(execCtx, "echo partial; exit 3")
+			Expect(err).To(MatchError(ContainSubstring("exit code 3")))
+			Expect(stdout).To(Equal("partial\n"))
+			secondPID, _, err := runInShell(execCtx, "echo $$")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secondPID).To(Equal(firstPID))
+		})
+	})
+	When("the shell dies", func() {
+		It("should start a new one for the next script", func() {
+			firstPID, _, err := runInShell(execCtx, "echo $$")
+			Expect(err).NotTo(HaveOccurred())
+			_, _, err = runInShell(execCtx, "kill -9 $$")
+			Expect(err).To(HaveOccurred())
+			secondPID, _, err := runInShell(execCtx, "echo $$")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secondPID).NotTo(Equal(firstPID))
+		})
+	})
+	When("the context is cancelled", func() {
+		It("should return straight away and run the next script in a new shell", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			stdin := bytes.Buffer{}
+			stdin.WriteString("sleep 10")
+			start := time.Now()
+			_, _, err := execCtx.ExecCommandStdIn(ctx, []string{"/usr/bin/sh"}, stdin)
+			Expect(err).To(HaveOccurred())
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+
+			stdout, _, err := runInShell(execCtx, "echo ok")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(Equal("ok\n"))
+		})
+	})
+})
+
+var _ = Describe("PersistentSessions", func() {
+	When("it is nil", func() {
+		It("should leave the context unwrapped", func() {
+			var sessions *clients.PersistentSessions
+			localCtx := clients.NewLocalExecContext()
+			Expect(sessions.Wrap(localCtx)).To(BeIdenticalTo(localCtx))
+		})
+	})
+})
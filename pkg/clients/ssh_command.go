@@ -0,0 +1,365 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package clients
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	defaultSSHPort    = "22"
+	sshDialTimeout    = 10 * time.Second
+	sshKnownHostsFile = "known_hosts"
+)
+
+// defaultIdentityFiles are the keys tried, if they exist, when no identity file is given
+var defaultIdentityFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// safeShellWord matches arguments which do not need quoting to be passed through a shell
+var safeShellWord = regexp.MustCompile(`^[A-Za-z0-9_./=:,@%+-]+$`)
+
+// SSHOptions describes how to authenticate against a SSH host
+type SSHOptions struct {
+	// IdentityFile is a private key, if empty ~/.ssh/id_ed25519, id_ecdsa and id_rsa are tried
+	IdentityFile string
+	// KnownHostsFile is checked for the host key, if empty ~/.ssh/known_hosts is used
+	KnownHostsFile string
+}
+
+// SSHExecContext runs commands on a host over SSH, for example a linuxptp host which
+// is not part of a kubernetes cluster. Only key based authentication is supported
+// and the host key must already be in the known_hosts file.
+type SSHExecContext struct {
+	client    *ssh.Client
+	config    *ssh.ClientConfig
+	agentConn net.Conn
+	address   string
+	host      string
+	lock      sync.Mutex
+}
+
+// ParseSSHTarget splits a target of the form [user@]host[:port] into the user and the address to dial.
+// If no user is given the current user is used.
+func ParseSSHTarget(target string) (username, address string, err error) {
+	username, hostPort, found := strings.Cut(target, "@")
+	if !found {
+		hostPort = username
+		currentUser, userErr := user.Current()
+		if userErr != nil {
+			return "", "", fmt.Errorf("failed to find the current user: %w", userErr)
+		}
+		username = currentUser.Username
+	}
+	if username == "" || hostPort == "" {
+		return "", "", fmt.Errorf("ssh target %s must be of the form [user@]host[:port]", target)
+	}
+	if _, _, splitErr := net.SplitHostPort(hostPort); splitErr != nil {
+		hostPort = net.JoinHostPort(strings.Trim(hostPort, "[]"), defaultSSHPort)
+	}
+	return username, hostPort, nil
+}
+
+func getSSHDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".ssh"), nil
+}
+
+// getAgentSigners returns the keys held by a running ssh-agent along with the connection to it,
+// which must be kept open for as long as the keys may be used and then closed
+func getAgentSigners() ([]ssh.Signer, net.Conn) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, nil
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		log.Debugf("could not connect to ssh-agent: %s", err.Error())
+		return nil, nil
+	}
+	agentSigners, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		log.Debugf("could not read keys from ssh-agent: %s", err.Error())
+		conn.Close()
+		return nil, nil
+	}
+	return agentSigners, conn
+}
+
+// getFileSigners returns the key in identityFile or, if it is empty, those default keys which can be used.
+// A default key which can not be read or parsed, for example because it is protected by a passphrase,
+// is skipped as it may be held by ssh-agent instead.
+func getFileSigners(identityFile string) ([]ssh.Signer, error) {
+	if identityFile != "" {
+		content, err := os.ReadFile(identityFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ssh key %s: %w", identityFile, err)
+		}
+		signer, err := ssh.ParsePrivateKey(content)
+		if err != nil {
+			// Keys protected by a passphrase can be used through ssh-agent
+			return nil, fmt.Errorf("failed to parse ssh key %s: %w", identityFile, err)
+		}
+		return []ssh.Signer{signer}, nil
+	}
+
+	sshDir, err := getSSHDir()
+	if err != nil {
+		return nil, err
+	}
+	signers := make([]ssh.Signer, 0, len(defaultIdentityFiles))
+	for _, name := range defaultIdentityFiles {
+		path := filepath.Join(sshDir, name)
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			log.Debugf("skipping ssh key %s which could not be read: %s", path, err.Error())
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(content)
+		if err != nil {
+			log.Debugf("skipping ssh key %s which could not be parsed: %s", path, err.Error())
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
+// getSigners returns the keys to authenticate with, those held by a running ssh-agent are tried first.
+// If ssh-agent is used the connection to it is returned so that it can be closed with the SSH connection.
+func getSigners(identityFile string) ([]ssh.Signer, net.Conn, error) {
+	signers, agentConn := getAgentSigners()
+	fileSigners, err := getFileSigners(identityFile)
+	if err == nil && len(signers)+len(fileSigners) == 0 {
+		err = errors.New("no ssh keys found, provide an identity file or start ssh-agent")
+	}
+	if err != nil {
+		if agentConn != nil {
+			agentConn.Close()
+		}
+		return nil, nil, err
+	}
+	return append(signers, fileSigners...), agentConn, nil
+}
+
+// NewSSHExecContext connects to target, of the form [user@]host[:port], checking the host key against known_hosts
+func NewSSHExecContext(target string, options SSHOptions) (*SSHExecContext, error) {
+	username, address, err := ParseSSHTarget(target)
+	if err != nil {
+		return &SSHExecContext{}, err
+	}
+	knownHostsFile := options.KnownHostsFile
+	if knownHostsFile == "" {
+		sshDir, dirErr := getSSHDir()
+		if dirErr != nil {
+			return &SSHExecContext{}, dirErr
+		}
+		knownHostsFile = filepath.Join(sshDir, sshKnownHostsFile)
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return &SSHExecContext{}, fmt.Errorf("failed to read known hosts %s: %w", knownHostsFile, err)
+	}
+	signers, agentConn, err := getSigners(options.IdentityFile)
+	if err != nil {
+		return &SSHExecContext{}, err
+	}
+
+	host, _, _ := net.SplitHostPort(address)
+	c := &SSHExecContext{
+		address:   address,
+		host:      host,
+		agentConn: agentConn,
+		config: &ssh.ClientConfig{
+			User:            username,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         sshDialTimeout,
+		},
+	}
+	err = c.connect()
+	if err != nil {
+		if agentConn != nil {
+			agentConn.Close()
+		}
+		return &SSHExecContext{}, err
+	}
+	return c, nil
+}
+
+// GetHost returns the host the commands are run on
+func (c *SSHExecContext) GetHost() string {
+	return c.host
+}
+
+func (c *SSHExecContext) connect() error {
+	client, err := ssh.Dial("tcp", c.address, c.config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s@%s: %w", c.config.User, c.address, err)
+	}
+	c.client = client
+	return nil
+}
+
+// newSession opens a session on the existing connection,
+// reconnecting once if the connection has been lost
+func (c *SSHExecContext) newSession() (*ssh.Session, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	session, err := c.client.NewSession()
+	if err == nil {
+		return session, nil
+	}
+	log.Debugf("failed to open ssh session, reconnecting: %s", err.Error())
+	c.client.Close()
+	err = c.connect()
+	if err != nil {
+		return nil, err
+	}
+	session, err = c.client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ssh session: %w", err)
+	}
+	return session, nil
+}
+
+// quoteCommand joins the command into a single line which the remote shell splits back into the same arguments
+func quoteCommand(command []string) string {
+	quoted := make([]string, 0, len(command))
+	for _, arg := range command {
+		if safeShellWord.MatchString(arg) {
+			quoted = append(quoted, arg)
+		} else {
+			quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// streamCommand runs command on the host with its stdin, stdout and stderr connected to those provided.
+// It returns once the command has exited or ctx is cancelled.
+func (c *SSHExecContext) streamCommand(
+	ctx context.Context,
+	command []string,
+	stdin io.Reader,
+	stdout, stderr io.Writer,
+) error {
+	if len(command) == 0 {
+		return errors.New("no command to run")
+	}
+	session, err := c.newSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	session.Stdout = stdout
+	session.Stderr = stderr
+	// stdin is copied separately as the session would otherwise wait for
+	// stdin to be closed even after the command has exited
+	var stdinPipe io.WriteCloser
+	if stdin != nil {
+		stdinPipe, err = session.StdinPipe()
+		if err != nil {
+			return fmt.Errorf("failed to open stdin of ssh command: %w", err)
+		}
+	}
+	err = session.Start(quoteCommand(command))
+	if err != nil {
+		return fmt.Errorf("error running ssh command: %w", err)
+	}
+	if stdinPipe != nil {
+		go copyStdin(stdinPipe, stdin)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// Closing the session stops the command even if the server ignores the signal
+		if signalErr := session.Signal(ssh.SIGKILL); signalErr != nil {
+			log.Debugf("failed to signal ssh command: %s", signalErr.Error())
+		}
+		session.Close()
+		<-done
+		err = ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("error running ssh command: %w", err)
+	}
+	return nil
+}
+
+func (c *SSHExecContext) execCommand(
+	ctx context.Context,
+	command []string,
+	stdin io.Reader,
+) (stdout, stderr string, err error) {
+	log.Debugf("execute command on %s over ssh, cmd: %s", c.host, strings.Join(command, " "))
+
+	var buffOut bytes.Buffer
+	var buffErr bytes.Buffer
+	err = c.streamCommand(ctx, command, stdin, &buffOut, &buffErr)
+	stdout, stderr = buffOut.String(), buffErr.String()
+	if err != nil {
+		log.Debug("stderr: ", stderr)
+		log.Debug("stdout: ", stdout)
+		return stdout, stderr, err
+	}
+	return stdout, stderr, nil
+}
+
+// ExecCommand runs command on the SSH host and returns output buffers
+func (c *SSHExecContext) ExecCommand(ctx context.Context, command []string) (stdout, stderr string, err error) {
+	return c.execCommand(ctx, command, nil)
+}
+
+//nolint:lll // allow slightly long function definition
+func (c *SSHExecContext) ExecCommandStdIn(ctx context.Context, command []string, buffIn bytes.Buffer) (stdout, stderr string, err error) {
+	return c.execCommand(ctx, command, &buffIn)
+}
+
+// Close closes the connection to the SSH host and to ssh-agent
+func (c *SSHExecContext) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.agentConn != nil {
+		if err := c.agentConn.Close(); err != nil {
+			log.Debugf("failed to close connection to ssh-agent: %s", err.Error())
+		}
+		c.agentConn = nil
+	}
+	if c.client == nil {
+		return nil
+	}
+	err := c.client.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close ssh connection: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package clients_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+)
+
+// serveSSH accepts connections authenticated by clientKey and runs each exec request with /bin/sh
+func serveSSH(listener net.Listener, hostKey ssh.Signer, clientKey ssh.PublicKey) {
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return &ssh.Permissions{}, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	config.AddHostKey(hostKey)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			_, channels, requests, err := ssh.NewServerConn(conn, config)
+			if err != nil {
+				return
+			}
+			go ssh.DiscardRequests(requests)
+			for newChannel := range channels {
+				channel, channelRequests, err := newChannel.Accept()
+				if err != nil {
+					continue
+				}
+				go func() {
+					defer channel.Close()
+					for req := range channelRequests {
+						if req.Type != "exec" {
+							_ = req.Reply(false, nil)
+							continue
+						}
+						_ = req.Reply(true, nil)
+						cmd := exec.Command("/bin/sh", "-c", string(req.Payload[4:]))
+						cmd.Stdin = channel
+						cmd.Stdout = channel
+						cmd.Stderr = channel.Stderr()
+						status := make([]byte, 4) //nolint:gomnd // exit-status is a uint32
+						if err := cmd.Run(); err != nil {
+							binary.BigEndian.PutUint32(status, 1)
+						}
+						_, _ = channel.SendRequest("exit-status", false, status)
+						return
+					}
+				}()
+			}
+		}()
+	}
+}
+
+var _ = Describe("SSHExecContext", func() {
+	var (
+		listener   net.Listener
+		clientPriv ed25519.PrivateKey
+		keyFile    string
+		knownHosts string
+		target     string
+	)
+	BeforeEach(func() {
+		os.Unsetenv("SSH_AUTH_SOCK")
+		dir := GinkgoT().TempDir()
+
+		_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		hostKey, err := ssh.NewSignerFromKey(hostPriv)
+		Expect(err).NotTo(HaveOccurred())
+		var clientPub ed25519.PublicKey
+		clientPub, clientPriv, err = ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		clientKey, err := ssh.NewPublicKey(clientPub)
+		Expect(err).NotTo(HaveOccurred())
+
+		block, err := ssh.MarshalPrivateKey(clientPriv, "")
+		Expect(err).NotTo(HaveOccurred())
+		keyFile = filepath.Join(dir, "id_ed25519")
+		Expect(os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600)).To(Succeed())
+
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		go serveSSH(listener, hostKey, clientKey)
+		target = "core@" + listener.Addr().String()
+
+		knownHosts = filepath.Join(dir, "known_hosts")
+		line := knownhosts.Line([]string{knownhosts.Normalize(listener.Addr().String())}, hostKey.PublicKey())
+		Expect(os.WriteFile(knownHosts, []byte(line+"\n"), 0600)).To(Succeed())
+	})
+	AfterEach(func() {
+		listener.Close()
+	})
+
+	When("the host key is known", func() {
+		It("should run the script sent to the shell on the host", func() {
+			sshCtx, err := clients.NewSSHExecContext(target, clients.SSHOptions{
+				IdentityFile:   keyFile,
+				KnownHostsFile: knownHosts,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer sshCtx.Close()
+			Expect(sshCtx.GetHost()).To(Equal("127.0.0.1"))
+
+			stdin := bytes.Buffer{}
+			stdin.WriteString("echo '<x>';echo hello;echo '</x>';echo oops >&2;")
+			stdout, stderr, err := sshCtx.ExecCommandStdIn(context.Background(), []string{"/usr/bin/sh"}, stdin)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(Equal("<x>\nhello\n</x>\n"))
+			Expect(stderr).To(Equal("oops\n"))
+
+			stdout, _, err = sshCtx.ExecCommand(context.Background(), []string{"echo", "it's quoted"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(Equal("it's quoted\n"))
+
+			_, _, err = sshCtx.ExecCommand(context.Background(), []string{"false"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	When("no identity file is given", func() {
+		var sshDir string
+		BeforeEach(func() {
+			home := GinkgoT().TempDir()
+			sshDir = filepath.Join(home, ".ssh")
+			Expect(os.Mkdir(sshDir, 0700)).To(Succeed())
+			originalHome := os.Getenv("HOME")
+			os.Setenv("HOME", home)
+			DeferCleanup(func() { os.Setenv("HOME", originalHome) })
+		})
+		It("should skip default keys which can not be parsed", func() {
+			protected, err := ssh.MarshalPrivateKeyWithPassphrase(clientPriv, "", []byte("secret"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(sshDir, "id_ed25519"), pem.EncodeToMemory(protected), 0600)).To(Succeed())
+			key, err := os.ReadFile(keyFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(sshDir, "id_rsa"), key, 0600)).To(Succeed())
+
+			sshCtx, err := clients.NewSSHExecContext(target, clients.SSHOptions{KnownHostsFile: knownHosts})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sshCtx.Close()).To(Succeed())
+		})
+		It("should use the keys held by ssh-agent and close the connection to it", func() {
+			keyring := agent.NewKeyring()
+			Expect(keyring.Add(agent.AddedKey{PrivateKey: clientPriv})).To(Succeed())
+			socket := filepath.Join(sshDir, "agent.sock")
+			agentListener, err := net.Listen("unix", socket)
+			Expect(err).NotTo(HaveOccurred())
+			defer agentListener.Close()
+			served := make(chan struct{})
+			go func() {
+				defer close(served)
+				conn, err := agentListener.Accept()
+				if err != nil {
+					return
+				}
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+			os.Setenv("SSH_AUTH_SOCK", socket)
+			defer os.Unsetenv("SSH_AUTH_SOCK")
+
+			sshCtx, err := clients.NewSSHExecContext(target, clients.SSHOptions{KnownHostsFile: knownHosts})
+			Expect(err).NotTo(HaveOccurred())
+			Consistently(served).ShouldNot(BeClosed())
+			Expect(sshCtx.Close()).To(Succeed())
+			Eventually(served).Should(BeClosed())
+		})
+	})
+	When("the identity file can not be parsed", func() {
+		It("should return an error", func() {
+			protected, err := ssh.MarshalPrivateKeyWithPassphrase(clientPriv, "", []byte("secret"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(keyFile, pem.EncodeToMemory(protected), 0600)).To(Succeed())
+			_, err = clients.NewSSHExecContext(target, clients.SSHOptions{
+				IdentityFile:   keyFile,
+				KnownHostsFile: knownHosts,
+			})
+			Expect(err).To(MatchError(ContainSubstring("failed to parse ssh key")))
+		})
+	})
+	When("the host key is not in known_hosts", func() {
+		It("should refuse to connect", func() {
+			Expect(os.WriteFile(knownHosts, []byte{}, 0600)).To(Succeed())
+			_, err := clients.NewSSHExecContext(target, clients.SSHOptions{
+				IdentityFile:   keyFile,
+				KnownHostsFile: knownHosts,
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("ParseSSHTarget", func() {
+	It("should add the default port", func() {
+		username, address, err := clients.ParseSSHTarget("core@ptp-host")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(username).To(Equal("core"))
+		Expect(address).To(Equal("ptp-host:22"))
+
+		_, address, err = clients.ParseSSHTarget("core@ptp-host:2222")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(address).To(Equal("ptp-host:2222"))
+	})
+	It("should reject an empty user", func() {
+		_, _, err := clients.ParseSSHTarget("@ptp-host")
+		Expect(err).To(HaveOccurred())
+	})
+})
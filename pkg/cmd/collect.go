@@ -3,18 +3,15 @@
 package cmd
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
-	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/runner"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
 )
@@ -22,6 +19,9 @@ import (
 const (
 	defaultDuration             string = "1000s"
 	defaultPollInterval         int    = 1
+	defaultPollTimeout          int    = 30
+	defaultMaxBackoff           int    = 300
+	defaultDisableAfter         int    = 0
 	defaultDevInfoInterval      int    = 60
 	defaultIncludeLogTimestamps bool   = false
 	defaultTempDir              string = "."
@@ -32,76 +32,175 @@ const (
 var (
 	requestedDurationStr   string
 	pollInterval           int
+	pollTimeout            int
+	maxBackoff             int
+	disableAfter           int
 	devInfoAnnouceInterval int
 	collectorNames         []string
+	collectorSpecs         []string
+	stopConditions         []string
 	logsOutputFile         string
+	captureOutputFile      string
 	includeLogTimestamps   bool
 	tempDir                string
 	keepDebugFiles         bool
+	runConfigFile          string
+	metricsListen          string
+	dryRun                 bool
+	persistentSessions     bool
 )
 
+// newRunConfigFromFlags returns a RunConfig populated with the current values of the flags
+func newRunConfigFromFlags() *runner.RunConfig {
+	runCfg := &runner.RunConfig{
+		PTPInterfaces:           ptpInterfaces,
+		NodeName:                nodeName,
+		Duration:                requestedDurationStr,
+		PollInterval:            pollInterval,
+		PollTimeout:             pollTimeout,
+		MaxBackoff:              maxBackoff,
+		DisableAfterFailures:    disableAfter,
+		DevInfoAnnounceInterval: devInfoAnnouceInterval,
+		Collectors:              collectorNames,
+		CollectorSpecs:          collectorSpecs,
+		StopOn:                  stopConditions,
+		TempDir:                 tempDir,
+		KeepDebugFiles:          keepDebugFiles,
+		PersistentSessions:      persistentSessions,
+		Local:                   local,
+		SSH:                     sshTarget,
+		SSHIdentityFile:         sshKey,
+		SSHKnownHostsFile:       sshKnownHosts,
+		MetricsListen:           metricsListen,
+		Output: runner.OutputConfig{
+			File:                 outputFile,
+			UseAnalyserJSON:      useAnalyserJSON,
+			LogsFile:             logsOutputFile,
+			CaptureFile:          captureOutputFile,
+			IncludeLogTimestamps: includeLogTimestamps,
+		},
+	}
+	if len(kubeConfigs) == 1 {
+		runCfg.KubeConfig = kubeConfigs[0]
+	} else {
+		for _, path := range kubeConfigs {
+			runCfg.Clusters = append(runCfg.Clusters, runner.ClusterConfig{KubeConfig: path})
+		}
+	}
+	return runCfg
+}
+
+// buildRunConfig layers the flags which were explicitly set by the user
+// on top of the run config file if one was provided.
+func buildRunConfig(cmd *cobra.Command) (*runner.RunConfig, error) {
+	runCfg := newRunConfigFromFlags()
+	if runConfigFile == "" {
+		return runCfg, runCfg.Validate()
+	}
+
+	err := runner.LoadRunConfig(runConfigFile, runCfg)
+	if err != nil {
+		return runCfg, err //nolint:wrapcheck // this returns a wrapped error
+	}
+
+	flagCfg := newRunConfigFromFlags()
+	overrides := map[string]func(){
+		"kubeconfig": func() {
+			runCfg.KubeConfig = flagCfg.KubeConfig
+			runCfg.Clusters = flagCfg.Clusters
+		},
+		"interface":           func() { runCfg.PTPInterfaces = flagCfg.PTPInterfaces },
+		"node":                func() { runCfg.NodeName = flagCfg.NodeName },
+		"local":               func() { runCfg.Local = flagCfg.Local },
+		"ssh":                 func() { runCfg.SSH = flagCfg.SSH },
+		"ssh-key":             func() { runCfg.SSHIdentityFile = flagCfg.SSHIdentityFile },
+		"ssh-known-hosts":     func() { runCfg.SSHKnownHostsFile = flagCfg.SSHKnownHostsFile },
+		"duration":            func() { runCfg.Duration = flagCfg.Duration },
+		"rate":                func() { runCfg.PollInterval = flagCfg.PollInterval },
+		"poll-timeout":        func() { runCfg.PollTimeout = flagCfg.PollTimeout },
+		"max-backoff":         func() { runCfg.MaxBackoff = flagCfg.MaxBackoff },
+		"disable-after":       func() { runCfg.DisableAfterFailures = flagCfg.DisableAfterFailures },
+		"announce":            func() { runCfg.DevInfoAnnounceInterval = flagCfg.DevInfoAnnounceInterval },
+		"collector":           func() { runCfg.Collectors = flagCfg.Collectors },
+		"collector-spec":      func() { runCfg.CollectorSpecs = flagCfg.CollectorSpecs },
+		"stop-on":             func() { runCfg.StopOn = flagCfg.StopOn },
+		"tempdir":             func() { runCfg.TempDir = flagCfg.TempDir },
+		"keep":                func() { runCfg.KeepDebugFiles = flagCfg.KeepDebugFiles },
+		"persistent-sessions": func() { runCfg.PersistentSessions = flagCfg.PersistentSessions },
+		"metrics-listen":      func() { runCfg.MetricsListen = flagCfg.MetricsListen },
+		"output":              func() { runCfg.Output.File = flagCfg.Output.File },
+		"use-analyser-format": func() { runCfg.Output.UseAnalyserJSON = flagCfg.Output.UseAnalyserJSON },
+		"logs-output":         func() { runCfg.Output.LogsFile = flagCfg.Output.LogsFile },
+		"capture":             func() { runCfg.Output.CaptureFile = flagCfg.Output.CaptureFile },
+		"log-timestamps":      func() { runCfg.Output.IncludeLogTimestamps = flagCfg.Output.IncludeLogTimestamps },
+	}
+	for name, override := range overrides {
+		if cmd.Flags().Changed(name) {
+			override()
+		}
+	}
+	return runCfg, runCfg.Validate()
+}
+
+// expandTempDir resolves a leading ~ in the tempdir to the current users home directory
+func expandTempDir(dir string) string {
+	if !strings.Contains(dir, "~") {
+		return dir
+	}
+	usr, err := user.Current()
+	if err != nil {
+		log.Fatal("Failed to fetch current user so could not resolve tempdir")
+	}
+	if dir == "~" {
+		return usr.HomeDir
+	} else if strings.HasPrefix(dir, "~/") {
+		return filepath.Join(usr.HomeDir, dir[2:])
+	}
+	return dir
+}
+
 // collectCmd represents the collect command
 var collectCmd = &cobra.Command{
 	Use:   "collect",
 	Short: "Run the collector tool",
-	Long:  `Run the collector tool to gather data from your target cluster`,
+	Long:  `Run the collector tool to gather data from your target cluster(s)`,
 	Run: func(cmd *cobra.Command, args []string) {
-		collectionRunner := runner.NewCollectorRunner(collectorNames)
-
-		requestedDuration, err := time.ParseDuration(requestedDurationStr)
-		if requestedDuration.Nanoseconds() < 0 {
-			log.Panicf("Requested duration must be positive")
-		}
+		runCfg, err := buildRunConfig(cmd)
 		utils.IfErrorExitOrPanic(err)
 
-		for _, c := range collectorNames {
-			if (c == collectors.LogsCollectorName || c == runner.All) && logsOutputFile == "" {
-				utils.IfErrorExitOrPanic(utils.NewMissingInputError(
-					errors.New("if Logs collector is selected you must also provide a log output file")),
-				)
-			}
-		}
-
-		if strings.Contains(tempDir, "~") {
-			usr, err := user.Current()
-			if err != nil {
-				log.Fatal("Failed to fetch current user so could not resolve tempdir")
-			}
-			if tempDir == "~" {
-				tempDir = usr.HomeDir
-			} else if strings.HasPrefix(tempDir, "~/") {
-				tempDir = filepath.Join(usr.HomeDir, tempDir[2:])
-			}
+		if dryRun {
+			err = runner.DryRun(runCfg, os.Stdout)
+			utils.IfErrorExitOrPanic(err)
+			return
 		}
 
-		if err := os.MkdirAll(tempDir, tempdirPerm); err != nil {
+		runCfg.TempDir = expandTempDir(runCfg.TempDir)
+		if err := os.MkdirAll(runCfg.TempDir, tempdirPerm); err != nil {
 			log.Fatal(err)
 		}
 
-		collectionRunner.Run(
-			kubeConfig,
-			outputFile,
-			requestedDuration,
-			pollInterval,
-			devInfoAnnouceInterval,
-			ptpInterface,
-			useAnalyserJSON,
-			logsOutputFile,
-			includeLogTimestamps,
-			tempDir,
-			keepDebugFiles,
-		)
+		runner.Run(runCfg)
 	},
 }
 
 func init() { //nolint:funlen // Allow this to get a little long
 	rootCmd.AddCommand(collectCmd)
 
-	AddKubeconfigFlag(collectCmd)
+	AddKubeconfigsFlag(collectCmd)
 	AddOutputFlag(collectCmd)
 	AddFormatFlag(collectCmd)
 	AddInterfaceFlag(collectCmd)
+	AddNodeFlag(collectCmd)
+	AddLocalFlag(collectCmd)
+	AddSSHFlags(collectCmd)
 
+	collectCmd.Flags().StringVarP(
+		&runConfigFile,
+		"config",
+		"c",
+		"",
+		"Path to a YAML or JSON run config file. Flags which are explicitly set take precedence over the file.",
+	)
 	collectCmd.Flags().StringVarP(
 		&requestedDurationStr,
 		"duration",
@@ -118,6 +217,24 @@ func init() { //nolint:funlen // Allow this to get a little long
 		"Poll interval for querying the cluster. The value will be polled once every interval. "+
 			"Using --rate 10 will cause the value to be polled once every 10 seconds",
 	)
+	collectCmd.Flags().IntVar(
+		&pollTimeout,
+		"poll-timeout",
+		defaultPollTimeout,
+		"Maximum number of seconds a single poll can take before it is cancelled, 0 means no limit",
+	)
+	collectCmd.Flags().IntVar(
+		&maxBackoff,
+		"max-backoff",
+		defaultMaxBackoff,
+		"Maximum number of seconds between polls of a collector which is failing, 0 means no limit",
+	)
+	collectCmd.Flags().IntVar(
+		&disableAfter,
+		"disable-after",
+		defaultDisableAfter,
+		"Stop polling a collector after this many consecutive failed polls, 0 means never",
+	)
 	collectCmd.Flags().IntVarP(
 		&devInfoAnnouceInterval,
 		"announce",
@@ -140,12 +257,35 @@ func init() { //nolint:funlen // Allow this to get a little long
 			strings.Join(runner.OptionalCollectorNames, ", "),
 		),
 	)
+	collectCmd.Flags().StringArrayVar(
+		&collectorSpecs,
+		"collector-spec",
+		[]string{},
+		"Path to a YAML or JSON file declaring extra collectors, can be repeated. "+
+			"The declared collectors can be selected with --collector like any other",
+	)
+	collectCmd.Flags().StringArrayVar(
+		&stopConditions,
+		"stop-on",
+		[]string{},
+		"Stop the run before the duration has passed when a condition is met, can be repeated:\n"+
+			"\tsamples=<collector>:<count>    every instance of the collector has written count samples\n"+
+			"\tdpll-state=[pps:|eec:]<state>  a DPLL changes into state e.g. holdover, locked or locked-ho-acq\n"+
+			"\tclock-class-change             the clockClass reported by PMC changes\n"+
+			"\tgnss-fix-lost                  the GNSS receiver loses its fix",
+	)
 
 	collectCmd.Flags().StringVarP(
 		&logsOutputFile,
 		"logs-output", "l", "",
 		"Path to the logs output file. This is required when using the logs collector",
 	)
+	collectCmd.Flags().StringVar(
+		&captureOutputFile,
+		"capture", "",
+		"Path to a file to record every command run on the nodes to, with its stdin, stdout, stderr and duration. "+
+			"The capture can be passed to replay to regenerate the output",
+	)
 	collectCmd.Flags().BoolVar(
 		&includeLogTimestamps,
 		"log-timestamps", defaultIncludeLogTimestamps,
@@ -155,4 +295,25 @@ func init() { //nolint:funlen // Allow this to get a little long
 	collectCmd.Flags().StringVarP(&tempDir, "tempdir", "t", defaultTempDir,
 		"Directory for storing temp/debug files. Must exist.")
 	collectCmd.Flags().BoolVar(&keepDebugFiles, "keep", defaultKeepDebugFiles, "Keep debug files")
+	collectCmd.Flags().StringVar(
+		&metricsListen,
+		"metrics-listen",
+		"",
+		"Address to serve the latest collected values as Prometheus metrics on, for example :9090. "+
+			"The metrics are served at /metrics for the duration of the run",
+	)
+	collectCmd.Flags().BoolVar(
+		&persistentSessions,
+		"persistent-sessions",
+		false,
+		"Keep a shell running in each container for the whole run and send every poll to it, "+
+			"rather than starting a new exec for each poll. This reduces the load on the API server and the jitter of polls",
+	)
+	collectCmd.Flags().BoolVar(
+		&dryRun,
+		"dry-run",
+		false,
+		"Print the collectors which would be run and the exact commands they would send to each container, "+
+			"without connecting to the cluster",
+	)
 }
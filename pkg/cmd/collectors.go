@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
+)
+
+var catalogueAsJSON bool
+
+var collectorsCmd = &cobra.Command{
+	Use:   "collectors",
+	Short: "describe the available collectors",
+	Long:  `describe the available collectors, what they need on the node and the records they write`,
+}
+
+var listCollectorsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the collectors and the analyser ids of their records",
+	Long: `list the collectors and the analyser ids of their records.
+Collectors declared in the files given with --collector-spec are included.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		catalogue, err := loadRegistry().GetCatalogue()
+		utils.IfErrorExitOrPanic(err)
+		if catalogueAsJSON {
+			utils.IfErrorExitOrPanic(writeJSON(os.Stdout, catalogue))
+			return
+		}
+		utils.IfErrorExitOrPanic(collectors.WriteCollectorList(os.Stdout, catalogue))
+	},
+}
+
+var describeCollectorCmd = &cobra.Command{
+	Use:   "describe <collector name or analyser id>",
+	Short: "describe a collector and the fields of its records",
+	Long: `describe a collector, what it needs on the node and the fields of its records.
+When given an analyser id such as dpll/time-error the collectors which write it are described.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		found, err := loadRegistry().Find(args[0])
+		if err != nil {
+			utils.IfErrorExitOrPanic(utils.NewMissingInputError(err))
+		}
+		if catalogueAsJSON {
+			utils.IfErrorExitOrPanic(writeJSON(os.Stdout, found))
+			return
+		}
+		for i := range found {
+			if i > 0 {
+				fmt.Fprintln(os.Stdout)
+			}
+			utils.IfErrorExitOrPanic(collectors.WriteCollectorDescription(os.Stdout, &found[i]))
+		}
+	},
+}
+
+// loadRegistry returns the built in collectors along with those declared in the files given with --collector-spec
+func loadRegistry() *collectors.CollectorRegistry {
+	registry, err := collectors.LoadRegistry(collectorSpecs)
+	if err != nil {
+		utils.IfErrorExitOrPanic(utils.NewMissingInputError(err))
+	}
+	return registry
+}
+
+func writeJSON(out io.Writer, value any) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("failed to write json: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(collectorsCmd)
+	collectorsCmd.AddCommand(listCollectorsCmd)
+	collectorsCmd.AddCommand(describeCollectorCmd)
+	collectorsCmd.PersistentFlags().StringArrayVar(
+		&collectorSpecs,
+		"collector-spec",
+		[]string{},
+		"Path to a YAML or JSON file declaring extra collectors to include, can be repeated",
+	)
+	collectorsCmd.PersistentFlags().BoolVar(&catalogueAsJSON, "json", false, "Write the descriptions as JSON")
+}
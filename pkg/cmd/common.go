@@ -10,15 +10,37 @@ import (
 
 var (
 	kubeConfig      string
+	kubeConfigs     []string
 	outputFile      string
 	useAnalyserJSON bool
-	ptpInterface    string
+	ptpInterfaces   []string
+	nodeName        string
+	local           bool
+	sshTarget       string
+	sshKey          string
+	sshKnownHosts   string
 )
 
+// MarkFlagsRequired marks each of the named flags as required on the targetCmd
+func MarkFlagsRequired(targetCmd *cobra.Command, names ...string) {
+	for _, name := range names {
+		err := targetCmd.MarkFlagRequired(name)
+		utils.IfErrorExitOrPanic(err)
+	}
+}
+
 func AddKubeconfigFlag(targetCmd *cobra.Command) {
 	targetCmd.Flags().StringVarP(&kubeConfig, "kubeconfig", "k", "", "Path to the kubeconfig file")
-	err := targetCmd.MarkFlagRequired("kubeconfig")
-	utils.IfErrorExitOrPanic(err)
+}
+
+func AddKubeconfigsFlag(targetCmd *cobra.Command) {
+	targetCmd.Flags().StringArrayVarP(
+		&kubeConfigs,
+		"kubeconfig",
+		"k",
+		[]string{},
+		"Path to the kubeconfig file, can be repeated to collect from multiple clusters at the same time",
+	)
 }
 
 func AddOutputFlag(targetCmd *cobra.Command) {
@@ -36,7 +58,53 @@ func AddFormatFlag(targetCmd *cobra.Command) {
 }
 
 func AddInterfaceFlag(targetCmd *cobra.Command) {
-	targetCmd.Flags().StringVarP(&ptpInterface, "interface", "i", "", "Name of the PTP interface")
-	err := targetCmd.MarkFlagRequired("interface")
-	utils.IfErrorExitOrPanic(err)
+	targetCmd.Flags().StringSliceVarP(
+		&ptpInterfaces,
+		"interface",
+		"i",
+		[]string{},
+		"Name of the PTP interface, can be repeated or comma separated to collect from multiple interfaces",
+	)
+}
+
+func AddLocalFlag(targetCmd *cobra.Command) {
+	targetCmd.Flags().BoolVar(
+		&local,
+		"local",
+		false,
+		"Run the commands directly on this host rather than through the kubernetes API, "+
+			"for use inside the linuxptp-daemon container or on a host running linuxptp",
+	)
+}
+
+func AddSSHFlags(targetCmd *cobra.Command) {
+	targetCmd.Flags().StringVar(
+		&sshTarget,
+		"ssh",
+		"",
+		"Run the commands over SSH on a host running linuxptp which is not part of a cluster, "+
+			"of the form [user@]host[:port]. Only key based authentication is supported",
+	)
+	targetCmd.Flags().StringVar(
+		&sshKey,
+		"ssh-key",
+		"",
+		"Path to the private key used with --ssh, by default ssh-agent and ~/.ssh/id_ed25519, id_ecdsa and id_rsa are tried",
+	)
+	targetCmd.Flags().StringVar(
+		&sshKnownHosts,
+		"ssh-known-hosts",
+		"",
+		"Path to the known_hosts file the host key is checked against when using --ssh (default ~/.ssh/known_hosts)",
+	)
+}
+
+func AddNodeFlag(targetCmd *cobra.Command) {
+	targetCmd.Flags().StringVarP(
+		&nodeName,
+		"node",
+		"n",
+		"",
+		"Name of the node running the linuxptp-daemon to target, required if there is more than one PTP node",
+	)
 }
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/replay"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
+)
+
+var captureFile string
+
+func runReplay() error {
+	input, err := os.Open(captureFile)
+	if err != nil {
+		return fmt.Errorf("failed to open capture file: %w", err)
+	}
+	defer input.Close()
+
+	outputFormat := callbacks.Raw
+	if useAnalyserJSON {
+		outputFormat = callbacks.AnalyserJSON
+	}
+	callback, err := callbacks.SetupCallback(outputFile, outputFormat)
+	if err != nil {
+		return fmt.Errorf("failed to open output: %w", err)
+	}
+
+	result, err := replay.Replay(context.Background(), input, ptpInterfaces, callback)
+	if cleanUpErr := callback.CleanUp(); cleanUpErr != nil {
+		log.Errorf("failed to close output: %s", cleanUpErr.Error())
+	}
+	if err != nil {
+		return err //nolint:wrapcheck // this returns a wrapped error
+	}
+
+	log.Infof("Replayed captures: %v", result.Replayed)
+	if result.FailedOnNode > 0 {
+		log.Infof("Skipped %d captures of commands which failed on the node", result.FailedOnNode)
+	}
+	if result.Unrecognised > 0 {
+		log.Warnf("%d captures were not run by a collector, pass --interface for each captured interface", result.Unrecognised)
+	}
+	if result.Failed > 0 {
+		log.Warnf("%d captures could not be replayed", result.Failed)
+	}
+	return nil
+}
+
+// replayCmd represents the replay command
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Regenerate the output from captured command outputs",
+	Long: `Regenerate the output of a run from the captured outputs of the commands the collectors ran on the node.
+The captures are processed by the current version of the tool so a run can be regenerated
+after a parser has been fixed or the analyser format has changed, without access to the hardware.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := runReplay()
+		utils.IfErrorExitOrPanic(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replayCmd)
+
+	AddOutputFlag(replayCmd)
+	AddFormatFlag(replayCmd)
+	AddInterfaceFlag(replayCmd)
+
+	replayCmd.Flags().StringVarP(&captureFile, "capture", "f", "", "Path to the file holding the captured command outputs")
+	MarkFlagsRequired(replayCmd, "capture")
+}
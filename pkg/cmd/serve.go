@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/runner"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/server"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
+)
+
+const (
+	defaultListenAddress string = "127.0.0.1:8080"
+	defaultDataDir       string = "."
+)
+
+var (
+	listenAddress string
+	dataDir       string
+)
+
+// newDefaultRunConfig returns the run config which the config of each session is applied on top of,
+// the values match the defaults of the collect command. Where to collect from and the collector
+// spec files are only taken from the flags, sessions can not set them.
+func newDefaultRunConfig() *runner.RunConfig {
+	runCfg := &runner.RunConfig{
+		Duration:                defaultDuration,
+		PollInterval:            defaultPollInterval,
+		PollTimeout:             defaultPollTimeout,
+		MaxBackoff:              defaultMaxBackoff,
+		DisableAfterFailures:    defaultDisableAfter,
+		DevInfoAnnounceInterval: defaultDevInfoInterval,
+		Collectors:              []string{runner.All},
+		CollectorSpecs:          collectorSpecs,
+		KeepDebugFiles:          defaultKeepDebugFiles,
+		Local:                   local,
+		SSH:                     sshTarget,
+		SSHIdentityFile:         sshKey,
+		SSHKnownHostsFile:       sshKnownHosts,
+	}
+	if len(kubeConfigs) == 1 {
+		runCfg.KubeConfig = kubeConfigs[0]
+	} else {
+		for _, path := range kubeConfigs {
+			runCfg.Clusters = append(runCfg.Clusters, runner.ClusterConfig{KubeConfig: path})
+		}
+	}
+	return runCfg
+}
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run collections controlled by a HTTP API",
+	Long: `Keep running and expose a HTTP API to start and stop named collection sessions,
+query their status and download their outputs. The clusters or host to collect from and
+the collector spec files are set with the flags, a session can not change them.
+
+	GET    /sessions               list the status of every session
+	POST   /sessions/<name>        start a session, the body is a YAML or JSON run config
+	GET    /sessions/<name>        get the status of a session
+	POST   /sessions/<name>/stop   stop a running session
+	DELETE /sessions/<name>        stop a session and remove its outputs
+	GET    /sessions/<name>/output download the collected data
+	GET    /sessions/<name>/logs   download the collected logs
+	GET    /sessions/<name>/capture download the captured commands, if a capture file was requested`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		dir := expandTempDir(dataDir)
+		err := os.MkdirAll(dir, tempdirPerm)
+		utils.IfErrorExitOrPanic(err)
+		err = server.ListenAndServe(ctx, listenAddress, dir, newDefaultRunConfig)
+		utils.IfErrorExitOrPanic(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	AddKubeconfigsFlag(serveCmd)
+	AddLocalFlag(serveCmd)
+	AddSSHFlags(serveCmd)
+	serveCmd.Flags().StringArrayVar(
+		&collectorSpecs,
+		"collector-spec",
+		[]string{},
+		"Path to a YAML or JSON file declaring extra collectors which sessions can select, can be repeated",
+	)
+
+	serveCmd.Flags().StringVar(&listenAddress, "listen", defaultListenAddress, "Address for the HTTP API to listen on")
+	serveCmd.Flags().StringVar(
+		&dataDir,
+		"data-dir",
+		defaultDataDir,
+		"Directory to keep the outputs of each session in, each session has its own sub directory",
+	)
+}
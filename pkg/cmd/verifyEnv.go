@@ -3,8 +3,12 @@
 package cmd
 
 import (
+	"errors"
+
 	"github.com/spf13/cobra"
 
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/verify"
 )
 
@@ -20,7 +24,29 @@ var verifyEnvCmd = &cobra.Command{
 	Short: "verify the environment is ready for collection",
 	Long:  `verify the environment is ready for collection`,
 	Run: func(cmd *cobra.Command, args []string) {
-		verify.Verify(ptpInterface, kubeConfig, useAnalyserJSON)
+		var hostCtx clients.ExecContext
+		onHost := local || sshTarget != ""
+		switch {
+		case local && sshTarget != "":
+			utils.IfErrorExitOrPanic(utils.NewMissingInputError(errors.New("--local can not be used with --ssh")))
+		case onHost && kubeConfig != "":
+			utils.IfErrorExitOrPanic(
+				utils.NewMissingInputError(errors.New("--kubeconfig can not be used with --local or --ssh")),
+			)
+		case local:
+			hostCtx = clients.NewLocalExecContext()
+		case sshTarget != "":
+			sshCtx, err := clients.NewSSHExecContext(sshTarget, clients.SSHOptions{
+				IdentityFile:   sshKey,
+				KnownHostsFile: sshKnownHosts,
+			})
+			utils.IfErrorExitOrPanic(err)
+			defer sshCtx.Close()
+			hostCtx = sshCtx
+		case kubeConfig == "":
+			utils.IfErrorExitOrPanic(utils.NewMissingInputError(errors.New("a kubeconfig must be provided")))
+		}
+		verify.Verify(ptpInterfaces, kubeConfig, nodeName, hostCtx, useAnalyserJSON)
 	},
 }
 
@@ -31,4 +57,8 @@ func init() {
 	AddOutputFlag(verifyEnvCmd)
 	AddFormatFlag(verifyEnvCmd)
 	AddInterfaceFlag(verifyEnvCmd)
+	AddNodeFlag(verifyEnvCmd)
+	AddLocalFlag(verifyEnvCmd)
+	AddSSHFlags(verifyEnvCmd)
+	MarkFlagsRequired(verifyEnvCmd, "interface")
 }
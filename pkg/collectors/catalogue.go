@@ -0,0 +1,238 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package collectors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const tabPadding = 2
+
+// FieldSchema describes a field in the data of a record
+type FieldSchema struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// OutputSchema describes one kind of record written by a collector. AnalyserID is the id
+// of the record in the analyser format and Tag the tag it is written with in the raw format.
+type OutputSchema struct {
+	AnalyserID  string        `json:"analyserId"`
+	Tag         string        `json:"tag"`
+	Description string        `json:"description,omitempty"`
+	Fields      []FieldSchema `json:"fields"`
+}
+
+// OptionSchema describes an option which can be set for a collector in the collectorSettings of a run config.
+// If Values is not empty the option must be set to one of them.
+type OptionSchema struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Values      []string `json:"values,omitempty"`
+}
+
+// CollectorInfo describes what a collector does, what it needs on the node and the records it writes.
+// Name, PerInterface, Required and UserDefined are filled in by the registry.
+type CollectorInfo struct {
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	Requires     []string       `json:"requires"`
+	Options      []OptionSchema `json:"options,omitempty"`
+	Outputs      []OutputSchema `json:"outputs"`
+	PerInterface bool           `json:"perInterface"`
+	Required     bool           `json:"required"`
+	UserDefined  bool           `json:"userDefined"`
+}
+
+// timestampField is the node's date when the values were fetched, which most records carry
+var timestampField = FieldSchema{Name: "timestamp", Type: "string", Description: "RFC3339 time on the node"}
+
+// GetNames returns the names of every registered collector, the required ones first
+func (reg *CollectorRegistry) GetNames() []string {
+	return append(reg.GetRequiredNames(), reg.GetOptionalNames()...)
+}
+
+// GetInfo returns the description of the named collector
+func (reg *CollectorRegistry) GetInfo(collectorName string) (CollectorInfo, error) {
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
+	info, ok := reg.infos[collectorName]
+	if !ok {
+		return CollectorInfo{}, fmt.Errorf("not index in registry for collector named %s", collectorName)
+	}
+	info.Name = collectorName
+	info.PerInterface = reg.perInterface[collectorName]
+	info.UserDefined = reg.userDefined[collectorName]
+	for _, name := range reg.required {
+		if name == collectorName {
+			info.Required = true
+		}
+	}
+	return info, nil
+}
+
+// GetCatalogue returns the description of every registered collector, the required ones first
+func (reg *CollectorRegistry) GetCatalogue() ([]CollectorInfo, error) {
+	names := reg.GetNames()
+	infos := make([]CollectorInfo, 0, len(names))
+	for _, collectorName := range names {
+		info, err := reg.GetInfo(collectorName)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// Find returns the description of the named collector or, if there is no collector with
+// that name, of the collectors which write records with that analyser id
+func (reg *CollectorRegistry) Find(nameOrAnalyserID string) ([]CollectorInfo, error) {
+	if info, err := reg.GetInfo(nameOrAnalyserID); err == nil {
+		return []CollectorInfo{info}, nil
+	}
+	catalogue, err := reg.GetCatalogue()
+	if err != nil {
+		return nil, err
+	}
+	found := make([]CollectorInfo, 0)
+	for _, info := range catalogue {
+		for _, output := range info.Outputs {
+			if output.AnalyserID == nameOrAnalyserID {
+				found = append(found, info)
+				break
+			}
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no collector is named %s or writes records with that analyser id", nameOrAnalyserID)
+	}
+	return found, nil
+}
+
+// CheckOptions returns an error if an option is not one the collector accepts or is set to a value it does not
+func (info *CollectorInfo) CheckOptions(options map[string]string) error {
+	for name, value := range options {
+		option, found := info.getOption(name)
+		if !found {
+			return fmt.Errorf("collector %s does not have an option named %s", info.Name, name)
+		}
+		if !option.allows(value) {
+			return fmt.Errorf("option %s of collector %s must be one of %s", name, info.Name, strings.Join(option.Values, ", "))
+		}
+	}
+	return nil
+}
+
+// allows reports if the option can be set to value
+func (option *OptionSchema) allows(value string) bool {
+	if len(option.Values) == 0 {
+		return true
+	}
+	for _, allowed := range option.Values {
+		if value == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (info *CollectorInfo) getOption(name string) (OptionSchema, bool) {
+	for _, option := range info.Options {
+		if option.Name == name {
+			return option, true
+		}
+	}
+	return OptionSchema{}, false
+}
+
+func (info *CollectorInfo) getScope() string {
+	if info.PerInterface {
+		return "interface"
+	}
+	return "node"
+}
+
+func (info *CollectorInfo) getInclusion() string {
+	switch {
+	case info.Required:
+		return "required"
+	case info.UserDefined:
+		return "user defined"
+	default:
+		return "optional"
+	}
+}
+
+func (info *CollectorInfo) getAnalyserIDs() string {
+	ids := make([]string, 0, len(info.Outputs))
+	for _, output := range info.Outputs {
+		ids = append(ids, output.AnalyserID)
+	}
+	if len(ids) == 0 {
+		return "-"
+	}
+	return strings.Join(ids, ", ")
+}
+
+// WriteCollectorList writes a table of the collectors and the analyser ids of the records they write
+func WriteCollectorList(out io.Writer, catalogue []CollectorInfo) error {
+	table := tabwriter.NewWriter(out, 0, 0, tabPadding, ' ', 0)
+	fmt.Fprintln(table, "COLLECTOR\tINCLUSION\tRUN PER\tANALYSER IDS\tDESCRIPTION")
+	for i := range catalogue {
+		info := &catalogue[i]
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n",
+			info.Name, info.getInclusion(), info.getScope(), info.getAnalyserIDs(), info.Description,
+		)
+	}
+	if err := table.Flush(); err != nil {
+		return fmt.Errorf("failed to write collector list: %w", err)
+	}
+	return nil
+}
+
+// WriteCollectorDescription writes everything known about a collector including the fields of its records
+func WriteCollectorDescription(out io.Writer, info *CollectorInfo) error {
+	table := tabwriter.NewWriter(out, 0, 0, tabPadding, ' ', 0)
+	fmt.Fprintf(table, "%s\n\n%s\n\n", info.Name, info.Description)
+	fmt.Fprintf(table, "Inclusion:\t%s\n", info.getInclusion())
+	fmt.Fprintf(table, "Run per:\t%s\n", info.getScope())
+	if len(info.Requires) > 0 {
+		fmt.Fprintln(table, "\nRequires:")
+		for _, requirement := range info.Requires {
+			fmt.Fprintf(table, "  - %s\n", requirement)
+		}
+	}
+	if len(info.Options) > 0 {
+		fmt.Fprintln(table, "\nOptions:")
+		fmt.Fprintln(table, "  OPTION\tVALUES\tDESCRIPTION")
+		for _, option := range info.Options {
+			values := "any"
+			if len(option.Values) > 0 {
+				values = strings.Join(option.Values, ", ")
+			}
+			fmt.Fprintf(table, "  %s\t%s\t%s\n", option.Name, values, option.Description)
+		}
+	}
+	if len(info.Outputs) == 0 {
+		fmt.Fprintln(table, "\nDoes not write any records to the output")
+	}
+	for _, output := range info.Outputs {
+		fmt.Fprintf(table, "\nRecords %s (tag %s)\n", output.AnalyserID, output.Tag)
+		if output.Description != "" {
+			fmt.Fprintln(table, output.Description)
+		}
+		fmt.Fprintln(table, "  FIELD\tTYPE\tDESCRIPTION")
+		for _, field := range output.Fields {
+			fmt.Fprintf(table, "  %s\t%s\t%s\n", field.Name, field.Type, field.Description)
+		}
+	}
+	if err := table.Flush(); err != nil {
+		return fmt.Errorf("failed to write collector description: %w", err)
+	}
+	return nil
+}
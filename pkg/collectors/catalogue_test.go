@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package collectors_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+)
+
+var _ = Describe("Catalogue", func() {
+	When("the built in collectors are described", func() {
+		It("should describe every registered collector", func() {
+			catalogue, err := collectors.GetRegistry().GetCatalogue()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(catalogue).To(HaveLen(len(collectors.GetRegistry().GetNames())))
+			for _, info := range catalogue {
+				Expect(info.Description).NotTo(BeEmpty(), info.Name)
+				for _, output := range info.Outputs {
+					Expect(output.AnalyserID).NotTo(BeEmpty(), info.Name)
+					Expect(output.Tag).NotTo(BeEmpty(), info.Name)
+					Expect(output.Fields).NotTo(BeEmpty(), info.Name)
+				}
+			}
+		})
+		It("should fill in the inclusion and scope from the registry", func() {
+			info, err := collectors.GetRegistry().GetInfo(collectors.DevInfoCollectorName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Name).To(Equal(collectors.DevInfoCollectorName))
+			Expect(info.Required).To(BeTrue())
+			Expect(info.PerInterface).To(BeTrue())
+			Expect(info.UserDefined).To(BeFalse())
+
+			info, err = collectors.GetRegistry().GetInfo(collectors.PMCCollectorName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Required).To(BeFalse())
+			Expect(info.PerInterface).To(BeFalse())
+		})
+	})
+	When("a collector is looked up", func() {
+		It("should find it by name", func() {
+			found, err := collectors.GetRegistry().Find(collectors.GPSCollectorName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(HaveLen(1))
+			Expect(found[0].Name).To(Equal(collectors.GPSCollectorName))
+		})
+		It("should find the collector which writes an analyser id", func() {
+			for _, analyserID := range []string{"dpll/time-error", "dpll/states"} {
+				found, err := collectors.GetRegistry().Find(analyserID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(HaveLen(1))
+				Expect(found[0].Name).To(Equal(collectors.DPLLCollectorName))
+			}
+			found, err := collectors.GetRegistry().Find("ts2phc/time-error")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(HaveLen(1))
+			Expect(found[0].Name).To(Equal(collectors.Ts2phcCollectorName))
+		})
+		It("should return an error for an unknown name", func() {
+			_, err := collectors.GetRegistry().Find("unknown")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	When("the catalogue is written", func() {
+		It("should list each collector with its analyser ids", func() {
+			catalogue, err := collectors.GetRegistry().GetCatalogue()
+			Expect(err).NotTo(HaveOccurred())
+			out := &bytes.Buffer{}
+			Expect(collectors.WriteCollectorList(out, catalogue)).To(Succeed())
+			Expect(out.String()).To(MatchRegexp(`DPLL\s+optional\s+interface\s+dpll/time-error, dpll/states\s`))
+			Expect(out.String()).To(MatchRegexp(`Logs\s+optional\s+node\s+phc/offset, ptp4l/port-state, ptp/fault\s`))
+		})
+		It("should describe the fields of each record", func() {
+			info, err := collectors.GetRegistry().GetInfo(collectors.DPLLCollectorName)
+			Expect(err).NotTo(HaveOccurred())
+			out := &bytes.Buffer{}
+			Expect(collectors.WriteCollectorDescription(out, &info)).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("Records dpll/time-error (tag dpll-info-fs)"))
+			Expect(out.String()).To(ContainSubstring("Records dpll/states (tag dpll-info-nl)"))
+			Expect(out.String()).To(MatchRegexp(`terror\s+float\s+phase offset`))
+			Expect(out.String()).To(MatchRegexp(`source\s+filesystem, netlink\s+read the DPLL state`))
+		})
+	})
+	When("a user defined collector is registered", func() {
+		It("should be described from its spec", func() {
+			registry, err := collectors.GetRegistry().WithCollectorSpecs([]collectors.CollectorSpec{{
+				Name:         "PHCTime",
+				AnalyserID:   "phc/time",
+				PerInterface: true,
+				Commands: []devices.CustomCommand{{
+					Key:     "phc",
+					Command: "phc_ctl {interface} get",
+					Regex:   `clock time is (?P<seconds>\d+)\.(?P<nanoseconds>\d+)`,
+				}},
+				Fields: map[string]devices.CustomFieldType{"seconds": devices.CustomInt},
+			}})
+			Expect(err).NotTo(HaveOccurred())
+
+			found, err := registry.Find("phc/time")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(HaveLen(1))
+			info := found[0]
+			Expect(info.UserDefined).To(BeTrue())
+			Expect(info.PerInterface).To(BeTrue())
+			Expect(info.Description).To(Equal("User defined collector PHCTime"))
+			Expect(info.Outputs).To(HaveLen(1))
+			Expect(info.Outputs[0].Tag).To(Equal("PHCTime"))
+			Expect(info.Outputs[0].Fields).To(Equal([]collectors.FieldSchema{
+				{Name: "timestamp", Type: "string", Description: "RFC3339 time on the node"},
+				{Name: "values.seconds", Type: "int"},
+				{Name: "values.nanoseconds", Type: "string"},
+			}))
+
+			_, err = collectors.GetRegistry().Find("phc/time")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+func TestCollectors(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Collectors Suite")
+}
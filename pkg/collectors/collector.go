@@ -3,27 +3,36 @@
 package collectors
 
 import (
+	"context"
 	"time"
 
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
 )
 
 type Collector interface {
-	Start() error                                // Setups any internal state required for collection to happen
-	Poll(chan PollResult, *utils.WaitGroupCount) // Poll for collectables
-	CleanUp() error                              // Stops the collector and cleans up any internal state. It should result in a state that can be started again
-	GetPollInterval() time.Duration              // Returns the collectors polling interval
+	Start() error                                                 // Setups any internal state required for collection to happen
+	Poll(context.Context, chan PollResult, *utils.WaitGroupCount) // Poll for collectables, cancelling the context stops the poll
+	CleanUp() error                                               // Stops the collector and cleans up any internal state. It should result in a state that can be started again
+	GetPollInterval() time.Duration                               // Returns the collectors polling interval
 	IsAnnouncer() bool
 }
 
-// A union of all values required to be passed into all constructions
+// A union of all values required to be passed into all constructions.
+// Options holds the options set for the collector in the run config, the collectors which accept
+// options list them in their CollectorInfo.
 type CollectionConstructor struct {
 	Callback               callbacks.Callback
 	Clientset              *clients.Clientset
+	CaptureWriter          *clients.CaptureWriter
+	HostExecContext        clients.ExecContext
+	Sessions               *clients.PersistentSessions
 	ErroredPolls           chan PollResult
+	Options                map[string]string
 	PTPInterface           string
+	NodeName               string
 	Msg                    string
 	LogsOutputFile         string
 	TempDir                string
@@ -33,6 +42,83 @@ type CollectionConstructor struct {
 	KeepDebugFiles         bool
 }
 
+// wrapExecContext runs the commands of execCtx through a persistent shell, if Sessions were provided,
+// and writes every command it runs to the capture file, if one was requested
+func (constructor *CollectionConstructor) wrapExecContext(
+	execCtx clients.ExecContext,
+) clients.ExecContext { //nolint:ireturn // the context is returned unwrapped if there is no capture
+	return constructor.recordExecContext(constructor.Sessions.Wrap(execCtx))
+}
+
+// wrapInterfaceExecContext is wrapExecContext for collectors which run the same script for every
+// interface, the interface is recorded with each command so that it can be replayed for the right one
+func (constructor *CollectionConstructor) wrapInterfaceExecContext(
+	execCtx clients.ExecContext,
+) clients.ExecContext { //nolint:ireturn // the context is returned unwrapped if there is no capture
+	execCtx = constructor.Sessions.Wrap(execCtx)
+	if constructor.CaptureWriter == nil {
+		return execCtx
+	}
+	return clients.NewInterfaceRecordingExecContext(execCtx, constructor.CaptureWriter, constructor.PTPInterface)
+}
+
+// recordExecContext writes every command execCtx runs to the capture file, if one was requested
+func (constructor *CollectionConstructor) recordExecContext(
+	execCtx clients.ExecContext,
+) clients.ExecContext { //nolint:ireturn // the context is returned unwrapped if there is no capture
+	if constructor.CaptureWriter == nil {
+		return execCtx
+	}
+	return clients.NewRecordingExecContext(execCtx, constructor.CaptureWriter)
+}
+
+// getPTPDaemonContext returns a context for running commands alongside ptp4l, either in the
+// linuxptp-daemon container or, if a HostExecContext was provided, directly on the PTP host
+// in which case there is no Clientset
+//
+//nolint:ireturn // the type of context depends on where the collection is run
+func (constructor *CollectionConstructor) getPTPDaemonContext() (clients.ExecContext, error) {
+	return constructor.getPTPPodContext(contexts.PTPContainer)
+}
+
+// getPTPDaemonProbeContext returns a context for a one off command run while a collector is being built.
+// It does not use a persistent shell as that would be left idle for the whole run.
+//
+//nolint:ireturn // the type of context depends on where the collection is run
+func (constructor *CollectionConstructor) getPTPDaemonProbeContext() (clients.ExecContext, error) {
+	ctx, err := constructor.getBasePTPPodContext(contexts.PTPContainer)
+	if err != nil {
+		return nil, err
+	}
+	return constructor.recordExecContext(ctx), nil
+}
+
+// getPTPPodContext returns a context for the named container in the linuxptp-daemon pod,
+// or the PTP host if a HostExecContext was provided
+//
+//nolint:ireturn // the type of context depends on where the collection is run
+func (constructor *CollectionConstructor) getPTPPodContext(container string) (clients.ExecContext, error) {
+	ctx, err := constructor.getBasePTPPodContext(container)
+	if err != nil {
+		return nil, err
+	}
+	return constructor.wrapExecContext(ctx), nil
+}
+
+// getBasePTPPodContext returns the context getPTPPodContext wraps
+//
+//nolint:ireturn // the type of context depends on where the collection is run
+func (constructor *CollectionConstructor) getBasePTPPodContext(container string) (clients.ExecContext, error) {
+	if constructor.HostExecContext != nil {
+		return constructor.HostExecContext, nil
+	}
+	ctx, err := contexts.GetPTPPodContext(constructor.Clientset, constructor.NodeName, container)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // this returns a wrapped error
+	}
+	return ctx, nil
+}
+
 type PollResult struct {
 	CollectorName string
 	Errors        []error
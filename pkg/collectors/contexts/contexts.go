@@ -4,6 +4,8 @@ package contexts
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 
@@ -13,6 +15,7 @@ import (
 const (
 	PTPNamespace               = "openshift-ptp"
 	PTPPodNamePrefix           = "linuxptp-daemon-"
+	PTPPodLabelSelector        = "app=linuxptp-daemon"
 	PTPContainer               = "linuxptp-daemon-container"
 	GPSContainer               = "gpsd"
 	NetlinkDebugPod            = "ptp-dpll-netlink-debug-pod"
@@ -20,22 +23,72 @@ const (
 	NetlinkDebugContainerImage = "quay.io/redhat-partner-solutions/dpll-debug:0.1"
 )
 
-func GetPTPDaemonContext(clientset *clients.Clientset) (clients.ExecContext, error) {
-	ctx, err := clients.NewContainerContext(clientset, PTPNamespace, PTPPodNamePrefix, PTPContainer)
+// GetPTPDaemonContext returns a context for the linuxptp-daemon container.
+// If nodeName is empty there must only be a single linuxptp-daemon pod in the cluster.
+func GetPTPDaemonContext(clientset *clients.Clientset, nodeName string) (clients.ExecContext, error) {
+	return GetPTPPodContext(clientset, nodeName, PTPContainer)
+}
+
+// GetPTPPodContext returns a context for the named container in the linuxptp-daemon pod.
+// If nodeName is empty there must only be a single linuxptp-daemon pod in the cluster.
+func GetPTPPodContext(clientset *clients.Clientset, nodeName, container string) (clients.ExecContext, error) {
+	ctx, err := clients.NewContainerContextFromLabels(clientset, PTPNamespace, PTPPodLabelSelector, nodeName, container)
 	if err != nil {
 		return ctx, fmt.Errorf("could not create container context %w", err)
 	}
 	return ctx, nil
 }
 
-func GetNetlinkContext(clientset *clients.Clientset) (*clients.ContainerCreationExecContext, error) {
+// GetPTPDaemonPodName returns the name of the linuxptp-daemon pod on the node.
+// If nodeName is empty there must only be a single linuxptp-daemon pod in the cluster.
+func GetPTPDaemonPodName(clientset *clients.Clientset, nodeName string) (string, error) {
+	podName, err := clientset.FindPodNameFromLabels(PTPNamespace, PTPPodLabelSelector, nodeName)
+	if err != nil {
+		return "", fmt.Errorf("could not find linuxptp-daemon pod %w", err)
+	}
+	return podName, nil
+}
+
+// GetPTPDaemonNodeName returns the name of the node the linuxptp-daemon pod is running on
+func GetPTPDaemonNodeName(clientset *clients.Clientset, nodeName string) (string, error) {
+	pod, err := clientset.FindPodFromLabels(PTPNamespace, PTPPodLabelSelector, nodeName)
+	if err != nil {
+		return "", fmt.Errorf("could not find linuxptp-daemon pod %w", err)
+	}
+	return pod.Spec.NodeName, nil
+}
+
+// invalidPodNameChars matches the runs of characters which are allowed in an interface name but not in a pod name
+var invalidPodNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// GetNetlinkPodName returns the name of the debug pod which queries the DPLL netlink interface for ptpInterface.
+// Characters which are not valid in a DNS-1123 name, such as `_` and `.`, are replaced with `-`.
+func GetNetlinkPodName(ptpInterface string) string {
+	name := invalidPodNameChars.ReplaceAllString(strings.ToLower(ptpInterface), "-")
+	return fmt.Sprintf("%s-%s", NetlinkDebugPod, strings.Trim(name, "-"))
+}
+
+// GetNetlinkContext returns a context for a debug pod which can query the DPLL netlink interface.
+// Each interface gets its own pod so that collectors for different interfaces can be started
+// and cleaned up independently. The pod is scheduled on the same node as the linuxptp-daemon.
+func GetNetlinkContext(
+	clientset *clients.Clientset,
+	nodeName string,
+	ptpInterface string,
+) (*clients.ContainerCreationExecContext, error) {
+	daemonPod, err := clientset.FindPodFromLabels(PTPNamespace, PTPPodLabelSelector, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find node for netlink context: %w", err)
+	}
+
 	hpt := corev1.HostPathDirectory
 	ctx, err := clients.NewContainerCreationExecContext(
 		clientset,
 		PTPNamespace,
-		NetlinkDebugPod,
+		GetNetlinkPodName(ptpInterface),
 		NetlinkDebugContainer,
 		NetlinkDebugContainerImage,
+		daemonPod.Spec.NodeName,
 		map[string]string{},
 		[]string{"sleep", "inf"},
 		&corev1.SecurityContext{
@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package contexts_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
+)
+
+var _ = Describe("GetNetlinkPodName", func() {
+	DescribeTable("should return a valid pod name for the interface",
+		func(ptpInterface, expected string) {
+			Expect(contexts.GetNetlinkPodName(ptpInterface)).To(Equal(contexts.NetlinkDebugPod + "-" + expected))
+		},
+		Entry("lower case", "ens7f0", "ens7f0"),
+		Entry("upper case", "ENS7F0", "ens7f0"),
+		Entry("a VLAN", "ens7f0.100", "ens7f0-100"),
+		Entry("an underscore", "net_1", "net-1"),
+		Entry("a trailing invalid character", "ens7f0_", "ens7f0"),
+	)
+})
+
+func TestContexts(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Contexts Suite")
+}
@@ -0,0 +1,246 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
+)
+
+var validCollectorName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// CollectorSpec declares a collector which runs commands in a container of the linuxptp-daemon pod,
+// or on the host for local and ssh runs, and parses their output with regular expressions.
+type CollectorSpec struct {
+	Fields map[string]devices.CustomFieldType `json:"fields,omitempty"`
+	// Name is used to select the collector and as the tag of its records
+	Name string `json:"name"`
+	// AnalyserID is the id of the records in the analyser format
+	AnalyserID string `json:"analyserId"`
+	// Description is shown by the collectors commands
+	Description string `json:"description,omitempty"`
+	// Container defaults to the linuxptp-daemon container
+	Container string                  `json:"container,omitempty"`
+	Commands  []devices.CustomCommand `json:"commands"`
+	// PerInterface collectors are run for each PTP interface with {interface} replaced in their commands
+	PerInterface bool `json:"perInterface,omitempty"`
+}
+
+// CollectorSpecFile is the content of a file declaring user defined collectors
+type CollectorSpecFile struct {
+	Collectors []CollectorSpec `json:"collectors"`
+}
+
+// LoadCollectorSpecs reads the collectors declared in a YAML or JSON file
+func LoadCollectorSpecs(path string) ([]CollectorSpec, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collector specs %s: %w", path, err)
+	}
+	specFile := CollectorSpecFile{}
+	err = yaml.UnmarshalStrict(content, &specFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse collector specs %s: %w", path, err)
+	}
+	return specFile.Collectors, nil
+}
+
+func (spec *CollectorSpec) getContainer() string {
+	if spec.Container == "" {
+		return contexts.PTPContainer
+	}
+	return spec.Container
+}
+
+func (spec *CollectorSpec) newFetcher(ptpInterface string) (*devices.CustomFetcher, error) {
+	customFetcher, err := devices.NewCustomFetcher(spec.AnalyserID, ptpInterface, spec.Commands, spec.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("invalid collector %s: %w", spec.Name, err)
+	}
+	return customFetcher, nil
+}
+
+// validate checks the spec can be turned into a collector
+func (spec *CollectorSpec) validate() error {
+	if !validCollectorName.MatchString(spec.Name) || strings.EqualFold(spec.Name, "all") ||
+		strings.EqualFold(spec.Name, "defaults") {
+		return fmt.Errorf("invalid collector name %q", spec.Name)
+	}
+	if spec.AnalyserID == "" {
+		return fmt.Errorf("invalid collector %s: an analyserId must be provided", spec.Name)
+	}
+	_, err := spec.newFetcher("")
+	return err
+}
+
+func (spec *CollectorSpec) getScope() collectorScope {
+	if spec.PerInterface {
+		return perInterface
+	}
+	return perNode
+}
+
+// plan returns the commands the collector would run for ptpInterface
+func (spec *CollectorSpec) plan(ptpInterface string) ([]PlannedCommand, error) {
+	customFetcher, err := spec.newFetcher(ptpInterface)
+	if err != nil {
+		return nil, err
+	}
+	command := ptpDaemonCommand(fmt.Sprintf("fetch the values of user defined collector %s", spec.Name), "")
+	command.Container = spec.getContainer()
+	command.Script = customFetcher.GetCommand()
+	return []PlannedCommand{command}, nil
+}
+
+// info describes the collector and the values in its records for the collectors commands
+func (spec *CollectorSpec) info() (CollectorInfo, error) {
+	customFetcher, err := spec.newFetcher(devices.CustomInterfacePlaceholder)
+	if err != nil {
+		return CollectorInfo{}, err
+	}
+	description := spec.Description
+	if description == "" {
+		description = fmt.Sprintf("User defined collector %s", spec.Name)
+	}
+	valueNames := customFetcher.GetValueNames()
+	values := make([]FieldSchema, 0, len(valueNames))
+	for _, name := range valueNames {
+		values = append(values, FieldSchema{Name: "values." + name, Type: string(customFetcher.GetFieldType(name))})
+	}
+	return CollectorInfo{
+		Description: description,
+		Requires:    []string{fmt.Sprintf("%s: the commands declared in the collector spec", spec.getContainer())},
+		Outputs: []OutputSchema{
+			{
+				AnalyserID: spec.AnalyserID,
+				Tag:        spec.Name,
+				Fields:     append([]FieldSchema{timestampField}, values...),
+			},
+		},
+	}, nil
+}
+
+// CustomCollector runs the commands of a user defined collector
+type CustomCollector struct {
+	*baseCollector
+	ctx     clients.ExecContext
+	fetcher *devices.CustomFetcher
+	name    string
+}
+
+func (custom *CustomCollector) poll(ctx context.Context) error {
+	info, err := custom.fetcher.Fetch(ctx, custom.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s %w", custom.name, err)
+	}
+	err = callbacks.CallWithContext(ctx, custom.callback, &info, custom.name)
+	if err != nil {
+		return fmt.Errorf("callback failed %w", err)
+	}
+	return nil
+}
+
+// Poll collects information from the cluster then
+// calls the callback.Call to allow that to persist it
+func (custom *CustomCollector) Poll(ctx context.Context, resultsChan chan PollResult, wg *utils.WaitGroupCount) {
+	defer func() {
+		wg.Done()
+	}()
+
+	errorsToReturn := make([]error, 0)
+	err := custom.poll(ctx)
+	if err != nil {
+		errorsToReturn = append(errorsToReturn, err)
+	}
+	resultsChan <- PollResult{
+		CollectorName: custom.name,
+		Errors:        errorsToReturn,
+	}
+}
+
+// newCustomCollectorBuilder returns a function which builds a CustomCollector from spec
+func newCustomCollectorBuilder(spec CollectorSpec) collectonBuilderFunc {
+	return func(constructor *CollectionConstructor) (Collector, error) {
+		customFetcher, err := spec.newFetcher(constructor.PTPInterface)
+		if err != nil {
+			return &CustomCollector{}, err
+		}
+		ctx, err := constructor.getPTPPodContext(spec.getContainer())
+		if err != nil {
+			return &CustomCollector{}, fmt.Errorf("failed to create %s: %w", spec.Name, err)
+		}
+		collector := CustomCollector{
+			baseCollector: newBaseCollector(
+				constructor.PollInterval,
+				false,
+				constructor.Callback,
+			),
+			ctx:     ctx,
+			fetcher: customFetcher,
+			name:    spec.Name,
+		}
+		return &collector, nil
+	}
+}
+
+// WithCollectorSpecs checks each spec and returns a copy of the registry with them added as optional
+// collectors, the registry itself is not changed. A spec may replace a collector from an earlier spec
+// with the same name but not a built in collector.
+func (reg *CollectorRegistry) WithCollectorSpecs(specs []CollectorSpec) (*CollectorRegistry, error) {
+	names := make(map[string]bool, len(specs))
+	for i := range specs {
+		spec := &specs[i]
+		if err := spec.validate(); err != nil {
+			return nil, err
+		}
+		if names[spec.Name] {
+			return nil, fmt.Errorf("collector %s is declared more than once", spec.Name)
+		}
+		if _, err := reg.GetBuilderFunc(spec.Name); err == nil && !reg.IsUserDefined(spec.Name) {
+			return nil, fmt.Errorf("collector %s is built in so can not be redefined", spec.Name)
+		}
+		names[spec.Name] = true
+	}
+	extended := reg.clone()
+	for i := range specs {
+		spec := specs[i]
+		info, err := spec.info()
+		if err != nil {
+			return nil, err
+		}
+		err = extended.registerUserDefined(spec.Name, newCustomCollectorBuilder(spec), spec.plan, info, spec.getScope())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return extended, nil
+}
+
+// LoadRegistry returns the built in collectors along with those declared in the collector spec files.
+// The registry returned by GetRegistry is not changed so the collectors declared for one run can not
+// be seen by any other run.
+func LoadRegistry(specFiles []string) (*CollectorRegistry, error) {
+	reg := GetRegistry()
+	for _, path := range specFiles {
+		specs, err := LoadCollectorSpecs(path)
+		if err != nil {
+			return nil, err
+		}
+		reg, err = reg.WithCollectorSpecs(specs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to register collectors from %s: %w", path, err)
+		}
+	}
+	return reg, nil
+}
@@ -3,16 +3,16 @@
 package collectors
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"sync"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
-	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/validations"
@@ -37,6 +37,7 @@ const (
 // Start sets up the collector so it is ready to be polled
 func (ptpDev *DevInfoCollector) Start() error {
 	ptpDev.running = true
+	ptpDev.wg.Add(1)
 	go ptpDev.monitorErroredPolls()
 	return nil
 }
@@ -50,7 +51,6 @@ func (ptpDev *DevInfoCollector) Start() error {
 //			We do not want a backlog because if erroredPolls becomes full will block the main
 //			loop in runner.Run
 func (ptpDev *DevInfoCollector) monitorErroredPolls() {
-	ptpDev.wg.Add(1)
 	defer ptpDev.wg.Done()
 	for {
 		select {
@@ -63,18 +63,16 @@ func (ptpDev *DevInfoCollector) monitorErroredPolls() {
 			if len(ptpDev.requiresFetch) == 0 {
 				ptpDev.requiresFetch <- true
 			}
-		default:
-			time.Sleep(time.Microsecond)
 		}
 	}
 }
 
 // polls for the device info, stores it then passes it to the callback
-func (ptpDev *DevInfoCollector) poll() error {
+func (ptpDev *DevInfoCollector) poll(ctx context.Context) error {
 	var devInfo *devices.PTPDeviceInfo
 	select {
 	case <-ptpDev.requiresFetch:
-		fetchedDevInfo, err := devices.GetPTPDeviceInfo(ptpDev.interfaceName, ptpDev.ctx)
+		fetchedDevInfo, err := devices.GetPTPDeviceInfo(ctx, ptpDev.interfaceName, ptpDev.ctx)
 		if err != nil {
 			return fmt.Errorf("failed to fetch %s %w", DeviceInfo, err)
 		}
@@ -84,7 +82,7 @@ func (ptpDev *DevInfoCollector) poll() error {
 		devInfo = ptpDev.devInfo
 	}
 
-	err := ptpDev.callback.Call(devInfo, DeviceInfo)
+	err := callbacks.CallWithContext(ctx, ptpDev.callback, devInfo, DeviceInfo)
 	if err != nil {
 		return fmt.Errorf("callback failed %w", err)
 	}
@@ -93,17 +91,17 @@ func (ptpDev *DevInfoCollector) poll() error {
 
 // Poll collects information from the cluster then
 // calls the callback.Call to allow that to persist it
-func (ptpDev *DevInfoCollector) Poll(resultsChan chan PollResult, wg *utils.WaitGroupCount) {
+func (ptpDev *DevInfoCollector) Poll(ctx context.Context, resultsChan chan PollResult, wg *utils.WaitGroupCount) {
 	defer func() {
 		wg.Done()
 	}()
 	errorsToReturn := make([]error, 0)
-	err := ptpDev.poll()
+	err := ptpDev.poll(ctx)
 	if err != nil {
 		errorsToReturn = append(errorsToReturn, err)
 	}
 	resultsChan <- PollResult{
-		CollectorName: DPLLCollectorName,
+		CollectorName: DevInfoCollectorName,
 		Errors:        errorsToReturn,
 	}
 }
@@ -151,7 +149,7 @@ func verify(ptpDevInfo *devices.PTPDeviceInfo, constructor *CollectionConstructo
 // Returns a new DevInfoCollector from the CollectionConstuctor Factory
 func NewDevInfoCollector(constructor *CollectionConstructor) (Collector, error) {
 	// Build DPPInfoFetcher ahead of time call to GetPTPDeviceInfo will build the other
-	ctx, err := contexts.GetPTPDaemonContext(constructor.Clientset)
+	ctx, err := constructor.getPTPDaemonContext()
 	if err != nil {
 		return &DevInfoCollector{}, fmt.Errorf("failed to create DevInfoCollector: %w", err)
 	}
@@ -160,7 +158,7 @@ func NewDevInfoCollector(constructor *CollectionConstructor) (Collector, error)
 		return &DevInfoCollector{}, fmt.Errorf("failed to build fetcher for PTPDeviceInfo %w", err)
 	}
 
-	ptpDevInfo, err := devices.GetPTPDeviceInfo(constructor.PTPInterface, ctx)
+	ptpDevInfo, err := devices.GetPTPDeviceInfo(context.Background(), constructor.PTPInterface, ctx)
 	if err != nil {
 		return &DevInfoCollector{}, fmt.Errorf("failed to fetch initial DeviceInfo %w", err)
 	}
@@ -187,6 +185,30 @@ func NewDevInfoCollector(constructor *CollectionConstructor) (Collector, error)
 	return &collector, nil
 }
 
+var devInfoCollectorInfo = CollectorInfo{
+	Description: "Fetches the vendor, device, firmware and driver of the interface's NIC at start up and " +
+		"announces them periodically so each part of the output can be associated with the hardware",
+	Requires: []string{
+		"linuxptp-daemon-container: cat and ls of /sys/class/net/<interface>/device",
+		"linuxptp-daemon-container: ethtool",
+	},
+	Outputs: []OutputSchema{
+		{
+			AnalyserID: "devInfo",
+			Tag:        DeviceInfo,
+			Fields: []FieldSchema{
+				{Name: "timestamp", Type: "string", Description: "time of the announcement on the node"},
+				{Name: "fetched_timestamp", Type: "string", Description: "RFC3339 time on the node when the info was fetched"},
+				{Name: "vendorID", Type: "string", Description: "PCI vendor ID"},
+				{Name: "devID", Type: "string", Description: "PCI device ID"},
+				{Name: "gnss", Type: "string", Description: "GNSS device of the NIC"},
+				{Name: "firmwareVersion", Type: "string", Description: "firmware version reported by ethtool"},
+				{Name: "driverVersion", Type: "string", Description: "driver version reported by ethtool"},
+			},
+		},
+	},
+}
+
 func init() {
-	RegisterCollector(DevInfoCollectorName, NewDevInfoCollector, required)
+	RegisterCollector(DevInfoCollectorName, NewDevInfoCollector, planDevInfo, devInfoCollectorInfo, required, perInterface)
 }
@@ -5,14 +5,41 @@ package devices
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/fetcher"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
 )
 
 var dateCmd *clients.Cmd
 
+// fetcherCache holds the fetchers built for each interface, clock ID or config file.
+// The collectors of every cluster in a run share it so each access takes the lock.
+type fetcherCache struct {
+	fetchers map[string]*fetcher.Fetcher
+	lock     sync.RWMutex
+}
+
+func newFetcherCache() *fetcherCache {
+	return &fetcherCache{fetchers: make(map[string]*fetcher.Fetcher)}
+}
+
+func (cache *fetcherCache) get(key string) (*fetcher.Fetcher, bool) {
+	cache.lock.RLock()
+	defer cache.lock.RUnlock()
+	fetcherInst, ok := cache.fetchers[key]
+	return fetcherInst, ok
+}
+
+// set stores a fetcher, it must be fully set up as other goroutines may use it straight away
+func (cache *fetcherCache) set(key string, fetcherInst *fetcher.Fetcher) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.fetchers[key] = fetcherInst
+}
+
 func formatTimestampAsRFC3339Nano(s string) (string, error) {
 	timestamp, err := utils.ParseTimestamp(strings.TrimSpace(s))
 	if err != nil {
@@ -0,0 +1,243 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package devices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/fetcher"
+)
+
+// CustomInterfacePlaceholder is replaced by the PTP interface name in the commands of a user defined collector
+const CustomInterfacePlaceholder = "{interface}"
+
+// CustomFieldType is the type a value captured by a user defined collector is converted to
+type CustomFieldType string
+
+const (
+	CustomString CustomFieldType = "string"
+	CustomInt    CustomFieldType = "int"
+	CustomFloat  CustomFieldType = "float"
+	CustomBool   CustomFieldType = "bool"
+)
+
+var (
+	validCustomKey   = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+	customFieldTypes = map[CustomFieldType]bool{CustomString: true, CustomInt: true, CustomFloat: true, CustomBool: true}
+)
+
+// CustomCommand is a command run by a user defined collector. If Regex is set each of its
+// named capture groups becomes a value, otherwise the trimmed output is stored under Key.
+type CustomCommand struct {
+	Key     string `json:"key"`
+	Command string `json:"command"`
+	Regex   string `json:"regex,omitempty"`
+}
+
+// CustomInfo holds the values captured by a user defined collector
+type CustomInfo struct {
+	Values     map[string]any `fetcherKey:"values" json:"values"`
+	Timestamp  string         `fetcherKey:"date"   json:"timestamp"`
+	analyserID string
+}
+
+// GetAnalyserFormat returns the json expected by the analysers
+func (info *CustomInfo) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	formatted := callbacks.AnalyserFormatType{
+		ID:   info.analyserID,
+		Data: info,
+	}
+	return []*callbacks.AnalyserFormatType{&formatted}, nil
+}
+
+// customParser extracts the values from the output of a single command
+type customParser struct {
+	regex *regexp.Regexp
+	key   string
+}
+
+// CustomFetcher runs the commands of a user defined collector and converts the captured values to their types
+type CustomFetcher struct {
+	fetcher    *fetcher.Fetcher
+	fields     map[string]CustomFieldType
+	analyserID string
+	parsers    []customParser
+}
+
+func convertCustomValue(value string, fieldType CustomFieldType) (any, error) {
+	var (
+		converted any
+		err       error
+	)
+	switch fieldType {
+	case CustomString, "":
+		converted = value
+	case CustomInt:
+		converted, err = strconv.ParseInt(value, 0, 64)
+	case CustomFloat:
+		converted, err = strconv.ParseFloat(value, 64)
+	case CustomBool:
+		converted, err = strconv.ParseBool(value)
+	default:
+		err = fmt.Errorf("unknown type %s", fieldType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %q to %s: %w", value, fieldType, err)
+	}
+	return converted, nil
+}
+
+// NewCustomFetcher checks the commands and field types of a user defined collector and
+// returns a fetcher which runs them for ptpInterface. Fields which are not given a type are strings.
+func NewCustomFetcher(
+	analyserID string,
+	ptpInterface string,
+	commands []CustomCommand,
+	fields map[string]CustomFieldType,
+) (*CustomFetcher, error) {
+	if len(commands) == 0 {
+		return nil, errors.New("at least one command must be provided")
+	}
+	customFetcher := &CustomFetcher{
+		fetcher:    fetcher.NewFetcher(),
+		fields:     fields,
+		analyserID: analyserID,
+		parsers:    make([]customParser, 0, len(commands)),
+	}
+	customFetcher.fetcher.AddCommand(getDateCommand())
+	customFetcher.fetcher.SetPostProcessor(customFetcher.process)
+
+	keys := make(map[string]bool)
+	captured := make(map[string]bool)
+	for _, command := range commands {
+		// The date command is always run so its key can not be reused
+		if !validCustomKey.MatchString(command.Key) || command.Key == "date" {
+			return nil, fmt.Errorf("invalid command key %q", command.Key)
+		}
+		if keys[command.Key] {
+			return nil, fmt.Errorf("command key %s is used more than once", command.Key)
+		}
+		keys[command.Key] = true
+		if strings.TrimSpace(command.Command) == "" {
+			return nil, fmt.Errorf("command %s is empty", command.Key)
+		}
+		script := strings.ReplaceAll(command.Command, CustomInterfacePlaceholder, ptpInterface)
+		err := customFetcher.fetcher.AddNewCommand(command.Key, script, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add command %s: %w", command.Key, err)
+		}
+
+		parser := customParser{key: command.Key}
+		names := []string{command.Key}
+		if command.Regex != "" {
+			parser.regex, err = regexp.Compile(command.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex for command %s: %w", command.Key, err)
+			}
+			names = make([]string, 0)
+			for _, name := range parser.regex.SubexpNames() {
+				if name != "" {
+					names = append(names, name)
+				}
+			}
+			if len(names) == 0 {
+				return nil, fmt.Errorf("regex for command %s has no named capture groups", command.Key)
+			}
+		}
+		for _, name := range names {
+			if captured[name] {
+				return nil, fmt.Errorf("value %s is captured more than once", name)
+			}
+			captured[name] = true
+		}
+		customFetcher.parsers = append(customFetcher.parsers, parser)
+	}
+
+	for name, fieldType := range fields {
+		if !captured[name] {
+			return nil, fmt.Errorf("field %s is not captured by any command", name)
+		}
+		if !customFieldTypes[fieldType] {
+			return nil, fmt.Errorf("field %s has an unknown type %s", name, fieldType)
+		}
+	}
+	return customFetcher, nil
+}
+
+// process matches the output of each command and converts the captured values
+func (customFetcher *CustomFetcher) process(result map[string]string) (map[string]any, error) {
+	values := make(map[string]any)
+	for _, parser := range customFetcher.parsers {
+		output := result[parser.key]
+		if parser.regex == nil {
+			value, err := convertCustomValue(output, customFetcher.fields[parser.key])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse output of %s: %w", parser.key, err)
+			}
+			values[parser.key] = value
+			continue
+		}
+		match := parser.regex.FindStringSubmatch(output)
+		if len(match) == 0 {
+			return nil, fmt.Errorf("unable to parse output of %s: %s", parser.key, output)
+		}
+		for i, name := range parser.regex.SubexpNames() {
+			if name == "" {
+				continue
+			}
+			value, err := convertCustomValue(match[i], customFetcher.fields[name])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s from output of %s: %w", name, parser.key, err)
+			}
+			values[name] = value
+		}
+	}
+	return map[string]any{"values": values}, nil
+}
+
+// GetValueNames returns the names of the captured values in the order of the commands
+func (customFetcher *CustomFetcher) GetValueNames() []string {
+	names := make([]string, 0, len(customFetcher.parsers))
+	for _, parser := range customFetcher.parsers {
+		if parser.regex == nil {
+			names = append(names, parser.key)
+			continue
+		}
+		for _, name := range parser.regex.SubexpNames() {
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// GetFieldType returns the type the named value is converted to
+func (customFetcher *CustomFetcher) GetFieldType(name string) CustomFieldType {
+	if fieldType, ok := customFetcher.fields[name]; ok && fieldType != "" {
+		return fieldType
+	}
+	return CustomString
+}
+
+// GetCommand returns the script which is run to fetch the CustomInfo
+func (customFetcher *CustomFetcher) GetCommand() string {
+	return customFetcher.fetcher.GetCommand()
+}
+
+// Fetch runs the commands and returns the values they captured
+func (customFetcher *CustomFetcher) Fetch(ctx context.Context, execCtx clients.ExecContext) (CustomInfo, error) {
+	info := CustomInfo{analyserID: customFetcher.analyserID}
+	err := customFetcher.fetcher.Fetch(ctx, execCtx, &info)
+	if err != nil {
+		return info, fmt.Errorf("failed to fetch %s: %w", customFetcher.analyserID, err)
+	}
+	return info, nil
+}
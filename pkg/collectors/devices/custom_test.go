@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package devices_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+)
+
+var _ = Describe("CustomFetcher", func() {
+	When("the commands are valid", func() {
+		It("should capture the values and convert them to their types", func() {
+			customFetcher, err := devices.NewCustomFetcher(
+				"test/custom",
+				"ens7f0",
+				[]devices.CustomCommand{
+					{
+						Key:     "offset",
+						Command: "echo 'offset -12 freq 3.5 locked true'",
+						Regex:   `offset (?P<offset>-?\d+) freq (?P<freq>\S+) locked (?P<locked>\w+)`,
+					},
+					{Key: "iface", Command: "echo '  {interface}  '"},
+				},
+				map[string]devices.CustomFieldType{
+					"offset": devices.CustomInt,
+					"freq":   devices.CustomFloat,
+					"locked": devices.CustomBool,
+				},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(customFetcher.GetCommand()).To(ContainSubstring("echo '<iface>';echo '  ens7f0  ';echo '</iface>';"))
+
+			info, err := customFetcher.Fetch(context.Background(), clients.NewLocalExecContext())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Timestamp).NotTo(BeEmpty())
+			Expect(info.Values).To(Equal(map[string]any{
+				"offset": int64(-12),
+				"freq":   3.5,
+				"locked": true,
+				"iface":  "ens7f0",
+			}))
+			formatted, err := info.GetAnalyserFormat()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(formatted[0].ID).To(Equal("test/custom"))
+		})
+		It("should return an error if the output does not match", func() {
+			customFetcher, err := devices.NewCustomFetcher(
+				"test/custom",
+				"",
+				[]devices.CustomCommand{{Key: "offset", Command: "echo nothing", Regex: `offset (?P<offset>\d+)`}},
+				nil,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = customFetcher.Fetch(context.Background(), clients.NewLocalExecContext())
+			Expect(err).To(MatchError(ContainSubstring("unable to parse output of offset")))
+		})
+	})
+	DescribeTable("invalid commands or fields",
+		func(commands []devices.CustomCommand, fields map[string]devices.CustomFieldType, message string) {
+			_, err := devices.NewCustomFetcher("test/custom", "", commands, fields)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("no commands", []devices.CustomCommand{}, nil, "at least one command"),
+		Entry("reserved key", []devices.CustomCommand{{Key: "date", Command: "date"}}, nil, "invalid command key"),
+		Entry("invalid regex", []devices.CustomCommand{{Key: "a", Command: "true", Regex: "("}}, nil, "invalid regex"),
+		Entry("no named groups", []devices.CustomCommand{{Key: "a", Command: "true", Regex: `(\d+)`}}, nil, "no named"),
+		Entry("uncaptured field", []devices.CustomCommand{{Key: "a", Command: "true"}},
+			map[string]devices.CustomFieldType{"b": devices.CustomInt}, "not captured"),
+		Entry("unknown type", []devices.CustomCommand{{Key: "a", Command: "true"}},
+			map[string]devices.CustomFieldType{"a": "duration"}, "unknown type"),
+	)
+})
@@ -3,6 +3,7 @@
 package devices
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -44,7 +45,7 @@ func (ptpDevInfo *PTPDeviceInfo) GetAnalyserFormat() ([]*callbacks.AnalyserForma
 }
 
 var (
-	devFetcher   map[string]*fetcher.Fetcher
+	devFetcher   *fetcherCache
 	ethtoolRegex = regexp.MustCompile(`version: (.*)\nfirmware-version: (.*)\n`)
 	// driver: ice
 	// version: 1.11.20.7
@@ -59,7 +60,7 @@ var (
 )
 
 func init() {
-	devFetcher = make(map[string]*fetcher.Fetcher)
+	devFetcher = newFetcherCache()
 }
 
 func extractOffsetFromTimestamp(result map[string]string) (map[string]any, error) {
@@ -142,28 +143,44 @@ func BuildPTPDeviceInfo(interfaceName string) error { //nolint:dupl // Further d
 		log.Errorf("failed to create fetcher for devInfo: %s", err.Error())
 		return fmt.Errorf("failed to create fetcher for devInfo: %w", err)
 	}
-	devFetcher[interfaceName] = fetcherInst
 	fetcherInst.SetPostProcessor(devInfoPostProcessor)
+	devFetcher.set(interfaceName, fetcherInst)
 	return nil
 }
 
+// GetPTPDeviceInfoCommand returns the script which is run to fetch the PTPDeviceInfo for an interface
+func GetPTPDeviceInfoCommand(interfaceName string) (string, error) {
+	if _, ok := devFetcher.get(interfaceName); !ok {
+		err := BuildPTPDeviceInfo(interfaceName)
+		if err != nil {
+			return "", err
+		}
+	}
+	fetcherInst, _ := devFetcher.get(interfaceName)
+	return fetcherInst.GetCommand(), nil
+}
+
 // GetPTPDeviceInfo returns the PTPDeviceInfo for an interface
-func GetPTPDeviceInfo(interfaceName string, ctx clients.ExecContext) (PTPDeviceInfo, error) {
+func GetPTPDeviceInfo(
+	ctx context.Context,
+	interfaceName string,
+	execCtx clients.ExecContext,
+) (PTPDeviceInfo, error) {
 	devInfo := PTPDeviceInfo{}
 	// Find the dev for the GNSS for this interface
-	fetcherInst, fetchedInstanceOk := devFetcher[interfaceName]
+	fetcherInst, fetchedInstanceOk := devFetcher.get(interfaceName)
 	if !fetchedInstanceOk {
 		err := BuildPTPDeviceInfo(interfaceName)
 		if err != nil {
 			return devInfo, err
 		}
-		fetcherInst, fetchedInstanceOk = devFetcher[interfaceName]
+		fetcherInst, fetchedInstanceOk = devFetcher.get(interfaceName)
 		if !fetchedInstanceOk {
 			return devInfo, errors.New("failed to create fetcher for PTPDeviceInfo")
 		}
 	}
 
-	err := fetcherInst.Fetch(ctx, &devInfo)
+	err := fetcherInst.Fetch(ctx, execCtx, &devInfo)
 	if err != nil {
 		log.Debugf("failed to fetch devInfo %s", err.Error())
 		return devInfo, fmt.Errorf("failed to fetch devInfo %w", err)
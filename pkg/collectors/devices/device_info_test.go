@@ -4,6 +4,7 @@ package devices_test
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"net/url"
 	"testing"
@@ -86,7 +87,7 @@ var _ = Describe("NewContainerContext", func() {
 
 			ctx, err := clients.NewContainerContext(clientset, "TestNamespace", "Test", "TestContainer")
 			Expect(err).NotTo(HaveOccurred())
-			info, err := devices.GetPTPDeviceInfo("aFakeInterface", ctx)
+			info, err := devices.GetPTPDeviceInfo(context.Background(), "aFakeInterface", ctx)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(info.Timestamp).To(Equal("2023-06-16T11:49:47.0584Z"))
 			Expect(info.DeviceID).To(Equal(devID))
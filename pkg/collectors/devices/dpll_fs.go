@@ -3,6 +3,7 @@
 package devices
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -26,6 +27,11 @@ type DevFilesystemDPLLInfo struct {
 	PPSOffset float64 `fetcherKey:"dpll_1_offset" json:"terror"`
 }
 
+// GetTimeError returns the offset of the PPS DPLL in the units expected by the analysers
+func (dpllInfo *DevFilesystemDPLLInfo) GetTimeError() float64 {
+	return dpllInfo.PPSOffset / unitConversionFactor
+}
+
 // AnalyserJSON returns the json expected by the analysers
 func (dpllInfo *DevFilesystemDPLLInfo) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
 	formatted := callbacks.AnalyserFormatType{
@@ -34,18 +40,18 @@ func (dpllInfo *DevFilesystemDPLLInfo) GetAnalyserFormat() ([]*callbacks.Analyse
 			"timestamp": dpllInfo.Timestamp,
 			"eecstate":  dpllInfo.EECState,
 			"state":     dpllInfo.PPSState,
-			"terror":    dpllInfo.PPSOffset / unitConversionFactor,
+			"terror":    dpllInfo.GetTimeError(),
 		},
 	}
 	return []*callbacks.AnalyserFormatType{&formatted}, nil
 }
 
 var (
-	dpllFSFetcher map[string]*fetcher.Fetcher
+	dpllFSFetcher *fetcherCache
 )
 
 func init() {
-	dpllFSFetcher = make(map[string]*fetcher.Fetcher)
+	dpllFSFetcher = newFetcherCache()
 }
 
 func postProcessDPLLFilesystem(result map[string]string) (map[string]any, error) {
@@ -85,26 +91,42 @@ func BuildFilesystemDPLLInfoFetcher(interfaceName string) error { //nolint:dupl
 		log.Errorf("failed to create fetcher for dpll: %s", err.Error())
 		return fmt.Errorf("failed to create fetcher for dpll: %w", err)
 	}
-	dpllFSFetcher[interfaceName] = fetcherInst
 	fetcherInst.SetPostProcessor(postProcessDPLLFilesystem)
+	dpllFSFetcher.set(interfaceName, fetcherInst)
 	return nil
 }
 
+// GetDevDPLLFilesystemCommand returns the script which is run to fetch the DPLL info for an interface
+func GetDevDPLLFilesystemCommand(interfaceName string) (string, error) {
+	if _, ok := dpllFSFetcher.get(interfaceName); !ok {
+		err := BuildFilesystemDPLLInfoFetcher(interfaceName)
+		if err != nil {
+			return "", err
+		}
+	}
+	fetcherInst, _ := dpllFSFetcher.get(interfaceName)
+	return fetcherInst.GetCommand(), nil
+}
+
 // GetDevDPLLFilesystemInfo returns the device DPLL info for an interface.
-func GetDevDPLLFilesystemInfo(ctx clients.ExecContext, interfaceName string) (DevFilesystemDPLLInfo, error) {
+func GetDevDPLLFilesystemInfo(
+	ctx context.Context,
+	execCtx clients.ExecContext,
+	interfaceName string,
+) (DevFilesystemDPLLInfo, error) {
 	dpllInfo := DevFilesystemDPLLInfo{}
-	fetcherInst, fetchedInstanceOk := dpllFSFetcher[interfaceName]
+	fetcherInst, fetchedInstanceOk := dpllFSFetcher.get(interfaceName)
 	if !fetchedInstanceOk {
 		err := BuildFilesystemDPLLInfoFetcher(interfaceName)
 		if err != nil {
 			return dpllInfo, err
 		}
-		fetcherInst, fetchedInstanceOk = dpllFSFetcher[interfaceName]
+		fetcherInst, fetchedInstanceOk = dpllFSFetcher.get(interfaceName)
 		if !fetchedInstanceOk {
 			return dpllInfo, errors.New("failed to create fetcher for DPLLInfo")
 		}
 	}
-	err := fetcherInst.Fetch(ctx, &dpllInfo)
+	err := fetcherInst.Fetch(ctx, execCtx, &dpllInfo)
 	if err != nil {
 		log.Debugf("failed to fetch dpllInfo %s", err.Error())
 		return dpllInfo, fmt.Errorf("failed to fetch dpllInfo %w", err)
@@ -112,7 +134,7 @@ func GetDevDPLLFilesystemInfo(ctx clients.ExecContext, interfaceName string) (De
 	return dpllInfo, nil
 }
 
-func IsDPLLFileSystemPresent(ctx clients.ExecContext, interfaceName string) (bool, error) {
+func buildDPLLFileSystemPresentFetcher(interfaceName string) (*fetcher.Fetcher, error) {
 	fetcherInst, err := fetcher.FetcherFactory(
 		[]*clients.Cmd{},
 		[]fetcher.AddCommandArgs{
@@ -124,7 +146,24 @@ func IsDPLLFileSystemPresent(ctx clients.ExecContext, interfaceName string) (boo
 		},
 	)
 	if err != nil {
-		return false, fmt.Errorf("failed to build fetcher to check DPLL FS  %w", err)
+		return nil, fmt.Errorf("failed to build fetcher to check DPLL FS  %w", err)
+	}
+	return fetcherInst, nil
+}
+
+// GetDPLLFileSystemPresentCommand returns the script which is run to check if the DPLL filesystem is present
+func GetDPLLFileSystemPresentCommand(interfaceName string) (string, error) {
+	fetcherInst, err := buildDPLLFileSystemPresentFetcher(interfaceName)
+	if err != nil {
+		return "", err
+	}
+	return fetcherInst.GetCommand(), nil
+}
+
+func IsDPLLFileSystemPresent(ctx context.Context, execCtx clients.ExecContext, interfaceName string) (bool, error) {
+	fetcherInst, err := buildDPLLFileSystemPresentFetcher(interfaceName)
+	if err != nil {
+		return false, err
 	}
 	type Paths struct {
 		Paths string `fetcherKey:"paths"`
@@ -136,7 +175,7 @@ func IsDPLLFileSystemPresent(ctx clients.ExecContext, interfaceName string) (boo
 		"dpll_1_offset": false,
 	}
 
-	err = fetcherInst.Fetch(ctx, &paths)
+	err = fetcherInst.Fetch(ctx, execCtx, &paths)
 	if err != nil {
 		return false, fmt.Errorf("failed to check DPLL FS  %w", err)
 	}
@@ -4,6 +4,7 @@ package devices_test
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"net/url"
 
@@ -56,7 +57,7 @@ var _ = Describe("NewContainerContext", func() {
 
 			ctx, err := clients.NewContainerContext(clientset, "TestNamespace", "Test", "TestContainer")
 			Expect(err).NotTo(HaveOccurred())
-			info, err := devices.GetDevDPLLFilesystemInfo(ctx, "aFakeInterface")
+			info, err := devices.GetDevDPLLFilesystemInfo(context.Background(), ctx, "aFakeInterface")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(info.Timestamp).To(Equal("2023-06-16T11:49:47.0584Z"))
 			Expect(info.EECState).To(Equal(eecState))
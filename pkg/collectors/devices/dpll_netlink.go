@@ -3,6 +3,7 @@
 package devices
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,6 +26,20 @@ var states = map[string]string{
 	"holdover":      "4",
 }
 
+// GetDPLLStateValue returns the value reported for a named DPLL state such as "locked" or "holdover".
+// Values which are already numeric are returned unchanged.
+func GetDPLLStateValue(state string) (string, bool) {
+	if value, ok := states[state]; ok {
+		return value, true
+	}
+	for _, value := range states {
+		if value == state {
+			return value, true
+		}
+	}
+	return "", false
+}
+
 type DevNetlinkDPLLInfo struct {
 	Timestamp string `fetcherKey:"date" json:"timestamp"`
 	EECState  string `fetcherKey:"eec"  json:"eecstate"`
@@ -69,13 +84,13 @@ type NetlinkEntry struct {
 //   'type': 'pps'}]
 
 var (
-	dpllNetlinkFetcher map[string]*fetcher.Fetcher
-	dpllClockIDFetcher map[string]*fetcher.Fetcher
+	dpllNetlinkFetcher *fetcherCache
+	dpllClockIDFetcher *fetcherCache
 )
 
 func init() {
-	dpllNetlinkFetcher = make(map[string]*fetcher.Fetcher)
-	dpllClockIDFetcher = make(map[string]*fetcher.Fetcher)
+	dpllNetlinkFetcher = newFetcherCache()
+	dpllClockIDFetcher = newFetcherCache()
 }
 
 func buildPostProcessDPLLNetlink(clockID *big.Int) fetcher.PostProcessFuncType {
@@ -104,9 +119,7 @@ func buildPostProcessDPLLNetlink(clockID *big.Int) fetcher.PostProcessFuncType {
 	}
 }
 
-// BuildDPLLNetlinkInfoFetcher popluates the fetcher required for
-// collecting the DPLLInfo
-func BuildDPLLNetlinkInfoFetcher(clockID *big.Int) error { //nolint:dupl // Further dedup risks be too abstract or fragile
+func newDPLLNetlinkInfoFetcher() (*fetcher.Fetcher, error) {
 	fetcherInst, err := fetcher.FetcherFactory(
 		[]*clients.Cmd{dateCmd},
 		[]fetcher.AddCommandArgs{
@@ -119,28 +132,52 @@ func BuildDPLLNetlinkInfoFetcher(clockID *big.Int) error { //nolint:dupl // Furt
 	)
 	if err != nil {
 		log.Errorf("failed to create fetcher for dpll netlink: %s", err.Error())
-		return fmt.Errorf("failed to create fetcher for dpll netlink: %w", err)
+		return nil, fmt.Errorf("failed to create fetcher for dpll netlink: %w", err)
+	}
+	return fetcherInst, nil
+}
+
+// BuildDPLLNetlinkInfoFetcher popluates the fetcher required for
+// collecting the DPLLInfo
+func BuildDPLLNetlinkInfoFetcher(clockID *big.Int) error {
+	fetcherInst, err := newDPLLNetlinkInfoFetcher()
+	if err != nil {
+		return err
 	}
-	dpllNetlinkFetcher[clockID.String()] = fetcherInst
 	fetcherInst.SetPostProcessor(buildPostProcessDPLLNetlink(clockID))
+	dpllNetlinkFetcher.set(clockID.String(), fetcherInst)
 	return nil
 }
 
+// GetDevDPLLNetlinkCommand returns the script which is run to fetch the DPLL info through netlink.
+// The script is the same for every clock ID as the output is filtered after it has been fetched.
+func GetDevDPLLNetlinkCommand() (string, error) {
+	fetcherInst, err := newDPLLNetlinkInfoFetcher()
+	if err != nil {
+		return "", err
+	}
+	return fetcherInst.GetCommand(), nil
+}
+
 // GetDevDPLLInfo returns the device DPLL info for an interface.
-func GetDevDPLLNetlinkInfo(ctx clients.ExecContext, clockID *big.Int) (DevNetlinkDPLLInfo, error) {
+func GetDevDPLLNetlinkInfo(
+	ctx context.Context,
+	execCtx clients.ExecContext,
+	clockID *big.Int,
+) (DevNetlinkDPLLInfo, error) {
 	dpllInfo := DevNetlinkDPLLInfo{}
-	fetcherInst, fetchedInstanceOk := dpllNetlinkFetcher[clockID.String()]
+	fetcherInst, fetchedInstanceOk := dpllNetlinkFetcher.get(clockID.String())
 	if !fetchedInstanceOk {
 		err := BuildDPLLNetlinkInfoFetcher(clockID)
 		if err != nil {
 			return dpllInfo, err
 		}
-		fetcherInst, fetchedInstanceOk = dpllNetlinkFetcher[clockID.String()]
+		fetcherInst, fetchedInstanceOk = dpllNetlinkFetcher.get(clockID.String())
 		if !fetchedInstanceOk {
 			return dpllInfo, errors.New("failed to create fetcher for DPLLInfo using netlink interface")
 		}
 	}
-	err := fetcherInst.Fetch(ctx, &dpllInfo)
+	err := fetcherInst.Fetch(ctx, execCtx, &dpllInfo)
 	if err != nil {
 		log.Debugf("failed to fetch dpllInfo  via netlink: %s", err.Error())
 		return dpllInfo, fmt.Errorf("failed to fetch dpllInfo via netlink: %w", err)
@@ -168,7 +205,7 @@ func BuildClockIDFetcher(interfaceName string) error {
 		return fmt.Errorf("failed to create fetcher for dpll clock ID: %w", err)
 	}
 	fetcherInst.SetPostProcessor(postProcessDPLLNetlinkClockID)
-	dpllClockIDFetcher[interfaceName] = fetcherInst
+	dpllClockIDFetcher.set(interfaceName, fetcherInst)
 	return nil
 }
 
@@ -191,20 +228,32 @@ type NetlinkClockID struct {
 	Timestamp string   `fetcherKey:"date"          json:"timestamp"`
 }
 
-func GetClockID(ctx clients.ExecContext, interfaceName string) (NetlinkClockID, error) {
+// GetClockIDCommand returns the script which is run to find the clock ID of an interface
+func GetClockIDCommand(interfaceName string) (string, error) {
+	if _, ok := dpllClockIDFetcher.get(interfaceName); !ok {
+		err := BuildClockIDFetcher(interfaceName)
+		if err != nil {
+			return "", err
+		}
+	}
+	fetcherInst, _ := dpllClockIDFetcher.get(interfaceName)
+	return fetcherInst.GetCommand(), nil
+}
+
+func GetClockID(ctx context.Context, execCtx clients.ExecContext, interfaceName string) (NetlinkClockID, error) {
 	clockID := NetlinkClockID{}
-	fetcherInst, fetchedInstanceOk := dpllClockIDFetcher[interfaceName]
+	fetcherInst, fetchedInstanceOk := dpllClockIDFetcher.get(interfaceName)
 	if !fetchedInstanceOk {
 		err := BuildClockIDFetcher(interfaceName)
 		if err != nil {
 			return clockID, err
 		}
-		fetcherInst, fetchedInstanceOk = dpllClockIDFetcher[interfaceName]
+		fetcherInst, fetchedInstanceOk = dpllClockIDFetcher.get(interfaceName)
 		if !fetchedInstanceOk {
 			return clockID, errors.New("failed to create fetcher for DPLLInfo using netlink interface")
 		}
 	}
-	err := fetcherInst.Fetch(ctx, &clockID)
+	err := fetcherInst.Fetch(ctx, execCtx, &clockID)
 	if err != nil {
 		log.Debugf("failed to fetch netlink clockID %s", err.Error())
 		return clockID, fmt.Errorf("failed to fetch netlink clockID %w", err)
@@ -3,6 +3,7 @@
 package devices
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -271,10 +272,15 @@ func processUBX(result map[string]string) (map[string]any, error) { //nolint:fun
 	return processedResult, nil
 }
 
+// GetGPSNavCommand returns the script which is run to fetch the GPSDetails
+func GetGPSNavCommand() string {
+	return gpsFetcher.GetCommand()
+}
+
 // GetGPSNav returns GPSNav of the host
-func GetGPSNav(ctx clients.ExecContext) (GPSDetails, error) {
+func GetGPSNav(ctx context.Context, execCtx clients.ExecContext) (GPSDetails, error) {
 	gpsNav := GPSDetails{}
-	err := gpsFetcher.Fetch(ctx, &gpsNav)
+	err := gpsFetcher.Fetch(ctx, execCtx, &gpsNav)
 	if err != nil {
 		log.Debugf("failed to fetch gpsNav %s", err.Error())
 		return gpsNav, fmt.Errorf("failed to fetch gpsNav %w", err)
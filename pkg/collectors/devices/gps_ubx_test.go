@@ -4,6 +4,7 @@ package devices_test
 
 import (
 	"bufio"
+	"context"
 	"net/url"
 	"strings"
 
@@ -68,7 +69,7 @@ var _ = Describe("GetGPSNav", func() {
 			ctx, err := clients.NewContainerContext(clientset, "TestNamespace", "Test", "TestContainer")
 			Expect(err).NotTo(HaveOccurred())
 
-			gpsInfo, err := devices.GetGPSNav(ctx)
+			gpsInfo, err := devices.GetGPSNav(context.Background(), ctx)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(gpsInfo.NavStatus.Timestamp).To(Equal("2023-06-16T11:49:47.0584Z"))
 			Expect(gpsInfo.NavStatus.GPSFix).To(Equal(3))
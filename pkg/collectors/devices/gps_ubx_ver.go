@@ -3,6 +3,7 @@
 package devices
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -178,9 +179,9 @@ func processGPSVer(result map[string]string) (map[string]any, error) {
 }
 
 // GetGPSVersions returns GPSVersions of the host
-func GetGPSVersions(ctx clients.ExecContext) (GPSVersions, error) {
+func GetGPSVersions(ctx context.Context, execCtx clients.ExecContext) (GPSVersions, error) {
 	gpsVer := GPSVersions{}
-	err := gpsVerFetcher.Fetch(ctx, &gpsVer)
+	err := gpsVerFetcher.Fetch(ctx, execCtx, &gpsVer)
 	if err != nil {
 		log.Debugf("failed to fetch gpsVer %s", err.Error())
 		return gpsVer, fmt.Errorf("failed to fetch gpsVer %w", err)
@@ -4,6 +4,7 @@ package devices_test
 
 import (
 	"bufio"
+	"context"
 	"net/url"
 	"strings"
 
@@ -75,7 +76,7 @@ var _ = Describe("GetGPSNav", func() {
 			ctx, err := clients.NewContainerContext(clientset, "TestNamespace", "Test", "TestContainer")
 			Expect(err).NotTo(HaveOccurred())
 
-			gpsInfo, err := devices.GetGPSVersions(ctx)
+			gpsInfo, err := devices.GetGPSVersions(context.Background(), ctx)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(gpsInfo.Timestamp).To(Equal("2023-07-13T14:58:52.4728Z"))
 			Expect(gpsInfo.FirmwareVersion).To(Equal("TIM 2.20"))
@@ -3,9 +3,11 @@
 package devices
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
@@ -38,6 +40,18 @@ func (gmSetting *PMCInfo) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType,
 	return []*callbacks.AnalyserFormatType{&formatted}, nil
 }
 
+// PMCDataSets holds the parsed responses to each of the PMC queries. A data set
+// which could not be parsed is left nil and the reason is added to Errors.
+type PMCDataSets struct {
+	Ports          []PMCPortDataSet          `fetcherKey:"ports"`
+	Errors         []error                   `fetcherKey:"errors"`
+	GMSettings     *PMCInfo                  `fetcherKey:"gmSettings"`
+	Parent         *PMCParentDataSet         `fetcherKey:"parentDataSet"`
+	Current        *PMCCurrentDataSet        `fetcherKey:"currentDataSet"`
+	TimeStatus     *PMCTimeStatus            `fetcherKey:"timeStatus"`
+	TimeProperties *PMCTimePropertiesDataSet `fetcherKey:"timeProperties"`
+}
+
 // MapStringToInt converts map string to map int
 func MapStringToInt(inputMap map[string]string) (map[string]int, error) {
 	convertedMap := make(map[string]int)
@@ -54,7 +68,7 @@ func MapStringToInt(inputMap map[string]string) (map[string]int, error) {
 }
 
 var (
-	pmcFetcher *fetcher.Fetcher
+	pmcFetcher *fetcherCache
 	pmcRegEx   = regexp.MustCompile(
 		`\sclockClass\s+(\d+)` +
 			`\s*clockAccuracy\s+(.+)\n` +
@@ -83,26 +97,67 @@ var (
 	)
 )
 
+// getGMSettingsCommand returns the pmc call which queries the grandmaster settings
+func getGMSettingsCommand(configFile string) string {
+	return fmt.Sprintf("pmc -u -f %s  'GET GRANDMASTER_SETTINGS_NP'", configFile)
+}
+
+// getPMCDataSetsCommand returns the pmc call which sends all of the data set queries
+func getPMCDataSetsCommand(configFile string) string {
+	queries := make([]string, 0, len(pmcDataSetQueries))
+	for _, query := range pmcDataSetQueries {
+		queries = append(queries, fmt.Sprintf("'GET %s'", query))
+	}
+	return fmt.Sprintf("pmc -u -f %s %s", configFile, strings.Join(queries, " "))
+}
+
 func init() {
-	pmcFetcher = fetcher.NewFetcher()
-	pmcFetcher.SetPostProcessor(processPMC)
-	pmcFetcher.AddCommand(getDateCommand())
-	err := pmcFetcher.AddNewCommand(
-		"PMC",
-		"pmc -u -f /var/run/ptp4l.0.config  'GET GRANDMASTER_SETTINGS_NP'",
-		true,
+	pmcFetcher = newFetcherCache()
+}
+
+// BuildPMCFetcher populates the fetcher required for collecting
+// the PMCDataSets of the ptp4l instance which uses configFile
+func BuildPMCFetcher(configFile string) error {
+	fetcherInst, err := fetcher.FetcherFactory(
+		[]*clients.Cmd{getDateCommand()},
+		[]fetcher.AddCommandArgs{
+			{
+				Key:     "PMC",
+				Command: getGMSettingsCommand(configFile),
+				Trim:    true,
+			},
+			{
+				Key:     "dataSets",
+				Command: getPMCDataSetsCommand(configFile),
+				Trim:    true,
+			},
+		},
 	)
 	if err != nil {
-		panic(fmt.Errorf("failed to setup PMC fetcher %w", err))
+		log.Errorf("failed to create fetcher for PMC: %s", err.Error())
+		return fmt.Errorf("failed to create fetcher for PMC: %w", err)
+	}
+	fetcherInst.SetPostProcessor(processPMC)
+	pmcFetcher.set(configFile, fetcherInst)
+	return nil
+}
+
+func getPMCFetcher(configFile string) (*fetcher.Fetcher, error) {
+	if _, ok := pmcFetcher.get(configFile); !ok {
+		err := BuildPMCFetcher(configFile)
+		if err != nil {
+			return nil, err
+		}
 	}
+	fetcherInst, _ := pmcFetcher.get(configFile)
+	return fetcherInst, nil
 }
 
-func processPMC(result map[string]string) (map[string]any, error) { //nolint:funlen // allow slightly long function
-	processedResult := make(map[string]any)
-	match := pmcRegEx.FindStringSubmatch(result["PMC"])
+func processGMSettings(output, timestamp string) (*PMCInfo, error) {
+	match := pmcRegEx.FindStringSubmatch(output)
 
 	if len(match) == 0 {
-		return processedResult, fmt.Errorf("unable to parse pmc output: %s", result["PMC"])
+		return nil, fmt.Errorf("unable to parse pmc output: %s", output)
 	}
 
 	valuesToConvert := map[string]string{
@@ -118,31 +173,134 @@ func processPMC(result map[string]string) (map[string]any, error) { //nolint:fun
 
 	convertedMap, err := MapStringToInt(valuesToConvert)
 	if err != nil {
-		return processedResult, err
+		return nil, err
+	}
+
+	return &PMCInfo{
+		Timestamp:               timestamp,
+		TimeSource:              match[11],
+		ClockAccuracy:           match[2],
+		OffsetScaledLogVariance: match[3],
+		ClockClass:              convertedMap["clockClass"],
+		CurrentUtcOffset:        convertedMap["currentUtcOffset"],
+		Leap61:                  convertedMap["leap61"],
+		Leap59:                  convertedMap["leap59"],
+		CurrentUtcOffsetValid:   convertedMap["currentUtcOffsetValid"],
+		PtpTimescale:            convertedMap["ptpTimescale"],
+		TimeTraceable:           convertedMap["timeTraceable"],
+		FrequencyTraceable:      convertedMap["frequencyTraceable"],
+	}, nil
+}
+
+// processPMC parses the grandmaster settings and each of the data sets on their own so that a query
+// pmc got no response to, for example because it timed out, only loses that data set
+func processPMC(result map[string]string) (map[string]any, error) {
+	timestamp := result["date"]
+	dataSets := result["dataSets"]
+	errs := make([]error, 0)
+	keepError := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	gmSettings, err := processGMSettings(result["PMC"], timestamp)
+	keepError(err)
+	parent, err := parseParentDataSet(dataSets, timestamp)
+	keepError(err)
+	current, err := parseCurrentDataSet(dataSets, timestamp)
+	keepError(err)
+	timeStatus, err := parseTimeStatus(dataSets, timestamp)
+	keepError(err)
+	ports, err := parsePortDataSets(dataSets, timestamp)
+	keepError(err)
+	timeProperties, err := parseTimePropertiesDataSet(dataSets, timestamp)
+	keepError(err)
+	return map[string]any{
+		"gmSettings":     gmSettings,
+		"parentDataSet":  parent,
+		"currentDataSet": current,
+		"timeStatus":     timeStatus,
+		"ports":          ports,
+		"timeProperties": timeProperties,
+		"errors":         errs,
+	}, nil
+}
+
+// GetPMCCommand returns the script which is run to fetch the PMCDataSets of the ptp4l instance which uses configFile
+func GetPMCCommand(configFile string) (string, error) {
+	fetcherInst, err := getPMCFetcher(configFile)
+	if err != nil {
+		return "", err
+	}
+	return fetcherInst.GetCommand(), nil
+}
+
+// GetPMC returns the grandmaster settings and the data sets of the ptp4l instance which uses configFile.
+// An error is only returned if pmc could not be run, the data sets which could not be parsed are in Errors.
+func GetPMC(ctx context.Context, execCtx clients.ExecContext, configFile string) (PMCDataSets, error) {
+	dataSets := PMCDataSets{}
+	fetcherInst, err := getPMCFetcher(configFile)
+	if err != nil {
+		return dataSets, err
+	}
+	err = fetcherInst.Fetch(ctx, execCtx, &dataSets)
+	if err != nil {
+		log.Debugf("failed to fetch PMC data sets %s", err.Error())
+		return dataSets, fmt.Errorf("failed to fetch PMC data sets %w", err)
+	}
+	return dataSets, nil
+}
+
+// buildLegacyGMSettingsFetcher returns the fetcher which only queried the grandmaster settings of the
+// default config, before the data sets were collected, so that captures of it can still be replayed
+func buildLegacyGMSettingsFetcher() (*fetcher.Fetcher, error) {
+	fetcherInst, err := fetcher.FetcherFactory(
+		[]*clients.Cmd{getDateCommand()},
+		[]fetcher.AddCommandArgs{
+			{
+				Key:     "PMC",
+				Command: getGMSettingsCommand(DefaultPTP4lConfig),
+				Trim:    true,
+			},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fetcher for legacy PMC: %w", err)
 	}
+	fetcherInst.SetPostProcessor(processLegacyGMSettings)
+	return fetcherInst, nil
+}
 
-	processedResult["timeSource"] = match[11]
-	processedResult["clockAccuracy"] = match[2]
-	processedResult["offsetScaledLogVariance"] = match[3]
-	processedResult["clockClass"] = convertedMap["clockClass"]
-	processedResult["currentUtcOffset"] = convertedMap["currentUtcOffset"]
-	processedResult["leap61"] = convertedMap["leap61"]
-	processedResult["leap59"] = convertedMap["leap59"]
-	processedResult["currentUtcOffsetValid"] = convertedMap["currentUtcOffsetValid"]
-	processedResult["ptpTimescale"] = convertedMap["ptpTimescale"]
-	processedResult["timeTraceable"] = convertedMap["timeTraceable"]
-	processedResult["frequencyTraceable"] = convertedMap["frequencyTraceable"]
+func processLegacyGMSettings(result map[string]string) (map[string]any, error) {
+	gmSettings, err := processGMSettings(result["PMC"], result["date"])
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"gmSettings": gmSettings}, nil
+}
 
-	return processedResult, nil
+// GetLegacyGMSettingsCommand returns the script which was run to fetch only the grandmaster settings
+func GetLegacyGMSettingsCommand() (string, error) {
+	fetcherInst, err := buildLegacyGMSettingsFetcher()
+	if err != nil {
+		return "", err
+	}
+	return fetcherInst.GetCommand(), nil
 }
 
-// GetPMC returns PMCInfo
-func GetPMC(ctx clients.ExecContext) (PMCInfo, error) {
-	gmSetting := PMCInfo{}
-	err := pmcFetcher.Fetch(ctx, &gmSetting)
+// GetLegacyGMSettings returns the grandmaster settings from the output of the script from GetLegacyGMSettingsCommand
+func GetLegacyGMSettings(ctx context.Context, execCtx clients.ExecContext) (*PMCInfo, error) {
+	fetcherInst, err := buildLegacyGMSettingsFetcher()
+	if err != nil {
+		return nil, err
+	}
+	type GMSettings struct {
+		GMSettings *PMCInfo `fetcherKey:"gmSettings"`
+	}
+	gmSettings := GMSettings{}
+	err = fetcherInst.Fetch(ctx, execCtx, &gmSettings)
 	if err != nil {
-		log.Debugf("failed to fetch gmSetting %s", err.Error())
-		return gmSetting, fmt.Errorf("failed to fetch gmSetting %w", err)
+		return nil, fmt.Errorf("failed to fetch gmSetting %w", err)
 	}
-	return gmSetting, nil
+	return gmSettings.GMSettings, nil
 }
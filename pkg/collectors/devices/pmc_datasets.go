@@ -0,0 +1,326 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package devices
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+)
+
+const (
+	pmcParentDataSet         = "PARENT_DATA_SET"
+	pmcCurrentDataSet        = "CURRENT_DATA_SET"
+	pmcTimeStatus            = "TIME_STATUS_NP"
+	pmcPortDataSet           = "PORT_DATA_SET"
+	pmcTimePropertiesDataSet = "TIME_PROPERTIES_DATA_SET"
+)
+
+var (
+	// pmcDataSetQueries are sent in a single pmc call, GRANDMASTER_SETTINGS_NP is fetched on its own
+	pmcDataSetQueries = []string{
+		pmcParentDataSet,
+		pmcCurrentDataSet,
+		pmcTimeStatus,
+		pmcPortDataSet,
+		pmcTimePropertiesDataSet,
+	}
+	pmcResponseRegex = regexp.MustCompile(`RESPONSE MANAGEMENT (\S+)`)
+	pmcValueRegex    = regexp.MustCompile(`^\s+(\S+)\s+(.*?)\s*$`)
+	// sending: GET CURRENT_DATA_SET
+	// 	507c6f.fffe.30fbe8-0 seq 0 RESPONSE MANAGEMENT CURRENT_DATA_SET
+	// 		stepsRemoved     1
+	// 		offsetFromMaster -2.0
+	// 		meanPathDelay    612.0
+)
+
+// PMCParentDataSet is the response to GET PARENT_DATA_SET
+type PMCParentDataSet struct {
+	Timestamp                             string `json:"timestamp"`
+	ParentPortIdentity                    string `json:"parentPortIdentity"`
+	ObservedParentOffsetScaledLogVariance string `json:"observedParentOffsetScaledLogVariance"`
+	ObservedParentClockPhaseChangeRate    string `json:"observedParentClockPhaseChangeRate"`
+	GMClockAccuracy                       string `json:"gmClockAccuracy"`
+	GMOffsetScaledLogVariance             string `json:"gmOffsetScaledLogVariance"`
+	GrandmasterIdentity                   string `json:"grandmasterIdentity"`
+	ParentStats                           int    `json:"parentStats"`
+	GrandmasterPriority1                  int    `json:"grandmasterPriority1"`
+	GMClockClass                          int    `json:"gmClockClass"`
+	GrandmasterPriority2                  int    `json:"grandmasterPriority2"`
+}
+
+// GetAnalyserFormat returns the json expected by the analysers
+func (parent *PMCParentDataSet) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	return []*callbacks.AnalyserFormatType{{ID: "ptp4l/parent-data-set", Data: parent}}, nil
+}
+
+// PMCCurrentDataSet is the response to GET CURRENT_DATA_SET
+type PMCCurrentDataSet struct {
+	Timestamp        string  `json:"timestamp"`
+	OffsetFromMaster float64 `json:"offsetFromMaster"`
+	MeanPathDelay    float64 `json:"meanPathDelay"`
+	StepsRemoved     int     `json:"stepsRemoved"`
+}
+
+// GetAnalyserFormat returns the json expected by the analysers
+func (current *PMCCurrentDataSet) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	return []*callbacks.AnalyserFormatType{{ID: "ptp4l/current-data-set", Data: current}}, nil
+}
+
+// PMCTimeStatus is the response to GET TIME_STATUS_NP
+type PMCTimeStatus struct {
+	Timestamp                  string  `json:"timestamp"`
+	LastGMPhaseChange          string  `json:"lastGmPhaseChange"`
+	GMIdentity                 string  `json:"gmIdentity"`
+	MasterOffset               int64   `json:"masterOffset"`
+	IngressTime                int64   `json:"ingressTime"`
+	CumulativeScaledRateOffset float64 `json:"cumulativeScaledRateOffset"`
+	ScaledLastGMPhaseChange    int64   `json:"scaledLastGmPhaseChange"`
+	GMTimeBaseIndicator        int     `json:"gmTimeBaseIndicator"`
+	GMPresent                  bool    `json:"gmPresent"`
+}
+
+// GetAnalyserFormat returns the json expected by the analysers
+func (status *PMCTimeStatus) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	return []*callbacks.AnalyserFormatType{{ID: "ptp4l/time-status", Data: status}}, nil
+}
+
+// PMCPortDataSet is the response to GET PORT_DATA_SET for one port
+type PMCPortDataSet struct {
+	Timestamp               string `json:"timestamp"`
+	PortIdentity            string `json:"portIdentity"`
+	PortState               string `json:"portState"`
+	PeerMeanPathDelay       int64  `json:"peerMeanPathDelay"`
+	LogMinDelayReqInterval  int    `json:"logMinDelayReqInterval"`
+	LogAnnounceInterval     int    `json:"logAnnounceInterval"`
+	AnnounceReceiptTimeout  int    `json:"announceReceiptTimeout"`
+	LogSyncInterval         int    `json:"logSyncInterval"`
+	DelayMechanism          int    `json:"delayMechanism"`
+	LogMinPdelayReqInterval int    `json:"logMinPdelayReqInterval"`
+	VersionNumber           int    `json:"versionNumber"`
+}
+
+// GetAnalyserFormat returns the json expected by the analysers
+func (port *PMCPortDataSet) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	return []*callbacks.AnalyserFormatType{{ID: "ptp4l/port-data-set", Data: port}}, nil
+}
+
+// PMCTimePropertiesDataSet is the response to GET TIME_PROPERTIES_DATA_SET
+type PMCTimePropertiesDataSet struct {
+	Timestamp             string `json:"timestamp"`
+	TimeSource            string `json:"timeSource"`
+	CurrentUtcOffset      int    `json:"currentUtcOffset"`
+	Leap61                int    `json:"leap61"`
+	Leap59                int    `json:"leap59"`
+	CurrentUtcOffsetValid int    `json:"currentUtcOffsetValid"`
+	PtpTimescale          int    `json:"ptpTimescale"`
+	TimeTraceable         int    `json:"timeTraceable"`
+	FrequencyTraceable    int    `json:"frequencyTraceable"`
+}
+
+// GetAnalyserFormat returns the json expected by the analysers
+func (properties *PMCTimePropertiesDataSet) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	return []*callbacks.AnalyserFormatType{{ID: "ptp4l/time-properties", Data: properties}}, nil
+}
+
+// parsePMCResponses returns the values of each response to the query found in the output of pmc
+func parsePMCResponses(output, query string) []map[string]string {
+	responses := make([]map[string]string, 0)
+	var current map[string]string
+	for _, line := range strings.Split(output, "\n") {
+		if match := pmcResponseRegex.FindStringSubmatch(line); match != nil {
+			current = nil
+			if match[1] == query {
+				current = make(map[string]string)
+				responses = append(responses, current)
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if match := pmcValueRegex.FindStringSubmatch(line); match != nil {
+			current[match[1]] = match[2]
+		} else {
+			current = nil
+		}
+	}
+	return responses
+}
+
+// pmcValues converts the values of a pmc response, the first failure is kept in err
+type pmcValues struct {
+	err    error
+	values map[string]string
+	query  string
+}
+
+func (response *pmcValues) setErr(key string, err error) {
+	if err != nil && response.err == nil {
+		response.err = fmt.Errorf("failed to parse %s from %s response: %w", key, response.query, err)
+	}
+}
+
+func (response *pmcValues) getString(key string) string {
+	value, ok := response.values[key]
+	if !ok {
+		response.setErr(key, fmt.Errorf("%s is missing", key))
+	}
+	return value
+}
+
+func (response *pmcValues) getInt(key string) int {
+	value, err := strconv.Atoi(response.getString(key))
+	response.setErr(key, err)
+	return value
+}
+
+func (response *pmcValues) getInt64(key string) int64 {
+	value, err := strconv.ParseInt(response.getString(key), 10, 64)
+	response.setErr(key, err)
+	return value
+}
+
+func (response *pmcValues) getFloat(key string) float64 {
+	value, err := strconv.ParseFloat(response.getString(key), 64)
+	response.setErr(key, err)
+	return value
+}
+
+func (response *pmcValues) getBool(key string) bool {
+	value, err := strconv.ParseBool(response.getString(key))
+	response.setErr(key, err)
+	return value
+}
+
+// getPMCResponses returns the responses to the query, pmc must have answered at least once
+func getPMCResponses(output, query string) ([]*pmcValues, error) {
+	responses := parsePMCResponses(output, query)
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("no response to GET %s in pmc output: %s", query, output)
+	}
+	values := make([]*pmcValues, 0, len(responses))
+	for _, response := range responses {
+		values = append(values, &pmcValues{values: response, query: query})
+	}
+	return values, nil
+}
+
+func parseParentDataSet(output, timestamp string) (*PMCParentDataSet, error) {
+	responses, err := getPMCResponses(output, pmcParentDataSet)
+	if err != nil {
+		return nil, err
+	}
+	response := responses[0]
+	parent := &PMCParentDataSet{
+		Timestamp:                             timestamp,
+		ParentPortIdentity:                    response.getString("parentPortIdentity"),
+		ParentStats:                           response.getInt("parentStats"),
+		ObservedParentOffsetScaledLogVariance: response.getString("observedParentOffsetScaledLogVariance"),
+		ObservedParentClockPhaseChangeRate:    response.getString("observedParentClockPhaseChangeRate"),
+		GrandmasterPriority1:                  response.getInt("grandmasterPriority1"),
+		GMClockClass:                          response.getInt("gm.ClockClass"),
+		GMClockAccuracy:                       response.getString("gm.ClockAccuracy"),
+		GMOffsetScaledLogVariance:             response.getString("gm.OffsetScaledLogVariance"),
+		GrandmasterPriority2:                  response.getInt("grandmasterPriority2"),
+		GrandmasterIdentity:                   response.getString("grandmasterIdentity"),
+	}
+	if response.err != nil {
+		return nil, response.err
+	}
+	return parent, nil
+}
+
+func parseCurrentDataSet(output, timestamp string) (*PMCCurrentDataSet, error) {
+	responses, err := getPMCResponses(output, pmcCurrentDataSet)
+	if err != nil {
+		return nil, err
+	}
+	response := responses[0]
+	current := &PMCCurrentDataSet{
+		Timestamp:        timestamp,
+		StepsRemoved:     response.getInt("stepsRemoved"),
+		OffsetFromMaster: response.getFloat("offsetFromMaster"),
+		MeanPathDelay:    response.getFloat("meanPathDelay"),
+	}
+	if response.err != nil {
+		return nil, response.err
+	}
+	return current, nil
+}
+
+func parseTimeStatus(output, timestamp string) (*PMCTimeStatus, error) {
+	responses, err := getPMCResponses(output, pmcTimeStatus)
+	if err != nil {
+		return nil, err
+	}
+	response := responses[0]
+	status := &PMCTimeStatus{
+		Timestamp:                  timestamp,
+		MasterOffset:               response.getInt64("master_offset"),
+		IngressTime:                response.getInt64("ingress_time"),
+		CumulativeScaledRateOffset: response.getFloat("cumulativeScaledRateOffset"),
+		ScaledLastGMPhaseChange:    response.getInt64("scaledLastGmPhaseChange"),
+		GMTimeBaseIndicator:        response.getInt("gmTimeBaseIndicator"),
+		LastGMPhaseChange:          response.getString("lastGmPhaseChange"),
+		GMPresent:                  response.getBool("gmPresent"),
+		GMIdentity:                 response.getString("gmIdentity"),
+	}
+	if response.err != nil {
+		return nil, response.err
+	}
+	return status, nil
+}
+
+func parsePortDataSets(output, timestamp string) ([]PMCPortDataSet, error) {
+	responses, err := getPMCResponses(output, pmcPortDataSet)
+	if err != nil {
+		return nil, err
+	}
+	ports := make([]PMCPortDataSet, 0, len(responses))
+	for _, response := range responses {
+		ports = append(ports, PMCPortDataSet{
+			Timestamp:               timestamp,
+			PortIdentity:            response.getString("portIdentity"),
+			PortState:               response.getString("portState"),
+			LogMinDelayReqInterval:  response.getInt("logMinDelayReqInterval"),
+			PeerMeanPathDelay:       response.getInt64("peerMeanPathDelay"),
+			LogAnnounceInterval:     response.getInt("logAnnounceInterval"),
+			AnnounceReceiptTimeout:  response.getInt("announceReceiptTimeout"),
+			LogSyncInterval:         response.getInt("logSyncInterval"),
+			DelayMechanism:          response.getInt("delayMechanism"),
+			LogMinPdelayReqInterval: response.getInt("logMinPdelayReqInterval"),
+			VersionNumber:           response.getInt("versionNumber"),
+		})
+		if response.err != nil {
+			return nil, response.err
+		}
+	}
+	return ports, nil
+}
+
+func parseTimePropertiesDataSet(output, timestamp string) (*PMCTimePropertiesDataSet, error) {
+	responses, err := getPMCResponses(output, pmcTimePropertiesDataSet)
+	if err != nil {
+		return nil, err
+	}
+	response := responses[0]
+	properties := &PMCTimePropertiesDataSet{
+		Timestamp:             timestamp,
+		CurrentUtcOffset:      response.getInt("currentUtcOffset"),
+		Leap61:                response.getInt("leap61"),
+		Leap59:                response.getInt("leap59"),
+		CurrentUtcOffsetValid: response.getInt("currentUtcOffsetValid"),
+		PtpTimescale:          response.getInt("ptpTimescale"),
+		TimeTraceable:         response.getInt("timeTraceable"),
+		FrequencyTraceable:    response.getInt("frequencyTraceable"),
+		TimeSource:            response.getString("timeSource"),
+	}
+	if response.err != nil {
+		return nil, response.err
+	}
+	return properties, nil
+}
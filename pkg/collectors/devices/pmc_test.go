@@ -4,6 +4,7 @@ package devices_test
 
 import (
 	"bufio"
+	"context"
 	"net/url"
 	"strings"
 
@@ -38,9 +39,12 @@ var _ = Describe("GetPMC", func() {
 	})
 
 	When("called GetPMC", func() {
-		It("should return a valid GMSettings", func() {
+		It("should return the GMSettings and data sets", func() {
 			expectedInput := "echo '<date>';date +%s.%N;echo '</date>';"
 			expectedInput += "echo '<PMC>';pmc -u -f /var/run/ptp4l.0.config  'GET GRANDMASTER_SETTINGS_NP';echo '</PMC>';"
+			expectedInput += "echo '<dataSets>';pmc -u -f /var/run/ptp4l.0.config 'GET PARENT_DATA_SET' " +
+				"'GET CURRENT_DATA_SET' 'GET TIME_STATUS_NP' 'GET PORT_DATA_SET' 'GET TIME_PROPERTIES_DATA_SET';" +
+				"echo '</dataSets>';"
 
 			expectedOutput := strings.Join([]string{
 				"<date>",
@@ -61,14 +65,77 @@ var _ = Describe("GetPMC", func() {
 				"		frequencyTraceable      0",
 				"		timeSource              0xa0",
 				"</PMC>",
+				"<dataSets>",
+				"sending: GET PARENT_DATA_SET",
+				"	507c6f.fffe.30fbe8-0 seq 0 RESPONSE MANAGEMENT PARENT_DATA_SET",
+				"		parentPortIdentity                    507c6f.fffe.1fb1f0-1",
+				"		parentStats                           0",
+				"		observedParentOffsetScaledLogVariance 0xffff",
+				"		observedParentClockPhaseChangeRate    0x7fffffff",
+				"		grandmasterPriority1                  128",
+				"		gm.ClockClass                         6",
+				"		gm.ClockAccuracy                      0x21",
+				"		gm.OffsetScaledLogVariance            0x4e5d",
+				"		grandmasterPriority2                  128",
+				"		grandmasterIdentity                   507c6f.fffe.1fb1f0",
+				"sending: GET CURRENT_DATA_SET",
+				"	507c6f.fffe.30fbe8-0 seq 1 RESPONSE MANAGEMENT CURRENT_DATA_SET",
+				"		stepsRemoved     1",
+				"		offsetFromMaster -2.0",
+				"		meanPathDelay    612.0",
+				"sending: GET TIME_STATUS_NP",
+				"	507c6f.fffe.30fbe8-0 seq 2 RESPONSE MANAGEMENT TIME_STATUS_NP",
+				"		master_offset              -2",
+				"		ingress_time               1686916187058400000",
+				"		cumulativeScaledRateOffset +0.000000000",
+				"		scaledLastGmPhaseChange    0",
+				"		gmTimeBaseIndicator        0",
+				"		lastGmPhaseChange          0x0000'0000000000000000.0000",
+				"		gmPresent                  true",
+				"		gmIdentity                 507c6f.fffe.1fb1f0",
+				"sending: GET PORT_DATA_SET",
+				"	507c6f.fffe.30fbe8-1 seq 3 RESPONSE MANAGEMENT PORT_DATA_SET",
+				"		portIdentity            507c6f.fffe.30fbe8-1",
+				"		portState               SLAVE",
+				"		logMinDelayReqInterval  -4",
+				"		peerMeanPathDelay       0",
+				"		logAnnounceInterval     -3",
+				"		announceReceiptTimeout  3",
+				"		logSyncInterval         -4",
+				"		delayMechanism          1",
+				"		logMinPdelayReqInterval -4",
+				"		versionNumber           2",
+				"	507c6f.fffe.30fbe8-2 seq 3 RESPONSE MANAGEMENT PORT_DATA_SET",
+				"		portIdentity            507c6f.fffe.30fbe8-2",
+				"		portState               MASTER",
+				"		logMinDelayReqInterval  -4",
+				"		peerMeanPathDelay       0",
+				"		logAnnounceInterval     -3",
+				"		announceReceiptTimeout  3",
+				"		logSyncInterval         -4",
+				"		delayMechanism          1",
+				"		logMinPdelayReqInterval -4",
+				"		versionNumber           2",
+				"sending: GET TIME_PROPERTIES_DATA_SET",
+				"	507c6f.fffe.30fbe8-0 seq 4 RESPONSE MANAGEMENT TIME_PROPERTIES_DATA_SET",
+				"		currentUtcOffset      37",
+				"		leap61                0",
+				"		leap59                0",
+				"		currentUtcOffsetValid 1",
+				"		ptpTimescale          1",
+				"		timeTraceable         1",
+				"		frequencyTraceable    1",
+				"		timeSource            0x20",
+				"</dataSets>",
 			}, "\n")
 			response[expectedInput] = []byte(expectedOutput)
 
 			ctx, err := clients.NewContainerContext(clientset, "TestNamespace", "Test", "TestContainer")
 			Expect(err).NotTo(HaveOccurred())
 
-			pmcInfo, err := devices.GetPMC(ctx)
+			dataSets, err := devices.GetPMC(context.Background(), ctx, devices.DefaultPTP4lConfig)
 			Expect(err).NotTo(HaveOccurred())
+			pmcInfo := dataSets.GMSettings
 			Expect(pmcInfo.Timestamp).To(Equal("2023-06-16T11:49:47.0584Z"))
 			Expect(pmcInfo.ClockAccuracy).To(Equal("0xfe"))
 			Expect(pmcInfo.ClockClass).To(Equal(248))
@@ -82,6 +149,79 @@ var _ = Describe("GetPMC", func() {
 			Expect(pmcInfo.FrequencyTraceable).To(Equal(0))
 			Expect(pmcInfo.TimeSource).To(Equal("0xa0"))
 
+			Expect(dataSets.Parent.Timestamp).To(Equal("2023-06-16T11:49:47.0584Z"))
+			Expect(dataSets.Parent.ParentPortIdentity).To(Equal("507c6f.fffe.1fb1f0-1"))
+			Expect(dataSets.Parent.GMClockClass).To(Equal(6))
+			Expect(dataSets.Parent.GMClockAccuracy).To(Equal("0x21"))
+			Expect(dataSets.Parent.GrandmasterPriority1).To(Equal(128))
+			Expect(dataSets.Parent.GrandmasterIdentity).To(Equal("507c6f.fffe.1fb1f0"))
+
+			Expect(dataSets.Current.StepsRemoved).To(Equal(1))
+			Expect(dataSets.Current.OffsetFromMaster).To(Equal(-2.0))
+			Expect(dataSets.Current.MeanPathDelay).To(Equal(612.0))
+
+			Expect(dataSets.TimeStatus.MasterOffset).To(Equal(int64(-2)))
+			Expect(dataSets.TimeStatus.IngressTime).To(Equal(int64(1686916187058400000)))
+			Expect(dataSets.TimeStatus.GMPresent).To(BeTrue())
+			Expect(dataSets.TimeStatus.LastGMPhaseChange).To(Equal("0x0000'0000000000000000.0000"))
+			Expect(dataSets.TimeStatus.GMIdentity).To(Equal("507c6f.fffe.1fb1f0"))
+
+			Expect(dataSets.Ports).To(HaveLen(2))
+			Expect(dataSets.Ports[0].PortIdentity).To(Equal("507c6f.fffe.30fbe8-1"))
+			Expect(dataSets.Ports[0].PortState).To(Equal("SLAVE"))
+			Expect(dataSets.Ports[0].LogSyncInterval).To(Equal(-4))
+			Expect(dataSets.Ports[1].PortState).To(Equal("MASTER"))
+			Expect(dataSets.Ports[1].VersionNumber).To(Equal(2))
+
+			Expect(dataSets.TimeProperties.CurrentUtcOffsetValid).To(Equal(1))
+			Expect(dataSets.TimeProperties.TimeTraceable).To(Equal(1))
+			Expect(dataSets.TimeProperties.TimeSource).To(Equal("0x20"))
+		})
+		It("should still return the GMSettings when the data set queries time out", func() {
+			expectedInput := "echo '<date>';date +%s.%N;echo '</date>';"
+			expectedInput += "echo '<PMC>';pmc -u -f /var/run/ptp4l.0.config  'GET GRANDMASTER_SETTINGS_NP';echo '</PMC>';"
+			expectedInput += "echo '<dataSets>';pmc -u -f /var/run/ptp4l.0.config 'GET PARENT_DATA_SET' " +
+				"'GET CURRENT_DATA_SET' 'GET TIME_STATUS_NP' 'GET PORT_DATA_SET' 'GET TIME_PROPERTIES_DATA_SET';" +
+				"echo '</dataSets>';"
+			response[expectedInput] = []byte(strings.Join([]string{
+				"<date>",
+				"1686916187.0584",
+				"</date>",
+				"<PMC>",
+				"sending: GET GRANDMASTER_SETTINGS_NP",
+				"	507c6f.fffe.30fbe8-0 seq 0 RESPONSE MANAGEMENT GRANDMASTER_SETTINGS_NP",
+				"		clockClass              248",
+				"		clockAccuracy           0xfe",
+				"		offsetScaledLogVariance 0xffff",
+				"		currentUtcOffset        37",
+				"		leap61                  0",
+				"		leap59                  0",
+				"		currentUtcOffsetValid   0",
+				"		ptpTimescale            1",
+				"		timeTraceable           0",
+				"		frequencyTraceable      0",
+				"		timeSource              0xa0",
+				"</PMC>",
+				"<dataSets>",
+				"sending: GET PARENT_DATA_SET",
+				"sending: GET CURRENT_DATA_SET",
+				"</dataSets>",
+			}, "\n"))
+
+			ctx, err := clients.NewContainerContext(clientset, "TestNamespace", "Test", "TestContainer")
+			Expect(err).NotTo(HaveOccurred())
+
+			dataSets, err := devices.GetPMC(context.Background(), ctx, devices.DefaultPTP4lConfig)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dataSets.GMSettings).NotTo(BeNil())
+			Expect(dataSets.GMSettings.ClockClass).To(Equal(248))
+			Expect(dataSets.Parent).To(BeNil())
+			Expect(dataSets.Current).To(BeNil())
+			Expect(dataSets.TimeStatus).To(BeNil())
+			Expect(dataSets.TimeProperties).To(BeNil())
+			Expect(dataSets.Ports).To(BeEmpty())
+			Expect(dataSets.Errors).To(HaveLen(5))
+			Expect(dataSets.Errors[0]).To(MatchError(ContainSubstring("no response to GET PARENT_DATA_SET")))
 		})
 	})
 })
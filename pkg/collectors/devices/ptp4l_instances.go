@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package devices
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/fetcher"
+)
+
+const (
+	// DefaultPTP4lConfig is the config of the ptp4l instance started for the first PtpConfig profile
+	DefaultPTP4lConfig = "/var/run/ptp4l.0.config"
+	// PTP4lConfigGlob matches the config of every ptp4l instance
+	PTP4lConfigGlob   = "/var/run/ptp4l.*.config"
+	ptp4lConfigSuffix = ".config"
+)
+
+// nonPortSections are the sections of a ptp4l config which do not configure an interface
+var nonPortSections = map[string]bool{
+	"global":               true,
+	"unicast_master_table": true,
+}
+
+// PTP4lInstance is a ptp4l process started by the linuxptp-daemon for one of the PtpConfig profiles
+type PTP4lInstance struct {
+	Name       string
+	ConfigFile string
+	Interfaces []string
+}
+
+func buildPTP4lInstancesFetcher() (*fetcher.Fetcher, error) {
+	fetcherInst, err := fetcher.FetcherFactory(
+		[]*clients.Cmd{},
+		[]fetcher.AddCommandArgs{
+			{
+				// Prints the path of each config followed by its section headers, for example
+				// /var/run/ptp4l.0.config
+				// [global]
+				// [ens7f0]
+				Key: "configs",
+				Command: fmt.Sprintf(
+					`for config in %s; do [ -f "$config" ] || continue; echo "$config"; grep '^\[' "$config"; done`,
+					PTP4lConfigGlob,
+				),
+				Trim: true,
+			},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build fetcher to find the ptp4l instances %w", err)
+	}
+	return fetcherInst, nil
+}
+
+// GetPTP4lInstancesCommand returns the script which is run to find the ptp4l instances
+func GetPTP4lInstancesCommand() (string, error) {
+	fetcherInst, err := buildPTP4lInstancesFetcher()
+	if err != nil {
+		return "", err
+	}
+	return fetcherInst.GetCommand(), nil
+}
+
+// ParsePTP4lInstances returns the instances described by the output of the script from GetPTP4lInstancesCommand
+func ParsePTP4lInstances(output string) []PTP4lInstance {
+	instances := make([]PTP4lInstance, 0)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "/"):
+			instances = append(instances, PTP4lInstance{
+				Name:       strings.TrimSuffix(path.Base(line), ptp4lConfigSuffix),
+				ConfigFile: line,
+				Interfaces: make([]string, 0),
+			})
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") && len(instances) > 0:
+			section := strings.TrimSpace(line[1 : len(line)-1])
+			if section != "" && !nonPortSections[section] {
+				current := &instances[len(instances)-1]
+				current.Interfaces = append(current.Interfaces, section)
+			}
+		}
+	}
+	return instances
+}
+
+// GetPTP4lInstances returns each ptp4l instance which has a config in the container
+// along with the interfaces it controls
+func GetPTP4lInstances(ctx context.Context, execCtx clients.ExecContext) ([]PTP4lInstance, error) {
+	fetcherInst, err := buildPTP4lInstancesFetcher()
+	if err != nil {
+		return nil, err
+	}
+	type Configs struct {
+		Configs string `fetcherKey:"configs"`
+	}
+	configs := Configs{}
+	err = fetcherInst.Fetch(ctx, execCtx, &configs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the ptp4l instances %w", err)
+	}
+	return ParsePTP4lInstances(configs.Configs), nil
+}
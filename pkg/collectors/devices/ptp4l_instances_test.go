@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package devices_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+)
+
+var _ = Describe("ParsePTP4lInstances", func() {
+	It("should return each config with the interfaces it controls", func() {
+		output := strings.Join([]string{
+			"/var/run/ptp4l.0.config",
+			"[global]",
+			"[ens7f0]",
+			"[ens7f1]",
+			"/var/run/ptp4l.1.config",
+			"[global]",
+			"[unicast_master_table]",
+			"[ens5f0]",
+		}, "\n")
+		Expect(devices.ParsePTP4lInstances(output)).To(Equal([]devices.PTP4lInstance{
+			{Name: "ptp4l.0", ConfigFile: "/var/run/ptp4l.0.config", Interfaces: []string{"ens7f0", "ens7f1"}},
+			{Name: "ptp4l.1", ConfigFile: "/var/run/ptp4l.1.config", Interfaces: []string{"ens5f0"}},
+		}))
+	})
+	It("should return an instance without interfaces if its config only has global settings", func() {
+		Expect(devices.ParsePTP4lInstances("/var/run/ptp4l.2.config\n[global]")).To(Equal([]devices.PTP4lInstance{
+			{Name: "ptp4l.2", ConfigFile: "/var/run/ptp4l.2.config", Interfaces: []string{}},
+		}))
+	})
+	It("should return no instances if there are no configs", func() {
+		Expect(devices.ParsePTP4lInstances("")).To(BeEmpty())
+	})
+})
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package devices
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/loglines"
+)
+
+// Ts2phcOffset is an offset of a PHC from its 1PPS source reported by ts2phc
+type Ts2phcOffset struct {
+	Timestamp  string `json:"timestamp"`
+	Config     string `json:"config,omitempty"`
+	Interface  string `json:"interface"`
+	ServoState string `json:"state"`
+	Offset     int64  `json:"terror"`
+	Frequency  int64  `json:"freq"`
+}
+
+// GetAnalyserFormat returns the json expected by the analysers
+func (offset *Ts2phcOffset) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	formatted := callbacks.AnalyserFormatType{
+		ID: "ts2phc/time-error",
+		Data: map[string]any{
+			"timestamp": offset.Timestamp,
+			"interface": offset.Interface,
+			"terror":    offset.Offset,
+			"freq":      offset.Frequency,
+			"state":     offset.ServoState,
+		},
+	}
+	return []*callbacks.AnalyserFormatType{&formatted}, nil
+}
+
+// ParseTs2phcOffset extracts the offset from a ts2phc log line, the second value
+// is false if the line does not report an offset.
+func ParseTs2phcOffset(timestamp time.Time, line string) (Ts2phcOffset, bool, error) {
+	offset, found, err := loglines.ParseOffset(timestamp, line)
+	if !found || offset.Process != "ts2phc" {
+		return Ts2phcOffset{}, false, nil
+	}
+	if err != nil {
+		return Ts2phcOffset{}, true, fmt.Errorf("failed to parse ts2phc offset: %w", err)
+	}
+	return Ts2phcOffset{
+		Timestamp:  offset.Timestamp,
+		Config:     offset.Config,
+		Interface:  offset.Clock,
+		ServoState: offset.ServoState,
+		Offset:     offset.Offset,
+		Frequency:  offset.Frequency,
+	}, true, nil
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package devices_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+)
+
+var _ = Describe("ParseTs2phcOffset", func() {
+	timestamp := time.Date(2023, 6, 16, 11, 49, 47, 58400000, time.UTC)
+
+	DescribeTable("should parse the offset lines of each ts2phc version",
+		func(line string, expected devices.Ts2phcOffset) {
+			offset, found, err := devices.ParseTs2phcOffset(timestamp, line)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			expected.Timestamp = "2023-06-16T11:49:47.0584Z"
+			Expect(offset).To(Equal(expected))
+		},
+		Entry("with master in the line",
+			"ts2phc[1896327.319]: [ts2phc.0.config] ens2f0 master offset         -1 s2 freq      -2",
+			devices.Ts2phcOffset{Config: "ts2phc.0.config", Interface: "ens2f0", ServoState: "s2", Offset: -1, Frequency: -2},
+		),
+		Entry("with the log level in the tag",
+			"ts2phc[1896327.319]: [ts2phc.0.config:6] ens7f0 offset          3 s0 freq     +10",
+			devices.Ts2phcOffset{Config: "ts2phc.0.config", Interface: "ens7f0", ServoState: "s0", Offset: 3, Frequency: 10},
+		),
+		Entry("without a tag",
+			"ts2phc[441.123]: ens5f0 master offset          0 s2 freq      -0",
+			devices.Ts2phcOffset{Interface: "ens5f0", ServoState: "s2"},
+		),
+	)
+
+	It("should skip lines which do not report an offset", func() {
+		for _, line := range []string{
+			"ts2phc[1896327.319]: [ts2phc.0.config] nmea sentence: GNRMC,114947.00,A,4233.01530,N,07112.87856,W",
+			"phc2sys[1896327.319]: [ptp4l.0.config] CLOCK_REALTIME phc offset        -6 s2 freq  -30209 delay    511",
+			"ptp4l[1896327.319]: [ptp4l.0.config] port 1: MASTER to PASSIVE on RS_PASSIVE",
+		} {
+			_, found, err := devices.ParseTs2phcOffset(timestamp, line)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse(), line)
+		}
+	})
+
+	It("should format the offset for the analysers", func() {
+		offset, _, err := devices.ParseTs2phcOffset(
+			timestamp,
+			"ts2phc[1896327.319]: [ts2phc.0.config] ens2f0 master offset         -1 s2 freq      -2",
+		)
+		Expect(err).NotTo(HaveOccurred())
+		formatted, err := offset.GetAnalyserFormat()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(formatted).To(HaveLen(1))
+		Expect(formatted[0].ID).To(Equal("ts2phc/time-error"))
+		Expect(formatted[0].Data).To(Equal(map[string]any{
+			"timestamp": "2023-06-16T11:49:47.0584Z",
+			"interface": "ens2f0",
+			"terror":    int64(-1),
+			"freq":      int64(-2),
+			"state":     "s2",
+		}))
+	})
+})
@@ -3,25 +3,37 @@
 package collectors
 
 import (
+	"context"
 	"fmt"
 
 	log "github.com/sirupsen/logrus"
 
-	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
 )
 
 const (
 	DPLLCollectorName = "DPLL"
+
+	// dpllSourceOption selects how the DPLL state is read rather than probing for the filesystem
+	dpllSourceOption = "source"
+	dpllSourceFS     = "filesystem"
+	dpllSourceNL     = "netlink"
 )
 
 // Returns a new DPLLCollector from the CollectionConstuctor Factory
 func NewDPLLCollector(constructor *CollectionConstructor) (Collector, error) {
-	ctx, err := contexts.GetPTPDaemonContext(constructor.Clientset)
+	switch constructor.Options[dpllSourceOption] {
+	case dpllSourceFS:
+		return NewDPLLFilesystemCollector(constructor)
+	case dpllSourceNL:
+		return NewDPLLNetlinkCollector(constructor)
+	}
+	// The collector which is returned sets up its own context
+	ctx, err := constructor.getPTPDaemonProbeContext()
 	if err != nil {
 		return &DPLLNetlinkCollector{}, fmt.Errorf("failed to create DPLLCollector: %w", err)
 	}
-	dpllFSExists, err := devices.IsDPLLFileSystemPresent(ctx, constructor.PTPInterface)
+	dpllFSExists, err := devices.IsDPLLFileSystemPresent(context.Background(), ctx, constructor.PTPInterface)
 	log.Debug("DPLL FS exists: ", dpllFSExists)
 	if dpllFSExists && err == nil {
 		return NewDPLLFilesystemCollector(constructor)
@@ -30,6 +42,50 @@ func NewDPLLCollector(constructor *CollectionConstructor) (Collector, error) {
 	}
 }
 
+// dpllStateField is the state of a DPLL as reported by the driver
+func dpllStateField(name, dpll string) FieldSchema {
+	return FieldSchema{Name: name, Type: "string", Description: fmt.Sprintf("state of the %s DPLL", dpll)}
+}
+
+var dpllCollectorInfo = CollectorInfo{
+	Description: "Polls the state of the EEC and PPS DPLLs of the interface's NIC. The filesystem is read " +
+		"when the driver exposes it, which also provides the phase offset, otherwise the netlink interface is used.",
+	Requires: []string{
+		"linuxptp-daemon-container: cat and ls of /sys/class/net/<interface>/device/dpll_*",
+		"netlink debug pod: ynl cli.py and lspci (only when the DPLL filesystem is not present)",
+	},
+	Options: []OptionSchema{
+		{
+			Name:        dpllSourceOption,
+			Description: "read the DPLL state from the filesystem or the netlink interface instead of probing",
+			Values:      []string{dpllSourceFS, dpllSourceNL},
+		},
+	},
+	Outputs: []OutputSchema{
+		{
+			AnalyserID:  "dpll/time-error",
+			Tag:         DPLLInfo,
+			Description: "Written when the DPLL filesystem is present",
+			Fields: []FieldSchema{
+				timestampField,
+				dpllStateField("eecstate", "EEC"),
+				dpllStateField("state", "PPS"),
+				{Name: "terror", Type: "float", Description: "phase offset of the PPS DPLL in ns"},
+			},
+		},
+		{
+			AnalyserID:  "dpll/states",
+			Tag:         DPLLNetlinkInfo,
+			Description: "Written when the DPLL filesystem is not present, has no phase offset",
+			Fields: []FieldSchema{
+				timestampField,
+				dpllStateField("eecstate", "EEC"),
+				dpllStateField("state", "PPS"),
+			},
+		},
+	},
+}
+
 func init() {
-	RegisterCollector(DPLLCollectorName, NewDPLLCollector, optional)
+	RegisterCollector(DPLLCollectorName, NewDPLLCollector, planDPLL, dpllCollectorInfo, optional, perInterface)
 }
@@ -3,10 +3,11 @@
 package collectors
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
-	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
 )
@@ -29,13 +30,13 @@ func (dpll *DPLLFilesystemCollector) Start() error {
 }
 
 // polls for the dpll info then passes it to the callback
-func (dpll *DPLLFilesystemCollector) poll() error {
-	dpllInfo, err := devices.GetDevDPLLFilesystemInfo(dpll.ctx, dpll.interfaceName)
+func (dpll *DPLLFilesystemCollector) poll(ctx context.Context) error {
+	dpllInfo, err := devices.GetDevDPLLFilesystemInfo(ctx, dpll.ctx, dpll.interfaceName)
 
 	if err != nil {
 		return fmt.Errorf("failed to fetch %s %w", DPLLInfo, err)
 	}
-	err = dpll.callback.Call(&dpllInfo, DPLLInfo)
+	err = callbacks.CallWithContext(ctx, dpll.callback, &dpllInfo, DPLLInfo)
 	if err != nil {
 		return fmt.Errorf("callback failed %w", err)
 	}
@@ -44,12 +45,12 @@ func (dpll *DPLLFilesystemCollector) poll() error {
 
 // Poll collects information from the cluster then
 // calls the callback.Call to allow that to persist it
-func (dpll *DPLLFilesystemCollector) Poll(resultsChan chan PollResult, wg *utils.WaitGroupCount) {
+func (dpll *DPLLFilesystemCollector) Poll(ctx context.Context, resultsChan chan PollResult, wg *utils.WaitGroupCount) {
 	defer func() {
 		wg.Done()
 	}()
 	errorsToReturn := make([]error, 0)
-	err := dpll.poll()
+	err := dpll.poll(ctx)
 	if err != nil {
 		errorsToReturn = append(errorsToReturn, err)
 	}
@@ -67,7 +68,7 @@ func (dpll *DPLLFilesystemCollector) CleanUp() error {
 
 // Returns a new DPLLFilesystemCollector from the CollectionConstuctor Factory
 func NewDPLLFilesystemCollector(constructor *CollectionConstructor) (Collector, error) {
-	ctx, err := contexts.GetPTPDaemonContext(constructor.Clientset)
+	ctx, err := constructor.getPTPDaemonContext()
 	if err != nil {
 		return &DPLLFilesystemCollector{}, fmt.Errorf("failed to create DPLLFilesystemCollector: %w", err)
 	}
@@ -3,11 +3,13 @@
 package collectors
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
@@ -17,6 +19,7 @@ import (
 type DPLLNetlinkCollector struct {
 	*baseCollector
 	ctx           *clients.ContainerCreationExecContext
+	execCtx       clients.ExecContext
 	clockID       *big.Int
 	interfaceName string
 }
@@ -29,13 +32,15 @@ const (
 // Start sets up the collector so it is ready to be polled
 func (dpll *DPLLNetlinkCollector) Start() error {
 	dpll.running = true
-	err := dpll.ctx.CreatePodAndWait()
-	if err != nil {
-		return fmt.Errorf("dpll netlink collector failed to start pod: %w", err)
+	if dpll.ctx != nil {
+		err := dpll.ctx.CreatePodAndWait()
+		if err != nil {
+			return fmt.Errorf("dpll netlink collector failed to start pod: %w", err)
+		}
 	}
 	log.Debug("dpll.interfaceName: ", dpll.interfaceName)
 	log.Debug("dpll.ctx: ", dpll.ctx)
-	clockIDStuct, err := devices.GetClockID(dpll.ctx, dpll.interfaceName)
+	clockIDStuct, err := devices.GetClockID(context.Background(), dpll.execCtx, dpll.interfaceName)
 	if err != nil {
 		return fmt.Errorf("dpll netlink collector failed to find clock id: %w", err)
 	}
@@ -49,13 +54,13 @@ func (dpll *DPLLNetlinkCollector) Start() error {
 }
 
 // polls for the dpll info then passes it to the callback
-func (dpll *DPLLNetlinkCollector) poll() error {
-	dpllInfo, err := devices.GetDevDPLLNetlinkInfo(dpll.ctx, dpll.clockID)
+func (dpll *DPLLNetlinkCollector) poll(ctx context.Context) error {
+	dpllInfo, err := devices.GetDevDPLLNetlinkInfo(ctx, dpll.execCtx, dpll.clockID)
 
 	if err != nil {
 		return fmt.Errorf("failed to fetch %s %w", DPLLNetlinkInfo, err)
 	}
-	err = dpll.callback.Call(&dpllInfo, DPLLNetlinkInfo)
+	err = callbacks.CallWithContext(ctx, dpll.callback, &dpllInfo, DPLLNetlinkInfo)
 	if err != nil {
 		return fmt.Errorf("callback failed %w", err)
 	}
@@ -64,12 +69,12 @@ func (dpll *DPLLNetlinkCollector) poll() error {
 
 // Poll collects information from the cluster then
 // calls the callback.Call to allow that to persist it
-func (dpll *DPLLNetlinkCollector) Poll(resultsChan chan PollResult, wg *utils.WaitGroupCount) {
+func (dpll *DPLLNetlinkCollector) Poll(ctx context.Context, resultsChan chan PollResult, wg *utils.WaitGroupCount) {
 	defer func() {
 		wg.Done()
 	}()
 	errorsToReturn := make([]error, 0)
-	err := dpll.poll()
+	err := dpll.poll(ctx)
 	if err != nil {
 		errorsToReturn = append(errorsToReturn, err)
 	}
@@ -82,6 +87,9 @@ func (dpll *DPLLNetlinkCollector) Poll(resultsChan chan PollResult, wg *utils.Wa
 // CleanUp stops a running collector
 func (dpll *DPLLNetlinkCollector) CleanUp() error {
 	dpll.running = false
+	if dpll.ctx == nil {
+		return nil
+	}
 	err := dpll.ctx.DeletePodAndWait()
 	if err != nil {
 		return fmt.Errorf("dpll netlink collector failed to clean up: %w", err)
@@ -91,11 +99,6 @@ func (dpll *DPLLNetlinkCollector) CleanUp() error {
 
 // Returns a new DPLLNetlinkCollector from the CollectionConstuctor Factory
 func NewDPLLNetlinkCollector(constructor *CollectionConstructor) (Collector, error) {
-	ctx, err := contexts.GetNetlinkContext(constructor.Clientset)
-	if err != nil {
-		return &DPLLNetlinkCollector{}, fmt.Errorf("failed to create DPLLNetlinkCollector: %w", err)
-	}
-
 	collector := DPLLNetlinkCollector{
 		baseCollector: newBaseCollector(
 			constructor.PollInterval,
@@ -103,8 +106,21 @@ func NewDPLLNetlinkCollector(constructor *CollectionConstructor) (Collector, err
 			constructor.Callback,
 		),
 		interfaceName: constructor.PTPInterface,
-		ctx:           ctx,
 	}
 
+	// When running directly on the host the netlink tools are run
+	// there rather than in a pod created for the purpose
+	if constructor.HostExecContext != nil {
+		collector.execCtx = constructor.wrapInterfaceExecContext(constructor.HostExecContext)
+		return &collector, nil
+	}
+
+	ctx, err := contexts.GetNetlinkContext(constructor.Clientset, constructor.NodeName, constructor.PTPInterface)
+	if err != nil {
+		return &DPLLNetlinkCollector{}, fmt.Errorf("failed to create DPLLNetlinkCollector: %w", err)
+	}
+	collector.ctx = ctx
+	collector.execCtx = constructor.wrapInterfaceExecContext(ctx)
+
 	return &collector, nil
 }
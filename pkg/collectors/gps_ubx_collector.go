@@ -3,10 +3,11 @@
 package collectors //nolint:dupl // new collector
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
-	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
 )
@@ -22,12 +23,12 @@ type GPSCollector struct {
 	interfaceName string
 }
 
-func (gps *GPSCollector) poll() error {
-	gpsNav, err := devices.GetGPSNav(gps.ctx)
+func (gps *GPSCollector) poll(ctx context.Context) error {
+	gpsNav, err := devices.GetGPSNav(ctx, gps.ctx)
 	if err != nil {
 		return fmt.Errorf("failed to fetch  %s %w", gpsNavKey, err)
 	}
-	err = gps.callback.Call(&gpsNav, gpsNavKey)
+	err = callbacks.CallWithContext(ctx, gps.callback, &gpsNav, gpsNavKey)
 	if err != nil {
 		return fmt.Errorf("callback failed %w", err)
 	}
@@ -36,13 +37,13 @@ func (gps *GPSCollector) poll() error {
 
 // Poll collects information from the cluster then
 // calls the callback.Call to allow that to persist it
-func (gps *GPSCollector) Poll(resultsChan chan PollResult, wg *utils.WaitGroupCount) {
+func (gps *GPSCollector) Poll(ctx context.Context, resultsChan chan PollResult, wg *utils.WaitGroupCount) {
 	defer func() {
 		wg.Done()
 	}()
 
 	errorsToReturn := make([]error, 0)
-	err := gps.poll()
+	err := gps.poll(ctx)
 	if err != nil {
 		errorsToReturn = append(errorsToReturn, err)
 	}
@@ -54,7 +55,7 @@ func (gps *GPSCollector) Poll(resultsChan chan PollResult, wg *utils.WaitGroupCo
 
 // Returns a new GPSCollector based on values in the CollectionConstructor
 func NewGPSCollector(constructor *CollectionConstructor) (Collector, error) {
-	ctx, err := contexts.GetPTPDaemonContext(constructor.Clientset)
+	ctx, err := constructor.getPTPDaemonContext()
 	if err != nil {
 		return &GPSCollector{}, fmt.Errorf("failed to create DPLLCollector: %w", err)
 	}
@@ -72,6 +73,36 @@ func NewGPSCollector(constructor *CollectionConstructor) (Collector, error) {
 	return &collector, nil
 }
 
+var gpsCollectorInfo = CollectorInfo{
+	Description: "Polls the navigation status, clock accuracy and antenna status of the GNSS receiver",
+	Requires:    []string{"linuxptp-daemon-container: ubxtool"},
+	Outputs: []OutputSchema{
+		{
+			AnalyserID:  "gnss/time-error",
+			Tag:         gpsNavKey,
+			Description: "One record per poll from UBX-NAV-CLOCK and UBX-NAV-STATUS",
+			Fields: []FieldSchema{
+				timestampField,
+				{Name: "terror", Type: "int", Description: "time accuracy estimate in ns (tAcc)"},
+				{Name: "ferror", Type: "int", Description: "frequency accuracy estimate in ps/s (fAcc)"},
+				{Name: "state", Type: "int", Description: "GNSS fix type (gpsFix)"},
+				{Name: "flags", Type: "string", Description: "navigation status flags"},
+			},
+		},
+		{
+			AnalyserID:  "gnss/rf-mon",
+			Tag:         gpsNavKey,
+			Description: "One record per antenna block per poll from UBX-MON-RF",
+			Fields: []FieldSchema{
+				timestampField,
+				{Name: "blockId", Type: "int", Description: "RF block the antenna is connected to"},
+				{Name: "status", Type: "int", Description: "antenna status (antStatus)"},
+				{Name: "power", Type: "int", Description: "antenna power status (antPower)"},
+			},
+		},
+	},
+}
+
 func init() {
-	RegisterCollector(GPSCollectorName, NewGPSCollector, optional)
+	RegisterCollector(GPSCollectorName, NewGPSCollector, planGPS, gpsCollectorInfo, optional, perNode)
 }
@@ -62,6 +62,7 @@ type LogsCollector struct {
 	slices             chan *loglines.LineSlice
 	client             *clients.Clientset
 	sliceQuit          chan os.Signal
+	nodeName           string
 	logsOutputFileName string
 	lastPoll           loglines.GenerationalLockedTime
 	wg                 sync.WaitGroup
@@ -80,6 +81,7 @@ func (logs *LogsCollector) SetLastPoll(pollTime time.Time) {
 
 // Start sets up the collector so it is ready to be polled
 func (logs *LogsCollector) Start() error {
+	logs.wg.Add(2) //nolint:gomnd // one for each of the goroutines below
 	go logs.processSlices()
 	go logs.writeToLogFile()
 	logs.generations.Dumper.Start()
@@ -101,7 +103,6 @@ func (logs *LogsCollector) writeLine(line *loglines.ProcessedLine, writer io.Str
 
 //nolint:cyclop // allow this to be a little complicated
 func (logs *LogsCollector) processSlices() {
-	logs.wg.Add(1)
 	defer logs.wg.Done()
 	for {
 		select {
@@ -121,20 +122,33 @@ func (logs *LogsCollector) processSlices() {
 			return
 		case lineSlice := <-logs.slices:
 			logs.generations.Add(lineSlice)
-		default:
+			// Whether to flush only changes when a slice is added so there is no need to check elsewhere
 			if logs.generations.ShouldFlush() {
 				deduplicated := logs.generations.Flush()
 				for _, line := range deduplicated.Lines {
 					logs.lines <- line
 				}
 			}
-			time.Sleep(time.Nanosecond)
 		}
 	}
 }
 
+// emitRecord passes any offset, port state change or fault reported by the line to the callback
+func (logs *LogsCollector) emitRecord(line *loglines.ProcessedLine) {
+	record, tag, err := loglines.ParseLine(line)
+	if err != nil {
+		log.Warning("failed to parse line: ", err)
+		return
+	}
+	if record == nil {
+		return
+	}
+	if err = logs.callback.Call(record, tag); err != nil {
+		log.Errorf("callback failed for %s %s", tag, err.Error())
+	}
+}
+
 func (logs *LogsCollector) writeToLogFile() {
-	logs.wg.Add(1)
 	defer logs.wg.Done()
 
 	fileHandle, err := os.OpenFile(logs.logsOutputFileName, os.O_CREATE|os.O_WRONLY, logFilePermissions)
@@ -147,12 +161,12 @@ func (logs *LogsCollector) writeToLogFile() {
 			for len(logs.lines) > 0 {
 				line := <-logs.lines
 				logs.writeLine(line, fileHandle)
+				logs.emitRecord(line)
 			}
 			return
 		case line := <-logs.lines:
 			logs.writeLine(line, fileHandle)
-		default:
-			time.Sleep(time.Nanosecond)
+			logs.emitRecord(line)
 		}
 	}
 }
@@ -199,8 +213,8 @@ func processStream(stream io.ReadCloser, expectedEndtime time.Time) ([]*loglines
 	return segment, nil
 }
 
-func (logs *LogsCollector) poll() error {
-	podName, err := logs.client.FindPodNameFromPrefix(contexts.PTPNamespace, contexts.PTPPodNamePrefix)
+func (logs *LogsCollector) poll(ctx context.Context) error {
+	podName, err := contexts.GetPTPDaemonPodName(logs.client, logs.nodeName)
 	if err != nil {
 		return fmt.Errorf("failed to poll: %w", err)
 	}
@@ -215,7 +229,7 @@ func (logs *LogsCollector) poll() error {
 		Pods(contexts.PTPNamespace).
 		GetLogs(podName, &podLogOptions).
 		Timeout(followTimeout)
-	stream, err := podLogRequest.Stream(context.TODO())
+	stream, err := podLogRequest.Stream(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to poll when r: %w", err)
 	}
@@ -236,12 +250,12 @@ func (logs *LogsCollector) poll() error {
 }
 
 // Poll collects log lines
-func (logs *LogsCollector) Poll(resultsChan chan PollResult, wg *utils.WaitGroupCount) {
+func (logs *LogsCollector) Poll(ctx context.Context, resultsChan chan PollResult, wg *utils.WaitGroupCount) {
 	defer func() {
 		wg.Done()
 	}()
 	errorsToReturn := make([]error, 0)
-	err := logs.poll()
+	err := logs.poll(ctx)
 	if err != nil {
 		errorsToReturn = append(errorsToReturn, err)
 	}
@@ -270,6 +284,7 @@ func NewLogsCollector(constructor *CollectionConstructor) (Collector, error) {
 			constructor.Callback,
 		),
 		client:             constructor.Clientset,
+		nodeName:           constructor.NodeName,
 		sliceQuit:          make(chan os.Signal),
 		writeQuit:          make(chan os.Signal),
 		pruned:             true,
@@ -286,7 +301,58 @@ func NewLogsCollector(constructor *CollectionConstructor) (Collector, error) {
 	return &collector, nil
 }
 
+// linuxptpLineFields are the fields common to the records parsed from the lines of each linuxptp process
+var linuxptpLineFields = []FieldSchema{
+	{Name: "timestamp", Type: "string", Description: "RFC3339 time the line was logged"},
+	{Name: "process", Type: "string", Description: "ptp4l, phc2sys or ts2phc"},
+	{Name: "config", Type: "string", Description: "config file of the process the daemon tags the line with"},
+}
+
+var logsCollectorInfo = CollectorInfo{
+	Description: "Follows the logs of the linuxptp-daemon container and writes them to the logs output file. " +
+		"Offsets, port state changes and faults logged by ptp4l, phc2sys and ts2phc are also written as records.",
+	Requires: []string{"Kubernetes API: read access to the logs of the linuxptp-daemon pod"},
+	Outputs: []OutputSchema{
+		{
+			AnalyserID:  "phc/offset",
+			Tag:         loglines.OffsetTag,
+			Description: "One record per offset logged by ptp4l, phc2sys or ts2phc, ts2phc offsets are also written by Ts2phc",
+			Fields: append(append([]FieldSchema{}, linuxptpLineFields...),
+				FieldSchema{Name: "clock", Type: "string", Description: "clock being steered, empty for ptp4l"},
+				FieldSchema{Name: "source", Type: "string", Description: "master for ptp4l, phc or sys for phc2sys"},
+				FieldSchema{Name: "terror", Type: "int", Description: "offset of the clock from its source in ns"},
+				FieldSchema{Name: "freq", Type: "int", Description: "frequency adjustment of the clock in ppb"},
+				FieldSchema{Name: "state", Type: "string", Description: "servo state: s0 unlocked, s1 jump, s2 locked"},
+				FieldSchema{Name: "delay", Type: "int", Description: "path delay in ns, when it is logged"},
+			),
+		},
+		{
+			AnalyserID:  "ptp4l/port-state",
+			Tag:         loglines.PortStateTag,
+			Description: "One record per port state change logged by ptp4l",
+			Fields: []FieldSchema{
+				linuxptpLineFields[0],
+				linuxptpLineFields[2],
+				{Name: "port", Type: "int", Description: "port number"},
+				{Name: "interface", Type: "string", Description: "interface of the port, when it is logged"},
+				{Name: "from", Type: "string", Description: "previous state of the port"},
+				{Name: "to", Type: "string", Description: "new state of the port"},
+				{Name: "event", Type: "string", Description: "event which caused the change"},
+			},
+		},
+		{
+			AnalyserID:  "ptp/fault",
+			Tag:         loglines.FaultTag,
+			Description: "One record per fault logged by ptp4l, phc2sys or ts2phc",
+			Fields: append(append([]FieldSchema{}, linuxptpLineFields...),
+				FieldSchema{Name: "fault", Type: "string", Description: "kind of fault such as tx-timestamp-timeout"},
+				FieldSchema{Name: "message", Type: "string", Description: "the logged message"},
+			),
+		},
+	},
+}
+
 func init() {
 	// Make log opt in as in may lose some data.
-	RegisterCollector(LogsCollectorName, NewLogsCollector, optional)
+	RegisterCollector(LogsCollectorName, NewLogsCollector, planLogs, logsCollectorInfo, optional, perNode)
 }
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package collectors
+
+import (
+	"fmt"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+)
+
+// PlannedCommand describes a script which a collector sends to a container
+// on the node. An empty Script means the collector does not run a command
+// in the container, the Description explains what it does instead.
+type PlannedCommand struct {
+	Description string
+	Namespace   string
+	Pod         string
+	Container   string
+	Script      string
+}
+
+type collectorPlanFunc func(ptpInterface string) ([]PlannedCommand, error)
+
+// ptpDaemonCommand returns a PlannedCommand which runs in the linuxptp-daemon container
+func ptpDaemonCommand(description, script string) PlannedCommand {
+	return PlannedCommand{
+		Description: description,
+		Namespace:   contexts.PTPNamespace,
+		Pod:         fmt.Sprintf("%s* (%s)", contexts.PTPPodNamePrefix, contexts.PTPPodLabelSelector),
+		Container:   contexts.PTPContainer,
+		Script:      script,
+	}
+}
+
+// netlinkCommand returns a PlannedCommand which runs in the netlink debug pod created for ptpInterface
+func netlinkCommand(description, ptpInterface, script string) PlannedCommand {
+	return PlannedCommand{
+		Description: description,
+		Namespace:   contexts.PTPNamespace,
+		Pod:         contexts.GetNetlinkPodName(ptpInterface),
+		Container:   contexts.NetlinkDebugContainer,
+		Script:      script,
+	}
+}
+
+func planDevInfo(ptpInterface string) ([]PlannedCommand, error) {
+	script, err := devices.GetPTPDeviceInfoCommand(ptpInterface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan %s: %w", DevInfoCollectorName, err)
+	}
+	return []PlannedCommand{ptpDaemonCommand("fetch the device info", script)}, nil
+}
+
+func planDPLL(ptpInterface string) ([]PlannedCommand, error) {
+	presentScript, err := devices.GetDPLLFileSystemPresentCommand(ptpInterface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan %s: %w", DPLLCollectorName, err)
+	}
+	fsScript, err := devices.GetDevDPLLFilesystemCommand(ptpInterface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan %s: %w", DPLLCollectorName, err)
+	}
+	clockIDScript, err := devices.GetClockIDCommand(ptpInterface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan %s: %w", DPLLCollectorName, err)
+	}
+	netlinkScript, err := devices.GetDevDPLLNetlinkCommand()
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan %s: %w", DPLLCollectorName, err)
+	}
+	return []PlannedCommand{
+		ptpDaemonCommand("check if the DPLL filesystem is present (once at start up)", presentScript),
+		ptpDaemonCommand("fetch the DPLL info if the DPLL filesystem is present", fsScript),
+		netlinkCommand(
+			fmt.Sprintf(
+				"find the clock ID if the DPLL filesystem is not present, the pod is created from %s",
+				contexts.NetlinkDebugContainerImage,
+			),
+			ptpInterface,
+			clockIDScript,
+		),
+		netlinkCommand("fetch the DPLL info if the DPLL filesystem is not present", ptpInterface, netlinkScript),
+	}, nil
+}
+
+func planGPS(string) ([]PlannedCommand, error) {
+	return []PlannedCommand{ptpDaemonCommand("fetch the GNSS status", devices.GetGPSNavCommand())}, nil
+}
+
+func planPMC(string) ([]PlannedCommand, error) {
+	instancesScript, err := devices.GetPTP4lInstancesCommand()
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan %s: %w", PMCCollectorName, err)
+	}
+	pmcScript, err := devices.GetPMCCommand(devices.DefaultPTP4lConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan %s: %w", PMCCollectorName, err)
+	}
+	return []PlannedCommand{
+		ptpDaemonCommand("find the ptp4l instances and the interfaces they control (once at start up)", instancesScript),
+		ptpDaemonCommand(
+			fmt.Sprintf(
+				"fetch the grandmaster settings and data sets of each ptp4l instance, the script for %s is shown",
+				devices.DefaultPTP4lConfig,
+			),
+			pmcScript,
+		),
+	}, nil
+}
+
+func planLogs(string) ([]PlannedCommand, error) {
+	return []PlannedCommand{
+		ptpDaemonCommand("follow the container logs through the Kubernetes API, no command is run", ""),
+	}, nil
+}
+
+func planTs2phc(string) ([]PlannedCommand, error) {
+	return []PlannedCommand{
+		ptpDaemonCommand("read the ts2phc offsets from the container logs through the Kubernetes API, no command is run", ""),
+	}, nil
+}
@@ -3,47 +3,102 @@
 package collectors //nolint:dupl // new collector
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
-	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
 )
 
 const (
-	PMCCollectorName = "PMC"
-	PMCInfo          = "pmc-info"
+	PMCCollectorName         = "PMC"
+	PMCInfo                  = "pmc-info"
+	PMCParentDataSet         = "pmc-parent-data-set"
+	PMCCurrentDataSet        = "pmc-current-data-set"
+	PMCTimeStatus            = "pmc-time-status"
+	PMCPortDataSet           = "pmc-port-data-set"
+	PMCTimePropertiesDataSet = "pmc-time-properties"
 )
 
 type PMCCollector struct {
 	*baseCollector
-	ctx clients.ExecContext
+	ctx       clients.ExecContext
+	instances []*pmcInstance
 }
 
-func (pmc *PMCCollector) poll() error {
-	gmSetting, err := devices.GetPMC(pmc.ctx)
-	if err != nil {
-		return fmt.Errorf("failed to fetch  %s %w", PMCInfo, err)
+// pmcInstance is a ptp4l instance along with the callback which tags its records
+type pmcInstance struct {
+	callback callbacks.Callback
+	devices.PTP4lInstance
+}
+
+// ptp4lInstanceTags returns the tags added to the records of a ptp4l instance
+func ptp4lInstanceTags(instance *devices.PTP4lInstance) map[string]string {
+	return map[string]string{
+		"instance":   instance.Name,
+		"interfaces": strings.Join(instance.Interfaces, ","),
+	}
+}
+
+// pmcRecords returns a record for each of the data sets which were parsed,
+// the port data set has one for each port
+func pmcRecords(dataSets *devices.PMCDataSets) []taggedOutput {
+	records := make([]taggedOutput, 0)
+	if dataSets.GMSettings != nil {
+		records = append(records, taggedOutput{output: dataSets.GMSettings, tag: PMCInfo})
+	}
+	if dataSets.Parent != nil {
+		records = append(records, taggedOutput{output: dataSets.Parent, tag: PMCParentDataSet})
+	}
+	if dataSets.Current != nil {
+		records = append(records, taggedOutput{output: dataSets.Current, tag: PMCCurrentDataSet})
+	}
+	if dataSets.TimeStatus != nil {
+		records = append(records, taggedOutput{output: dataSets.TimeStatus, tag: PMCTimeStatus})
+	}
+	if dataSets.TimeProperties != nil {
+		records = append(records, taggedOutput{output: dataSets.TimeProperties, tag: PMCTimePropertiesDataSet})
+	}
+	for i := range dataSets.Ports {
+		records = append(records, taggedOutput{output: &dataSets.Ports[i], tag: PMCPortDataSet})
 	}
-	err = pmc.callback.Call(&gmSetting, PMCInfo)
+	return records
+}
+
+// poll writes the data sets of the instance which were parsed and returns an error for each which was not
+func (pmc *PMCCollector) poll(ctx context.Context, instance *pmcInstance) []error {
+	dataSets, err := devices.GetPMC(ctx, pmc.ctx, instance.ConfigFile)
 	if err != nil {
-		return fmt.Errorf("callback failed %w", err)
+		return []error{fmt.Errorf("failed to fetch  %s for %s %w", PMCInfo, instance.Name, err)}
+	}
+	errs := make([]error, 0, len(dataSets.Errors))
+	for _, parseErr := range dataSets.Errors {
+		errs = append(errs, fmt.Errorf("failed to parse pmc response for %s %w", instance.Name, parseErr))
 	}
-	return nil
+	for _, record := range pmcRecords(&dataSets) {
+		err = callbacks.CallWithContext(ctx, instance.callback, record.output, record.tag)
+		if err != nil {
+			return append(errs, fmt.Errorf("callback failed %w", err))
+		}
+	}
+	return errs
 }
 
 // Poll collects information from the cluster then
 // calls the callback.Call to allow that to persist it
-func (pmc *PMCCollector) Poll(resultsChan chan PollResult, wg *utils.WaitGroupCount) {
+func (pmc *PMCCollector) Poll(ctx context.Context, resultsChan chan PollResult, wg *utils.WaitGroupCount) {
 	defer func() {
 		wg.Done()
 	}()
 
 	errorsToReturn := make([]error, 0)
-	err := pmc.poll()
-	if err != nil {
-		errorsToReturn = append(errorsToReturn, err)
+	for _, instance := range pmc.instances {
+		errorsToReturn = append(errorsToReturn, pmc.poll(ctx, instance)...)
 	}
 	resultsChan <- PollResult{
 		CollectorName: PMCCollectorName,
@@ -53,10 +108,19 @@ func (pmc *PMCCollector) Poll(resultsChan chan PollResult, wg *utils.WaitGroupCo
 
 // Returns a new PMCCollector based on values in the CollectionConstructor
 func NewPMCCollector(constructor *CollectionConstructor) (Collector, error) {
-	ctx, err := contexts.GetPTPDaemonContext(constructor.Clientset)
+	ctx, err := constructor.getPTPDaemonContext()
+	if err != nil {
+		return &PMCCollector{}, fmt.Errorf("failed to create PMCCollector: %w", err)
+	}
+	ptp4lInstances, err := devices.GetPTP4lInstances(context.Background(), ctx)
 	if err != nil {
 		return &PMCCollector{}, fmt.Errorf("failed to create PMCCollector: %w", err)
 	}
+	if len(ptp4lInstances) == 0 {
+		return &PMCCollector{}, utils.NewRequirementsNotMetError(
+			fmt.Errorf("no ptp4l config matching %s found", devices.PTP4lConfigGlob),
+		)
+	}
 
 	collector := PMCCollector{
 		baseCollector: newBaseCollector(
@@ -66,10 +130,125 @@ func NewPMCCollector(constructor *CollectionConstructor) (Collector, error) {
 		),
 		ctx: ctx,
 	}
+	for i := range ptp4lInstances {
+		instance := &ptp4lInstances[i]
+		log.Debugf("PMC will query %s which controls %v", instance.Name, instance.Interfaces)
+		collector.instances = append(collector.instances, &pmcInstance{
+			PTP4lInstance: *instance,
+			callback:      callbacks.NewTaggedCallback(constructor.Callback, ptp4lInstanceTags(instance)),
+		})
+	}
 
 	return &collector, nil
 }
 
+var pmcCollectorInfo = CollectorInfo{
+	Description: "Polls the grandmaster settings and the data sets of every ptp4l instance using pmc",
+	Requires:    []string{"linuxptp-daemon-container: pmc", "linuxptp-daemon-container: /var/run/ptp4l.*.config"},
+	Outputs: []OutputSchema{
+		{
+			AnalyserID:  "phc/gm-settings",
+			Tag:         PMCInfo,
+			Description: "One record per poll from GRANDMASTER_SETTINGS_NP",
+			Fields: []FieldSchema{
+				timestampField,
+				{Name: "clock_class", Type: "int", Description: "clockClass of the grandmaster"},
+				{Name: "clockAccuracy", Type: "string", Description: "clockAccuracy of the grandmaster"},
+				{Name: "offsetScaledLogVariance", Type: "string", Description: "offsetScaledLogVariance of the grandmaster"},
+				{Name: "currentUtcOffset", Type: "int", Description: "offset between TAI and UTC in seconds"},
+				{Name: "leap61", Type: "int", Description: "1 if the last minute of the day has 61 seconds"},
+				{Name: "leap59", Type: "int", Description: "1 if the last minute of the day has 59 seconds"},
+				{Name: "currentUtcOffsetValid", Type: "int", Description: "1 if currentUtcOffset is known to be correct"},
+				{Name: "ptpTimescale", Type: "int", Description: "1 if the grandmaster uses the PTP timescale"},
+				{Name: "timeTraceable", Type: "int", Description: "1 if the time is traceable to a primary reference"},
+				{Name: "frequencyTraceable", Type: "int", Description: "1 if the frequency is traceable to a primary reference"},
+				{Name: "timeSource", Type: "string", Description: "source of time used by the grandmaster"},
+			},
+		},
+		{
+			AnalyserID:  "ptp4l/parent-data-set",
+			Tag:         PMCParentDataSet,
+			Description: "One record per poll from PARENT_DATA_SET",
+			Fields: []FieldSchema{
+				timestampField,
+				{Name: "parentPortIdentity", Type: "string", Description: "identity of the port ptp4l is synchronised to"},
+				{Name: "parentStats", Type: "int", Description: "1 if the observed parent statistics are valid"},
+				{
+					Name: "observedParentOffsetScaledLogVariance", Type: "string", Description: "observed variance of the parent",
+				},
+				{Name: "observedParentClockPhaseChangeRate", Type: "string", Description: "phase change rate of the parent"},
+				{Name: "grandmasterPriority1", Type: "int", Description: "priority1 of the grandmaster"},
+				{Name: "gmClockClass", Type: "int", Description: "clockClass of the grandmaster"},
+				{Name: "gmClockAccuracy", Type: "string", Description: "clockAccuracy of the grandmaster"},
+				{Name: "gmOffsetScaledLogVariance", Type: "string", Description: "offsetScaledLogVariance of the grandmaster"},
+				{Name: "grandmasterPriority2", Type: "int", Description: "priority2 of the grandmaster"},
+				{Name: "grandmasterIdentity", Type: "string", Description: "clock identity of the grandmaster"},
+			},
+		},
+		{
+			AnalyserID:  "ptp4l/current-data-set",
+			Tag:         PMCCurrentDataSet,
+			Description: "One record per poll from CURRENT_DATA_SET",
+			Fields: []FieldSchema{
+				timestampField,
+				{Name: "stepsRemoved", Type: "int", Description: "number of boundary clocks to the grandmaster"},
+				{Name: "offsetFromMaster", Type: "float", Description: "offset from the master in nanoseconds"},
+				{Name: "meanPathDelay", Type: "float", Description: "mean path delay to the master in nanoseconds"},
+			},
+		},
+		{
+			AnalyserID:  "ptp4l/time-status",
+			Tag:         PMCTimeStatus,
+			Description: "One record per poll from TIME_STATUS_NP",
+			Fields: []FieldSchema{
+				timestampField,
+				{Name: "masterOffset", Type: "int", Description: "offset from the master in nanoseconds"},
+				{Name: "ingressTime", Type: "int", Description: "time the last sync message was received in nanoseconds"},
+				{Name: "cumulativeScaledRateOffset", Type: "float", Description: "rate offset to the grandmaster"},
+				{Name: "scaledLastGmPhaseChange", Type: "int", Description: "scaled phase change of the last grandmaster change"},
+				{Name: "gmTimeBaseIndicator", Type: "int", Description: "time base indicator of the grandmaster"},
+				{Name: "lastGmPhaseChange", Type: "string", Description: "phase change of the last grandmaster change"},
+				{Name: "gmPresent", Type: "bool", Description: "true if a grandmaster is present"},
+				{Name: "gmIdentity", Type: "string", Description: "clock identity of the grandmaster"},
+			},
+		},
+		{
+			AnalyserID:  "ptp4l/port-data-set",
+			Tag:         PMCPortDataSet,
+			Description: "One record per port per poll from PORT_DATA_SET",
+			Fields: []FieldSchema{
+				timestampField,
+				{Name: "portIdentity", Type: "string", Description: "identity of the port"},
+				{Name: "portState", Type: "string", Description: "state of the port e.g. SLAVE or MASTER"},
+				{Name: "logMinDelayReqInterval", Type: "int", Description: "log2 of the delay request interval"},
+				{Name: "peerMeanPathDelay", Type: "int", Description: "peer mean path delay in nanoseconds"},
+				{Name: "logAnnounceInterval", Type: "int", Description: "log2 of the announce interval"},
+				{Name: "announceReceiptTimeout", Type: "int", Description: "announce intervals before a timeout"},
+				{Name: "logSyncInterval", Type: "int", Description: "log2 of the sync interval"},
+				{Name: "delayMechanism", Type: "int", Description: "delay mechanism of the port"},
+				{Name: "logMinPdelayReqInterval", Type: "int", Description: "log2 of the peer delay request interval"},
+				{Name: "versionNumber", Type: "int", Description: "PTP version of the port"},
+			},
+		},
+		{
+			AnalyserID:  "ptp4l/time-properties",
+			Tag:         PMCTimePropertiesDataSet,
+			Description: "One record per poll from TIME_PROPERTIES_DATA_SET",
+			Fields: []FieldSchema{
+				timestampField,
+				{Name: "currentUtcOffset", Type: "int", Description: "offset between TAI and UTC in seconds"},
+				{Name: "leap61", Type: "int", Description: "1 if the last minute of the day has 61 seconds"},
+				{Name: "leap59", Type: "int", Description: "1 if the last minute of the day has 59 seconds"},
+				{Name: "currentUtcOffsetValid", Type: "int", Description: "1 if currentUtcOffset is known to be correct"},
+				{Name: "ptpTimescale", Type: "int", Description: "1 if the grandmaster uses the PTP timescale"},
+				{Name: "timeTraceable", Type: "int", Description: "1 if the time is traceable to a primary reference"},
+				{Name: "frequencyTraceable", Type: "int", Description: "1 if the frequency is traceable to a primary reference"},
+				{Name: "timeSource", Type: "string", Description: "source of time used by the grandmaster"},
+			},
+		},
+	},
+}
+
 func init() {
-	RegisterCollector(PMCCollectorName, NewPMCCollector, optional)
+	RegisterCollector(PMCCollectorName, NewPMCCollector, planPMC, pmcCollectorInfo, optional, perNode)
 }
@@ -5,20 +5,36 @@ package collectors
 import (
 	"fmt"
 	"log"
+	"sync"
 )
 
 type collectonBuilderFunc func(*CollectionConstructor) (Collector, error)
 type collectorInclusionType int
+type collectorScope int
 
 const (
 	required collectorInclusionType = iota
 	optional
 )
 
+const (
+	// perNode collectors have a single instance per run
+	perNode collectorScope = iota
+	// perInterface collectors have an instance for each requested PTP interface
+	perInterface
+)
+
+// CollectorRegistry holds the collectors which can be run. The built in collectors register themselves
+// on start up, user defined collectors can be added later so the registry is safe for concurrent use.
 type CollectorRegistry struct {
-	registry map[string]collectonBuilderFunc
-	required []string
-	optional []string
+	registry     map[string]collectonBuilderFunc
+	plans        map[string]collectorPlanFunc
+	infos        map[string]CollectorInfo
+	perInterface map[string]bool
+	userDefined  map[string]bool
+	required     []string
+	optional     []string
+	lock         sync.RWMutex
 }
 
 var registry *CollectorRegistry
@@ -30,9 +46,52 @@ func GetRegistry() *CollectorRegistry {
 func (reg *CollectorRegistry) register(
 	collectorName string,
 	builderFunc collectonBuilderFunc,
+	planFunc collectorPlanFunc,
+	info CollectorInfo,
 	inclusionType collectorInclusionType,
+	scope collectorScope,
 ) {
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+	reg.add(collectorName, builderFunc, planFunc, info, inclusionType, scope)
+}
+
+// registerUserDefined adds an optional collector declared in a collector spec file.
+// It may replace an earlier user defined collector but not a built in one.
+func (reg *CollectorRegistry) registerUserDefined(
+	collectorName string,
+	builderFunc collectonBuilderFunc,
+	planFunc collectorPlanFunc,
+	info CollectorInfo,
+	scope collectorScope,
+) error {
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+	if _, ok := reg.registry[collectorName]; ok && !reg.userDefined[collectorName] {
+		return fmt.Errorf("collector %s is built in so can not be redefined", collectorName)
+	}
+	reg.userDefined[collectorName] = true
+	reg.add(collectorName, builderFunc, planFunc, info, optional, scope)
+	return nil
+}
+
+// add stores the collector, if one is already registered with the same name it is replaced
+func (reg *CollectorRegistry) add(
+	collectorName string,
+	builderFunc collectonBuilderFunc,
+	planFunc collectorPlanFunc,
+	info CollectorInfo,
+	inclusionType collectorInclusionType,
+	scope collectorScope,
+) {
+	_, replaced := reg.registry[collectorName]
 	reg.registry[collectorName] = builderFunc
+	reg.plans[collectorName] = planFunc
+	reg.infos[collectorName] = info
+	reg.perInterface[collectorName] = scope == perInterface
+	if replaced {
+		return
+	}
 	switch inclusionType {
 	case required:
 		reg.required = append(reg.required, collectorName)
@@ -44,6 +103,8 @@ func (reg *CollectorRegistry) register(
 }
 
 func (reg *CollectorRegistry) GetBuilderFunc(collectorName string) (collectonBuilderFunc, error) {
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
 	builderFunc, ok := reg.registry[collectorName]
 	if !ok {
 		return nil, fmt.Errorf("not index in registry for collector named %s", collectorName)
@@ -51,21 +112,84 @@ func (reg *CollectorRegistry) GetBuilderFunc(collectorName string) (collectonBui
 	return builderFunc, nil
 }
 
+// GetPlan returns the commands the collector would run for ptpInterface without running them
+func (reg *CollectorRegistry) GetPlan(collectorName, ptpInterface string) ([]PlannedCommand, error) {
+	reg.lock.RLock()
+	planFunc, ok := reg.plans[collectorName]
+	reg.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("not index in registry for collector named %s", collectorName)
+	}
+	return planFunc(ptpInterface)
+}
+
+// IsPerInterface returns true if the collector should be
+// instantiated once for every PTP interface
+func (reg *CollectorRegistry) IsPerInterface(collectorName string) bool {
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
+	return reg.perInterface[collectorName]
+}
+
+// IsUserDefined returns true if the collector was declared in a collector spec file
+func (reg *CollectorRegistry) IsUserDefined(collectorName string) bool {
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
+	return reg.userDefined[collectorName]
+}
+
 func (reg *CollectorRegistry) GetRequiredNames() []string {
-	return reg.required
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
+	return append([]string{}, reg.required...)
 }
 
 func (reg *CollectorRegistry) GetOptionalNames() []string {
-	return reg.optional
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
+	return append([]string{}, reg.optional...)
 }
 
-func RegisterCollector(collectorName string, builderFunc collectonBuilderFunc, inclusionType collectorInclusionType) {
+// RegisterCollector adds a built in collector along with the description shown by the collectors commands
+func RegisterCollector(
+	collectorName string,
+	builderFunc collectonBuilderFunc,
+	planFunc collectorPlanFunc,
+	info CollectorInfo,
+	inclusionType collectorInclusionType,
+	scope collectorScope,
+) {
 	if registry == nil {
-		registry = &CollectorRegistry{
-			registry: make(map[string]collectonBuilderFunc, 0),
-			required: make([]string, 0),
-			optional: make([]string, 0),
-		}
+		registry = newRegistry()
+	}
+	registry.register(collectorName, builderFunc, planFunc, info, inclusionType, scope)
+}
+
+func newRegistry() *CollectorRegistry {
+	return &CollectorRegistry{
+		registry:     make(map[string]collectonBuilderFunc, 0),
+		plans:        make(map[string]collectorPlanFunc, 0),
+		infos:        make(map[string]CollectorInfo, 0),
+		perInterface: make(map[string]bool, 0),
+		userDefined:  make(map[string]bool, 0),
+		required:     make([]string, 0),
+		optional:     make([]string, 0),
+	}
+}
+
+// clone returns a copy of the registry which collectors can be added to without changing the original
+func (reg *CollectorRegistry) clone() *CollectorRegistry {
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
+	copied := newRegistry()
+	for name, builderFunc := range reg.registry {
+		copied.registry[name] = builderFunc
+		copied.plans[name] = reg.plans[name]
+		copied.infos[name] = reg.infos[name]
+		copied.perInterface[name] = reg.perInterface[name]
+		copied.userDefined[name] = reg.userDefined[name]
 	}
-	registry.register(collectorName, builderFunc, inclusionType)
+	copied.required = append(copied.required, reg.required...)
+	copied.optional = append(copied.optional, reg.optional...)
+	return copied
 }
@@ -0,0 +1,298 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+)
+
+// taggedOutput is a record along with the tag it is written with
+type taggedOutput struct {
+	output callbacks.OutputType
+	tag    string
+}
+
+// replayFunc re-processes a captured output into the records the collector would have written,
+// it returns no records if the script does not produce any
+type replayFunc func(ctx context.Context, execCtx clients.ExecContext) ([]taggedOutput, error)
+
+// single returns the record of a script which produces exactly one
+func single(output callbacks.OutputType, tag string, err error) ([]taggedOutput, error) {
+	if err != nil {
+		return nil, err
+	}
+	return []taggedOutput{{output: output, tag: tag}}, nil
+}
+
+// replayer recognises the captured output of one of a collector's scripts
+type replayer struct {
+	replay        replayFunc
+	collectorName string
+	script        string
+	// pod is only set if the script is run in a different pod for each interface
+	pod string
+	// ptpInterface is only set if the same script is run for every interface
+	ptpInterface string
+	// tags are added to the records, they are the same as those the collector adds
+	tags map[string]string
+}
+
+func interfaceTags(ptpInterface string) map[string]string {
+	return map[string]string{"interface": ptpInterface}
+}
+
+// CaptureReplayer turns captured outputs back into the records the collectors would have written
+type CaptureReplayer struct {
+	clockIDs      map[string]*big.Int
+	replayers     []*replayer
+	ptpInterfaces []string
+}
+
+// NewCaptureReplayer returns a CaptureReplayer which recognises the scripts run by
+// the node level collectors and those run by the interface collectors for ptpInterfaces
+func NewCaptureReplayer(ptpInterfaces []string) (*CaptureReplayer, error) {
+	replay := &CaptureReplayer{clockIDs: make(map[string]*big.Int), ptpInterfaces: ptpInterfaces}
+	instancesScript, err := devices.GetPTP4lInstancesCommand()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build replayer for %s: %w", PMCCollectorName, err)
+	}
+	legacyPMCScript, err := devices.GetLegacyGMSettingsCommand()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build replayer for %s: %w", PMCCollectorName, err)
+	}
+	replay.replayers = append(replay.replayers,
+		&replayer{
+			collectorName: GPSCollectorName,
+			script:        devices.GetGPSNavCommand(),
+			replay: func(ctx context.Context, execCtx clients.ExecContext) ([]taggedOutput, error) {
+				gpsNav, err := devices.GetGPSNav(ctx, execCtx)
+				return single(&gpsNav, gpsNavKey, err)
+			},
+		},
+		// The instances found when the collector started decide which configs
+		// are queried and how their records are tagged
+		&replayer{
+			collectorName: PMCCollectorName,
+			script:        instancesScript,
+			replay: func(ctx context.Context, execCtx clients.ExecContext) ([]taggedOutput, error) {
+				instances, err := devices.GetPTP4lInstances(ctx, execCtx)
+				if err != nil {
+					return nil, err //nolint:wrapcheck // the caller adds context
+				}
+				return nil, replay.setPTP4lInstances(instances)
+			},
+		},
+		// Captures made before the data sets were collected only queried the grandmaster settings
+		&replayer{
+			collectorName: PMCCollectorName,
+			script:        legacyPMCScript,
+			replay: func(ctx context.Context, execCtx clients.ExecContext) ([]taggedOutput, error) {
+				gmSettings, err := devices.GetLegacyGMSettings(ctx, execCtx)
+				return single(gmSettings, PMCInfo, err)
+			},
+		},
+	)
+	// Captures which do not include the instances only queried the default config
+	err = replay.addPMC(devices.DefaultPTP4lConfig, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, ptpInterface := range ptpInterfaces {
+		err := replay.addInterface(ptpInterface)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return replay, nil
+}
+
+// addPMC adds the replayer for the PMC script of the ptp4l instance which uses configFile
+func (replay *CaptureReplayer) addPMC(configFile string, tags map[string]string) error {
+	script, err := devices.GetPMCCommand(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to build replayer for %s: %w", PMCCollectorName, err)
+	}
+	replay.replayers = append(replay.replayers, &replayer{
+		collectorName: PMCCollectorName,
+		script:        script,
+		tags:          tags,
+		replay: func(ctx context.Context, execCtx clients.ExecContext) ([]taggedOutput, error) {
+			dataSets, err := devices.GetPMC(ctx, execCtx, configFile)
+			if err != nil {
+				return nil, err //nolint:wrapcheck // the caller adds context
+			}
+			records := pmcRecords(&dataSets)
+			if len(records) == 0 {
+				return nil, fmt.Errorf("no pmc responses could be parsed: %v", dataSets.Errors)
+			}
+			// The data sets which were parsed are still replayed like the collector would have written them
+			for _, parseErr := range dataSets.Errors {
+				log.Warnf("skipping part of %s capture for %s: %s", PMCCollectorName, configFile, parseErr.Error())
+			}
+			return records, nil
+		},
+	})
+	return nil
+}
+
+// setPTP4lInstances replaces the replayers of the PMC scripts with one for each of the instances
+func (replay *CaptureReplayer) setPTP4lInstances(instances []devices.PTP4lInstance) error {
+	instancesScript, err := devices.GetPTP4lInstancesCommand()
+	if err != nil {
+		return fmt.Errorf("failed to build replayer for %s: %w", PMCCollectorName, err)
+	}
+	legacyPMCScript, err := devices.GetLegacyGMSettingsCommand()
+	if err != nil {
+		return fmt.Errorf("failed to build replayer for %s: %w", PMCCollectorName, err)
+	}
+	replayers := make([]*replayer, 0, len(replay.replayers))
+	for _, rep := range replay.replayers {
+		if rep.collectorName != PMCCollectorName || rep.script == instancesScript || rep.script == legacyPMCScript {
+			replayers = append(replayers, rep)
+		}
+	}
+	replay.replayers = replayers
+	for i := range instances {
+		err = replay.addPMC(instances[i].ConfigFile, ptp4lInstanceTags(&instances[i]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addInterface adds the replayers for the scripts the interface collectors run for ptpInterface
+func (replay *CaptureReplayer) addInterface(ptpInterface string) error {
+	devInfoScript, err := devices.GetPTPDeviceInfoCommand(ptpInterface)
+	if err != nil {
+		return fmt.Errorf("failed to build replayer for %s: %w", DevInfoCollectorName, err)
+	}
+	dpllFSScript, err := devices.GetDevDPLLFilesystemCommand(ptpInterface)
+	if err != nil {
+		return fmt.Errorf("failed to build replayer for %s: %w", DPLLCollectorName, err)
+	}
+	clockIDScript, err := devices.GetClockIDCommand(ptpInterface)
+	if err != nil {
+		return fmt.Errorf("failed to build replayer for %s: %w", DPLLCollectorName, err)
+	}
+	netlinkScript, err := devices.GetDevDPLLNetlinkCommand()
+	if err != nil {
+		return fmt.Errorf("failed to build replayer for %s: %w", DPLLCollectorName, err)
+	}
+
+	replay.replayers = append(replay.replayers,
+		&replayer{
+			collectorName: DevInfoCollectorName,
+			script:        devInfoScript,
+			tags:          interfaceTags(ptpInterface),
+			replay: func(ctx context.Context, execCtx clients.ExecContext) ([]taggedOutput, error) {
+				devInfo, err := devices.GetPTPDeviceInfo(ctx, ptpInterface, execCtx)
+				return single(&devInfo, DeviceInfo, err)
+			},
+		},
+		&replayer{
+			collectorName: DPLLCollectorName,
+			script:        dpllFSScript,
+			tags:          interfaceTags(ptpInterface),
+			replay: func(ctx context.Context, execCtx clients.ExecContext) ([]taggedOutput, error) {
+				dpllInfo, err := devices.GetDevDPLLFilesystemInfo(ctx, execCtx, ptpInterface)
+				return single(&dpllInfo, DPLLInfo, err)
+			},
+		},
+		// The netlink output holds every DPLL on the node so the clock ID found when
+		// the collector started is needed to pick out the ones for this interface
+		&replayer{
+			collectorName: DPLLCollectorName,
+			script:        clockIDScript,
+			pod:           contexts.GetNetlinkPodName(ptpInterface),
+			tags:          interfaceTags(ptpInterface),
+			replay: func(ctx context.Context, execCtx clients.ExecContext) ([]taggedOutput, error) {
+				clockID, err := devices.GetClockID(ctx, execCtx, ptpInterface)
+				if err != nil {
+					return nil, err //nolint:wrapcheck // the caller adds context
+				}
+				replay.clockIDs[ptpInterface] = clockID.ClockID
+				return nil, nil
+			},
+		},
+		&replayer{
+			collectorName: DPLLCollectorName,
+			script:        netlinkScript,
+			pod:           contexts.GetNetlinkPodName(ptpInterface),
+			ptpInterface:  ptpInterface,
+			tags:          interfaceTags(ptpInterface),
+			replay: func(ctx context.Context, execCtx clients.ExecContext) ([]taggedOutput, error) {
+				clockID, ok := replay.clockIDs[ptpInterface]
+				if !ok {
+					return nil, fmt.Errorf("no clock ID has been captured for %s", ptpInterface)
+				}
+				dpllInfo, err := devices.GetDevDPLLNetlinkInfo(ctx, execCtx, clockID)
+				return single(&dpllInfo, DPLLNetlinkInfo, err)
+			},
+		},
+	)
+	return nil
+}
+
+// matches reports if the capture is of the replayer's script. If the same script is run for every
+// interface the interface recorded with the capture is compared, captures made before the interface
+// was recorded are matched on their pod. Captures with neither match any interface, see isAmbiguous.
+func (rep *replayer) matches(capture *clients.CapturedExec) bool {
+	if capture.Stdin != rep.script {
+		return false
+	}
+	if rep.ptpInterface != "" && capture.Interface != "" {
+		return capture.Interface == rep.ptpInterface
+	}
+	return rep.pod == "" || capture.Pod == "" || capture.Pod == rep.pod
+}
+
+// isAmbiguous reports if there is no way to tell which interface the capture was for,
+// which is the case for older captures of a script run for every interface made on a host
+func (replay *CaptureReplayer) isAmbiguous(rep *replayer, capture *clients.CapturedExec) bool {
+	return rep.ptpInterface != "" && capture.Interface == "" && capture.Pod == "" && len(replay.ptpInterfaces) > 1
+}
+
+// Replay re-processes the captured output and passes the record it produces to the callback.
+// It returns the name of the collector which ran the script, or an empty string if none of them did.
+func (replay *CaptureReplayer) Replay(
+	ctx context.Context,
+	capture *clients.CapturedExec,
+	callback callbacks.Callback,
+) (string, error) {
+	for _, rep := range replay.replayers {
+		if !rep.matches(capture) {
+			continue
+		}
+		if replay.isAmbiguous(rep, capture) {
+			return rep.collectorName, fmt.Errorf(
+				"can not replay %s capture from %s as it does not record which of %v it was for",
+				rep.collectorName, capture.Time, replay.ptpInterfaces,
+			)
+		}
+		records, err := rep.replay(ctx, clients.NewPlaybackExecContext([]*clients.CapturedExec{capture}))
+		if err != nil {
+			return rep.collectorName, fmt.Errorf("failed to replay %s capture from %s: %w", rep.collectorName, capture.Time, err)
+		}
+		if len(rep.tags) > 0 {
+			callback = callbacks.NewTaggedCallback(callback, rep.tags)
+		}
+		for _, record := range records {
+			err = callback.Call(record.output, record.tag)
+			if err != nil {
+				return rep.collectorName, fmt.Errorf("callback failed %w", err)
+			}
+		}
+		return rep.collectorName, nil
+	}
+	return "", nil
+}
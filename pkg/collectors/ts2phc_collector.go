@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package collectors
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/loglines"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
+)
+
+const (
+	Ts2phcCollectorName = "Ts2phc"
+	Ts2phcInfo          = "ts2phc-offset"
+)
+
+// Ts2phcCollector reads the offsets ts2phc reports while steering the PHCs from their 1PPS source.
+// ts2phc has no management interface so each poll reads the lines logged by the
+// linuxptp-daemon container since the previous poll. lastLine is zero until a line has been read.
+type Ts2phcCollector struct {
+	*baseCollector
+	client   *clients.Clientset
+	lastLine time.Time
+	nodeName string
+}
+
+// getLogOptions asks for the lines logged since the last line read. Before any line has been read
+// the lines of the last poll interval are asked for, so the start of the run is measured by the
+// clock of the node rather than the clock of the machine running the collector.
+func (ts2phc *Ts2phcCollector) getLogOptions() *v1.PodLogOptions {
+	podLogOptions := &v1.PodLogOptions{
+		Container:  contexts.PTPContainer,
+		Timestamps: true,
+	}
+	if ts2phc.lastLine.IsZero() {
+		sinceSeconds := int64(math.Ceil(ts2phc.GetPollInterval().Seconds()))
+		if sinceSeconds < 1 {
+			sinceSeconds = 1
+		}
+		podLogOptions.SinceSeconds = &sinceSeconds
+	} else {
+		podLogOptions.SinceTime = &metav1.Time{Time: ts2phc.lastLine}
+	}
+	return podLogOptions
+}
+
+// processTs2phcLogs calls the callback for every offset logged after lastLine, it
+// returns the time of the last line read so the next poll can carry on from there
+func processTs2phcLogs(
+	ctx context.Context,
+	stream io.Reader,
+	lastLine time.Time,
+	callback callbacks.Callback,
+) (time.Time, error) {
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		line, err := loglines.ProcessLine(scanner.Text())
+		if err != nil {
+			log.Debug("failed to process line: ", err)
+			continue
+		}
+		// SinceTime only has a resolution of a second so lines from the previous poll are returned again
+		if !line.Timestamp.After(lastLine) {
+			continue
+		}
+		lastLine = line.Timestamp
+		offset, found, err := devices.ParseTs2phcOffset(line.Timestamp, line.Content)
+		if err != nil {
+			return lastLine, err
+		}
+		if !found {
+			continue
+		}
+		err = callbacks.CallWithContext(ctx, callback, &offset, Ts2phcInfo)
+		if err != nil {
+			return lastLine, fmt.Errorf("callback failed %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return lastLine, fmt.Errorf("error while reading logs stream %w", err)
+	}
+	return lastLine, nil
+}
+
+func (ts2phc *Ts2phcCollector) poll(ctx context.Context) error {
+	podName, err := contexts.GetPTPDaemonPodName(ts2phc.client, ts2phc.nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to poll: %w", err)
+	}
+	stream, err := ts2phc.client.K8sClient.CoreV1().
+		Pods(contexts.PTPNamespace).
+		GetLogs(podName, ts2phc.getLogOptions()).
+		Timeout(followTimeout).
+		Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read ts2phc logs: %w", err)
+	}
+	defer stream.Close()
+
+	ts2phc.lastLine, err = processTs2phcLogs(ctx, stream, ts2phc.lastLine, ts2phc.callback)
+	return err
+}
+
+// Poll collects the offsets logged by ts2phc then
+// calls the callback.Call to allow that to persist them
+func (ts2phc *Ts2phcCollector) Poll(ctx context.Context, resultsChan chan PollResult, wg *utils.WaitGroupCount) {
+	defer func() {
+		wg.Done()
+	}()
+
+	errorsToReturn := make([]error, 0)
+	err := ts2phc.poll(ctx)
+	if err != nil {
+		errorsToReturn = append(errorsToReturn, err)
+	}
+	resultsChan <- PollResult{
+		CollectorName: Ts2phcCollectorName,
+		Errors:        errorsToReturn,
+	}
+}
+
+// Returns a new Ts2phcCollector based on values in the CollectionConstructor
+func NewTs2phcCollector(constructor *CollectionConstructor) (Collector, error) {
+	if constructor.Clientset == nil {
+		return &Ts2phcCollector{}, fmt.Errorf("%s reads the kubernetes API so needs a kubeconfig", Ts2phcCollectorName)
+	}
+	collector := Ts2phcCollector{
+		baseCollector: newBaseCollector(
+			constructor.PollInterval,
+			false,
+			constructor.Callback,
+		),
+		client:   constructor.Clientset,
+		nodeName: constructor.NodeName,
+	}
+	return &collector, nil
+}
+
+var ts2phcCollectorInfo = CollectorInfo{
+	Description: "Reads the offset, frequency adjustment and servo state ts2phc logs " +
+		"while steering each PHC from its 1PPS source",
+	Requires: []string{"Kubernetes API: read access to the logs of the linuxptp-daemon pod"},
+	Outputs: []OutputSchema{
+		{
+			AnalyserID:  "ts2phc/time-error",
+			Tag:         Ts2phcInfo,
+			Description: "One record per offset logged by ts2phc, the Logs collector also writes each as a phc/offset",
+			Fields: []FieldSchema{
+				{Name: "timestamp", Type: "string", Description: "RFC3339 time the line was logged"},
+				{Name: "interface", Type: "string", Description: "interface of the PHC being steered"},
+				{Name: "terror", Type: "int", Description: "offset of the PHC from the 1PPS source in ns"},
+				{Name: "freq", Type: "int", Description: "frequency adjustment of the PHC in ppb"},
+				{Name: "state", Type: "string", Description: "servo state: s0 unlocked, s1 jump, s2 locked, s3 locked stable"},
+			},
+		},
+	},
+}
+
+func init() {
+	RegisterCollector(Ts2phcCollectorName, NewTs2phcCollector, planTs2phc, ts2phcCollectorInfo, optional, perNode)
+}
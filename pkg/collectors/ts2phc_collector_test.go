@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package collectors //nolint:testpackage // testing the log options and processing without a cluster
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+)
+
+type nopWriteCloser struct {
+	bytes.Buffer
+}
+
+func (*nopWriteCloser) Close() error {
+	return nil
+}
+
+var _ = Describe("Ts2phcCollector", func() {
+	When("no line has been read yet", func() {
+		It("should ask for the lines of the last poll interval", func() {
+			ts2phc := &Ts2phcCollector{baseCollector: newBaseCollector(5, false, nil)}
+			options := ts2phc.getLogOptions()
+			Expect(options.SinceTime).To(BeNil())
+			Expect(options.SinceSeconds).NotTo(BeNil())
+			Expect(*options.SinceSeconds).To(Equal(int64(5)))
+		})
+		It("should process every line", func() {
+			lines := strings.Join([]string{
+				"2023-06-16T11:49:47.000000000Z ts2phc[1896327.319]: [ts2phc.0.config] ens7f0 master offset -1 s2 freq -2",
+				"2023-06-16T11:49:48.000000000Z ts2phc[1896328.319]: [ts2phc.0.config] ens7f0 master offset 3 s2 freq +1",
+			}, "\n")
+			tags := make([]string, 0)
+			callback := callbacks.NewObservingCallback(
+				callbacks.NewFileCallback(&nopWriteCloser{}, callbacks.Raw),
+				func(_ callbacks.OutputType, tag string, _ map[string]string) { tags = append(tags, tag) },
+			)
+			lastLine, err := processTs2phcLogs(context.Background(), strings.NewReader(lines), time.Time{}, callback)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tags).To(Equal([]string{Ts2phcInfo, Ts2phcInfo}))
+			Expect(lastLine).To(Equal(time.Date(2023, 6, 16, 11, 49, 48, 0, time.UTC)))
+		})
+	})
+	When("a line has been read", func() {
+		It("should ask for the lines logged since it", func() {
+			lastLine := time.Date(2023, 6, 16, 11, 49, 48, 0, time.UTC)
+			ts2phc := &Ts2phcCollector{baseCollector: newBaseCollector(5, false, nil), lastLine: lastLine}
+			options := ts2phc.getLogOptions()
+			Expect(options.SinceSeconds).To(BeNil())
+			Expect(options.SinceTime.Time).To(Equal(lastLine))
+		})
+	})
+})
@@ -4,11 +4,14 @@ package fetcher
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
 )
 
@@ -52,13 +55,22 @@ func (inst *Fetcher) AddCommand(cmdInst *clients.Cmd) {
 	inst.cmdGrp.AddCommand(cmdInst)
 }
 
-// Fetch executes the commands on the container passed as the ctx and
-// use the results to populate pack
-func (inst *Fetcher) Fetch(ctx clients.ExecContext, pack any) error {
-	runResult, err := runCommands(ctx, inst.cmdGrp)
+// GetCommand returns the script which Fetch sends to the container
+func (inst *Fetcher) GetCommand() string {
+	return inst.cmdGrp.GetCommand()
+}
+
+// Fetch executes the commands on the container passed as the execCtx and
+// use the results to populate pack. Cancelling ctx stops any command which is still running.
+// If ctx carries a sample the exec timings and the node's date are recorded on it.
+func (inst *Fetcher) Fetch(ctx context.Context, execCtx clients.ExecContext, pack any) error {
+	runResult, err := runCommands(ctx, execCtx, inst.cmdGrp)
 	if err != nil {
 		return err
 	}
+	if date, ok := runResult["date"]; ok {
+		callbacks.GetSample(ctx).SetNodeTimestamp(date)
+	}
 	result := make(map[string]any)
 	for key, value := range runResult {
 		result[key] = value
@@ -79,19 +91,23 @@ func (inst *Fetcher) Fetch(ctx clients.ExecContext, pack any) error {
 	return nil
 }
 
-// runCommands executes the commands on the container passed as the ctx
+// runCommands executes the commands on the container passed as the execCtx
 // and extracts the results from the stdout
-func runCommands(ctx clients.ExecContext, cmdGrp clients.Cmder) (result map[string]string, err error) { //nolint:lll // allow slightly long function definition
+//
+//nolint:lll // allow slightly long function definition
+func runCommands(ctx context.Context, execCtx clients.ExecContext, cmdGrp clients.Cmder) (result map[string]string, err error) {
 	cmd := cmdGrp.GetCommand()
 	command := []string{"/usr/bin/sh"}
 	var buffIn bytes.Buffer
 	buffIn.WriteString(cmd)
 
-	stdout, _, err := ctx.ExecCommandStdIn(command, buffIn)
+	send := time.Now()
+	stdout, _, err := execCtx.ExecCommandStdIn(ctx, command, buffIn)
+	callbacks.GetSample(ctx).RecordExec(send, time.Now())
 	if err != nil {
 		log.Debugf(
 			"command in container failed unexpectedly:\n\tcontext: %v\n\tcommand: %v\n\terror: %v",
-			ctx, command, err,
+			execCtx, command, err,
 		)
 		return result, fmt.Errorf("runCommands failed %w", err)
 	}
@@ -151,8 +151,6 @@ func (dump *GenerationDumper) dumpProcessor() {
 			return
 		case toDump := <-dump.toDump:
 			dump.writeToFile(toDump)
-		default:
-			time.Sleep(time.Nanosecond)
 		}
 	}
 }
@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package loglines
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+)
+
+const (
+	OffsetTag    = "phc-offset"
+	PortStateTag = "port-state"
+	FaultTag     = "ptp-fault"
+)
+
+var (
+	linuxptpLineRegex = regexp.MustCompile(
+		`^(?P<process>ptp4l|phc2sys|ts2phc)\[\d+\.\d+\]:\s+(?:\[(?P<config>[^\]:]+)(?::\d+)?\]\s+)?(?P<message>.*)$`,
+		// The daemon tags each line with the config file of the process and may add the log level:
+		// ptp4l[1896327.319]: [ptp4l.0.config] master offset         -4 s2 freq   -1234 path delay       567
+		// phc2sys[1896327.319]: [ptp4l.0.config:6] CLOCK_REALTIME phc offset        -6 s2 freq  -30209 delay    511
+	)
+	offsetRegex = regexp.MustCompile(
+		`^(?:(?P<clock>\S+)\s+)??(?:(?P<source>master|phc|sys)\s+)?offset\s+(?P<offset>-?\d+)\s+` +
+			`(?P<state>s\d)\s+freq\s+(?P<freq>[-+]?\d+)(?:\s+(?:path\s+)?delay\s+(?P<delay>-?\d+))?`,
+		// master offset         -4 s2 freq   -1234 path delay       567
+		// CLOCK_REALTIME phc offset        -6 s2 freq  -30209 delay    511
+		// ens2f0 master offset         -1 s2 freq      -2
+		// ens2f0 offset          3 s2 freq     +10
+	)
+	portStateRegex = regexp.MustCompile(
+		`^port (?P<port>\d+)(?: \((?P<interface>[^)]+)\))?: (?P<from>\S+) to (?P<to>\S+) on (?P<event>\S+)`,
+		// port 1: LISTENING to MASTER on ANNOUNCE_RECEIPT_TIMEOUT_EXPIRES
+		// port 1 (ens7f0): SLAVE to FAULTY on FAULT_DETECTED (FT_UNSPECIFIED)
+	)
+	faultPatterns = []struct {
+		regex *regexp.Regexp
+		fault string
+	}{
+		{regexp.MustCompile(`timed out while polling for tx timestamp`), "tx-timestamp-timeout"},
+		{regexp.MustCompile(`clock jumped backward or running slower than expected`), "clock-jump"},
+		{regexp.MustCompile(`clock frequency changed unexpectedly`), "clock-frequency-change"},
+		{regexp.MustCompile(`received \S+ without timestamp`), "missing-timestamp"},
+		{regexp.MustCompile(`bad message`), "bad-message"},
+		{regexp.MustCompile(`(?:send|recv) .*failed`), "message-failed"},
+		{regexp.MustCompile(`Waiting for ptp4l`), "ptp4l-not-running"},
+	}
+)
+
+// Offset is an offset of a clock from its source reported by ptp4l, phc2sys or ts2phc
+type Offset struct {
+	Delay      *int64 `json:"delay,omitempty"`
+	Timestamp  string `json:"timestamp"`
+	Process    string `json:"process"`
+	Config     string `json:"config,omitempty"`
+	Clock      string `json:"clock,omitempty"`
+	Source     string `json:"source,omitempty"`
+	ServoState string `json:"state"`
+	Offset     int64  `json:"terror"`
+	Frequency  int64  `json:"freq"`
+}
+
+// GetAnalyserFormat returns the json expected by the analysers
+func (offset *Offset) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	data := map[string]any{
+		"timestamp": offset.Timestamp,
+		"process":   offset.Process,
+		"config":    offset.Config,
+		"clock":     offset.Clock,
+		"source":    offset.Source,
+		"terror":    offset.Offset,
+		"freq":      offset.Frequency,
+		"state":     offset.ServoState,
+	}
+	if offset.Delay != nil {
+		data["delay"] = *offset.Delay
+	}
+	return []*callbacks.AnalyserFormatType{{ID: "phc/offset", Data: data}}, nil
+}
+
+// PortState is a change in the state of a ptp4l port
+type PortState struct {
+	Timestamp string `json:"timestamp"`
+	Config    string `json:"config,omitempty"`
+	Interface string `json:"interface,omitempty"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Event     string `json:"event"`
+	Port      int    `json:"port"`
+}
+
+// GetAnalyserFormat returns the json expected by the analysers
+func (state *PortState) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	return []*callbacks.AnalyserFormatType{{ID: "ptp4l/port-state", Data: state}}, nil
+}
+
+// Fault is a problem reported by ptp4l, phc2sys or ts2phc
+type Fault struct {
+	Timestamp string `json:"timestamp"`
+	Process   string `json:"process"`
+	Config    string `json:"config,omitempty"`
+	Fault     string `json:"fault"`
+	Message   string `json:"message"`
+}
+
+// GetAnalyserFormat returns the json expected by the analysers
+func (fault *Fault) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	return []*callbacks.AnalyserFormatType{{ID: "ptp/fault", Data: fault}}, nil
+}
+
+// linuxptpLine is a line logged by a linuxptp process split into its parts
+type linuxptpLine struct {
+	timestamp string
+	process   string
+	config    string
+	message   string
+}
+
+func splitLinuxptpLine(timestamp time.Time, content string) (linuxptpLine, bool) {
+	match := linuxptpLineRegex.FindStringSubmatch(content)
+	if match == nil {
+		return linuxptpLine{}, false
+	}
+	return linuxptpLine{
+		timestamp: timestamp.UTC().Format(time.RFC3339Nano),
+		process:   match[linuxptpLineRegex.SubexpIndex("process")],
+		config:    match[linuxptpLineRegex.SubexpIndex("config")],
+		message:   match[linuxptpLineRegex.SubexpIndex("message")],
+	}, true
+}
+
+func parseOffset(line *linuxptpLine) (Offset, bool, error) {
+	match := offsetRegex.FindStringSubmatch(line.message)
+	if match == nil {
+		return Offset{}, false, nil
+	}
+	offset := Offset{
+		Timestamp:  line.timestamp,
+		Process:    line.process,
+		Config:     line.config,
+		Clock:      match[offsetRegex.SubexpIndex("clock")],
+		Source:     match[offsetRegex.SubexpIndex("source")],
+		ServoState: match[offsetRegex.SubexpIndex("state")],
+	}
+	var err error
+	offset.Offset, err = strconv.ParseInt(match[offsetRegex.SubexpIndex("offset")], 10, 64)
+	if err != nil {
+		return offset, true, fmt.Errorf("failed to parse offset from %q: %w", line.message, err)
+	}
+	offset.Frequency, err = strconv.ParseInt(match[offsetRegex.SubexpIndex("freq")], 10, 64)
+	if err != nil {
+		return offset, true, fmt.Errorf("failed to parse frequency from %q: %w", line.message, err)
+	}
+	if delayStr := match[offsetRegex.SubexpIndex("delay")]; delayStr != "" {
+		delay, err := strconv.ParseInt(delayStr, 10, 64)
+		if err != nil {
+			return offset, true, fmt.Errorf("failed to parse delay from %q: %w", line.message, err)
+		}
+		offset.Delay = &delay
+	}
+	return offset, true, nil
+}
+
+func parsePortState(line *linuxptpLine) (PortState, bool, error) {
+	match := portStateRegex.FindStringSubmatch(line.message)
+	if match == nil || line.process != "ptp4l" {
+		return PortState{}, false, nil
+	}
+	port, err := strconv.Atoi(match[portStateRegex.SubexpIndex("port")])
+	if err != nil {
+		return PortState{}, true, fmt.Errorf("failed to parse port from %q: %w", line.message, err)
+	}
+	return PortState{
+		Timestamp: line.timestamp,
+		Config:    line.config,
+		Interface: match[portStateRegex.SubexpIndex("interface")],
+		From:      match[portStateRegex.SubexpIndex("from")],
+		To:        match[portStateRegex.SubexpIndex("to")],
+		Event:     match[portStateRegex.SubexpIndex("event")],
+		Port:      port,
+	}, true, nil
+}
+
+func parseFault(line *linuxptpLine) (Fault, bool) {
+	for _, pattern := range faultPatterns {
+		if pattern.regex.MatchString(line.message) {
+			return Fault{
+				Timestamp: line.timestamp,
+				Process:   line.process,
+				Config:    line.config,
+				Fault:     pattern.fault,
+				Message:   line.message,
+			}, true
+		}
+	}
+	return Fault{}, false
+}
+
+// ParseOffset extracts the offset from a line logged by ptp4l, phc2sys or ts2phc,
+// the second value is false if the line does not report an offset.
+func ParseOffset(timestamp time.Time, content string) (Offset, bool, error) {
+	line, ok := splitLinuxptpLine(timestamp, content)
+	if !ok {
+		return Offset{}, false, nil
+	}
+	return parseOffset(&line)
+}
+
+// ParseLine returns the record for a line logged by ptp4l, phc2sys or ts2phc which reports an offset,
+// a port state change or a fault along with its tag. The record is nil if the line is not recognised.
+func ParseLine(line *ProcessedLine) (callbacks.OutputType, string, error) {
+	linuxptp, ok := splitLinuxptpLine(line.Timestamp, line.Content)
+	if !ok {
+		return nil, "", nil
+	}
+	if offset, found, err := parseOffset(&linuxptp); found {
+		if err != nil {
+			return nil, "", err
+		}
+		return &offset, OffsetTag, nil
+	}
+	if state, found, err := parsePortState(&linuxptp); found {
+		if err != nil {
+			return nil, "", err
+		}
+		return &state, PortStateTag, nil
+	}
+	if fault, found := parseFault(&linuxptp); found {
+		return &fault, FaultTag, nil
+	}
+	return nil, "", nil
+}
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package loglines_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/loglines"
+)
+
+var _ = Describe("ParseLine", func() {
+	timestamp := time.Date(2023, 6, 16, 11, 49, 47, 58400000, time.UTC)
+	const expectedTimestamp = "2023-06-16T11:49:47.0584Z"
+	delay := func(value int64) *int64 { return &value }
+
+	DescribeTable("should recognise the lines of each linuxptp process",
+		func(content string, expected callbacks.OutputType, expectedTag string) {
+			record, tag, err := loglines.ParseLine(&loglines.ProcessedLine{Timestamp: timestamp, Content: content})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(record).To(Equal(expected))
+			Expect(tag).To(Equal(expectedTag))
+		},
+		Entry("ptp4l offset",
+			"ptp4l[1896327.319]: [ptp4l.0.config] master offset         -4 s2 freq   -1234 path delay       567",
+			&loglines.Offset{
+				Timestamp: expectedTimestamp, Process: "ptp4l", Config: "ptp4l.0.config", Source: "master",
+				ServoState: "s2", Offset: -4, Frequency: -1234, Delay: delay(567),
+			},
+			loglines.OffsetTag,
+		),
+		Entry("phc2sys offset with the log level in the tag",
+			"phc2sys[1896327.319]: [ptp4l.0.config:6] CLOCK_REALTIME phc offset        -6 s2 freq  -30209 delay    511",
+			&loglines.Offset{
+				Timestamp: expectedTimestamp, Process: "phc2sys", Config: "ptp4l.0.config", Clock: "CLOCK_REALTIME",
+				Source: "phc", ServoState: "s2", Offset: -6, Frequency: -30209, Delay: delay(511),
+			},
+			loglines.OffsetTag,
+		),
+		Entry("ts2phc offset",
+			"ts2phc[1896327.319]: [ts2phc.0.config] ens2f0 offset          3 s0 freq     +10",
+			&loglines.Offset{
+				Timestamp: expectedTimestamp, Process: "ts2phc", Config: "ts2phc.0.config", Clock: "ens2f0",
+				ServoState: "s0", Offset: 3, Frequency: 10,
+			},
+			loglines.OffsetTag,
+		),
+		Entry("port state change",
+			"ptp4l[1896327.319]: [ptp4l.0.config] port 1: LISTENING to MASTER on ANNOUNCE_RECEIPT_TIMEOUT_EXPIRES",
+			&loglines.PortState{
+				Timestamp: expectedTimestamp, Config: "ptp4l.0.config", Port: 1,
+				From: "LISTENING", To: "MASTER", Event: "ANNOUNCE_RECEIPT_TIMEOUT_EXPIRES",
+			},
+			loglines.PortStateTag,
+		),
+		Entry("port state change with the interface",
+			"ptp4l[1896327.319]: [ptp4l.1.config] port 2 (ens7f0): SLAVE to FAULTY on FAULT_DETECTED (FT_UNSPECIFIED)",
+			&loglines.PortState{
+				Timestamp: expectedTimestamp, Config: "ptp4l.1.config", Interface: "ens7f0", Port: 2,
+				From: "SLAVE", To: "FAULTY", Event: "FAULT_DETECTED",
+			},
+			loglines.PortStateTag,
+		),
+		Entry("fault",
+			"ptp4l[1896327.319]: [ptp4l.0.config] timed out while polling for tx timestamp",
+			&loglines.Fault{
+				Timestamp: expectedTimestamp, Process: "ptp4l", Config: "ptp4l.0.config",
+				Fault: "tx-timestamp-timeout", Message: "timed out while polling for tx timestamp",
+			},
+			loglines.FaultTag,
+		),
+		Entry("fault without a tag",
+			"phc2sys[1896327.319]: Waiting for ptp4l...",
+			&loglines.Fault{
+				Timestamp: expectedTimestamp, Process: "phc2sys", Fault: "ptp4l-not-running", Message: "Waiting for ptp4l...",
+			},
+			loglines.FaultTag,
+		),
+	)
+
+	It("should ignore other lines", func() {
+		for _, content := range []string{
+			"I0616 11:49:47.058400 2912 daemon.go:100] ptp4l is running",
+			"ptp4l[1896327.319]: [ptp4l.0.config] selected local clock 507c6f.fffe.1fb1f0 as best master",
+			"ts2phc[1896327.319]: [ts2phc.0.config] nmea sentence: GNRMC,114947.00,A,4233.01530,N,07112.87856,W",
+		} {
+			record, _, err := loglines.ParseLine(&loglines.ProcessedLine{Timestamp: timestamp, Content: content})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(record).To(BeNil(), content)
+		}
+	})
+
+	It("should only include the delay in the analyser format when it is logged", func() {
+		record, _, err := loglines.ParseLine(&loglines.ProcessedLine{
+			Timestamp: timestamp,
+			Content:   "ts2phc[1896327.319]: [ts2phc.0.config] ens2f0 master offset         -1 s2 freq      -2",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		formatted, err := record.GetAnalyserFormat()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(formatted).To(HaveLen(1))
+		Expect(formatted[0].ID).To(Equal("phc/offset"))
+		Expect(formatted[0].Data).To(Equal(map[string]any{
+			"timestamp": expectedTimestamp,
+			"process":   "ts2phc",
+			"config":    "ts2phc.0.config",
+			"clock":     "ens2f0",
+			"source":    "master",
+			"terror":    int64(-1),
+			"freq":      int64(-2),
+			"state":     "s2",
+		}))
+	})
+})
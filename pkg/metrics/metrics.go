@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+)
+
+const (
+	namespace         = "vse_sync"
+	metricsPath       = "/metrics"
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 5 * time.Second
+
+	clusterLabel    = "cluster"
+	interfaceLabel  = "interface"
+	collectorLabel  = "collector"
+	instanceLabel   = "instance"
+	interfacesLabel = "interfaces"
+)
+
+// HealthStates lists the states a collector can be in, each is exported
+// as a series of the collector state metric so that the current one is 1
+var HealthStates = []string{"healthy", "failing", "disabled"}
+
+// Exporter holds the latest values written by the collectors as prometheus metrics
+type Exporter struct {
+	registry            *prometheus.Registry
+	records             *prometheus.CounterVec
+	gnssFix             *prometheus.GaugeVec
+	gnssTimeAcc         *prometheus.GaugeVec
+	gnssFreqAcc         *prometheus.GaugeVec
+	gnssAntennaStatus   *prometheus.GaugeVec
+	gnssAntennaPower    *prometheus.GaugeVec
+	dpllState           *prometheus.GaugeVec
+	dpllTimeError       *prometheus.GaugeVec
+	pmcClockClass       *prometheus.GaugeVec
+	pmcClockAccuracy    *prometheus.GaugeVec
+	pmcUtcOffset        *prometheus.GaugeVec
+	pmcTimeTraceable    *prometheus.GaugeVec
+	pmcFreqTraceable    *prometheus.GaugeVec
+	polls               *prometheus.CounterVec
+	pollErrors          *prometheus.CounterVec
+	consecutiveFailures *prometheus.GaugeVec
+	collectorState      *prometheus.GaugeVec
+}
+
+func newGaugeVec(registry *prometheus.Registry, name, help string, labels ...string) *prometheus.GaugeVec {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: namespace, Name: name, Help: help}, labels)
+	registry.MustRegister(gauge)
+	return gauge
+}
+
+func newCounterVec(registry *prometheus.Registry, name, help string, labels ...string) *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: namespace, Name: name, Help: help}, labels)
+	registry.MustRegister(counter)
+	return counter
+}
+
+// NewExporter returns an Exporter with all of its metrics registered
+func NewExporter() *Exporter {
+	registry := prometheus.NewRegistry()
+	return &Exporter{
+		registry: registry,
+		records: newCounterVec(registry, "records_total",
+			"Number of records written to the output", clusterLabel, "tag"),
+		gnssFix: newGaugeVec(registry, "gnss_fix_type",
+			"UBX fix type of the GNSS receiver, 0 is no fix and 5 is time only", clusterLabel),
+		gnssTimeAcc: newGaugeVec(registry, "gnss_time_accuracy_nanoseconds",
+			"Time accuracy estimate of the GNSS receiver", clusterLabel),
+		gnssFreqAcc: newGaugeVec(registry, "gnss_frequency_accuracy_ps_per_second",
+			"Frequency accuracy estimate of the GNSS receiver", clusterLabel),
+		gnssAntennaStatus: newGaugeVec(registry, "gnss_antenna_status",
+			"UBX antenna status of each RF block", clusterLabel, "block"),
+		gnssAntennaPower: newGaugeVec(registry, "gnss_antenna_power",
+			"UBX antenna power status of each RF block", clusterLabel, "block"),
+		dpllState: newGaugeVec(registry, "dpll_state",
+			"State of the DPLL, 1 freerun, 2 locked, 3 locked-ho-acq and 4 holdover", clusterLabel, interfaceLabel, "dpll"),
+		dpllTimeError: newGaugeVec(registry, "dpll_time_error",
+			"Offset of the PPS DPLL as written to the analyser output", clusterLabel, interfaceLabel),
+		pmcClockClass: newGaugeVec(registry, "pmc_clock_class",
+			"clockClass of the grandmaster settings", clusterLabel, instanceLabel, interfacesLabel),
+		pmcClockAccuracy: newGaugeVec(registry, "pmc_clock_accuracy",
+			"clockAccuracy of the grandmaster settings", clusterLabel, instanceLabel, interfacesLabel),
+		pmcUtcOffset: newGaugeVec(registry, "pmc_current_utc_offset_seconds",
+			"currentUtcOffset of the grandmaster settings", clusterLabel, instanceLabel, interfacesLabel),
+		pmcTimeTraceable: newGaugeVec(registry, "pmc_time_traceable",
+			"timeTraceable flag of the grandmaster settings", clusterLabel, instanceLabel, interfacesLabel),
+		pmcFreqTraceable: newGaugeVec(registry, "pmc_frequency_traceable",
+			"frequencyTraceable flag of the grandmaster settings", clusterLabel, instanceLabel, interfacesLabel),
+		polls: newCounterVec(registry, "collector_polls_total",
+			"Number of polls of each collector", clusterLabel, collectorLabel),
+		pollErrors: newCounterVec(registry, "collector_poll_errors_total",
+			"Number of polls of each collector which had errors", clusterLabel, collectorLabel),
+		consecutiveFailures: newGaugeVec(registry, "collector_consecutive_failures",
+			"Number of polls in a row which have had errors", clusterLabel, collectorLabel),
+		collectorState: newGaugeVec(registry, "collector_state",
+			"The current health of each collector has a value of 1", clusterLabel, collectorLabel, "state"),
+	}
+}
+
+// ObserveRecord updates the metrics from a record written by a collector
+func (exporter *Exporter) ObserveRecord(cluster, tag string, output callbacks.OutputType, tags map[string]string) {
+	exporter.records.WithLabelValues(cluster, tag).Inc()
+	iface := tags[interfaceLabel]
+
+	switch info := output.(type) {
+	case *devices.GPSDetails:
+		exporter.gnssFix.WithLabelValues(cluster).Set(float64(info.NavStatus.GPSFix))
+		exporter.gnssTimeAcc.WithLabelValues(cluster).Set(float64(info.NavClock.TimeAcc))
+		exporter.gnssFreqAcc.WithLabelValues(cluster).Set(float64(info.NavClock.FreqAcc))
+		for _, antenna := range info.AntennaDetails {
+			block := strconv.Itoa(antenna.BlockID)
+			exporter.gnssAntennaStatus.WithLabelValues(cluster, block).Set(float64(antenna.Status))
+			exporter.gnssAntennaPower.WithLabelValues(cluster, block).Set(float64(antenna.Power))
+		}
+	case *devices.DevFilesystemDPLLInfo:
+		exporter.setDPLLState(cluster, iface, "pps", info.PPSState)
+		exporter.setDPLLState(cluster, iface, "eec", info.EECState)
+		exporter.dpllTimeError.WithLabelValues(cluster, iface).Set(info.GetTimeError())
+	case *devices.DevNetlinkDPLLInfo:
+		exporter.setDPLLState(cluster, iface, "pps", info.PPSState)
+		exporter.setDPLLState(cluster, iface, "eec", info.EECState)
+	case *devices.PMCInfo:
+		// Each ptp4l instance has its own grandmaster so its settings are kept apart
+		instance, ifaces := tags[instanceLabel], tags[interfacesLabel]
+		exporter.pmcClockClass.WithLabelValues(cluster, instance, ifaces).Set(float64(info.ClockClass))
+		exporter.pmcUtcOffset.WithLabelValues(cluster, instance, ifaces).Set(float64(info.CurrentUtcOffset))
+		exporter.pmcTimeTraceable.WithLabelValues(cluster, instance, ifaces).Set(float64(info.TimeTraceable))
+		exporter.pmcFreqTraceable.WithLabelValues(cluster, instance, ifaces).Set(float64(info.FrequencyTraceable))
+		// clockAccuracy is reported in hex e.g. 0x20
+		if accuracy, err := strconv.ParseInt(info.ClockAccuracy, 0, 64); err == nil {
+			exporter.pmcClockAccuracy.WithLabelValues(cluster, instance, ifaces).Set(float64(accuracy))
+		}
+	}
+}
+
+func (exporter *Exporter) setDPLLState(cluster, iface, dpll, state string) {
+	value, err := strconv.ParseFloat(state, 64)
+	if err != nil {
+		log.Debugf("could not export DPLL state %s: %s", state, err.Error())
+		return
+	}
+	exporter.dpllState.WithLabelValues(cluster, iface, dpll).Set(value)
+}
+
+// ObservePoll updates the health metrics of a collector after it has been polled
+func (exporter *Exporter) ObservePoll(cluster, collector, state string, failed bool, consecutiveFailures int) {
+	exporter.polls.WithLabelValues(cluster, collector).Inc()
+	if failed {
+		exporter.pollErrors.WithLabelValues(cluster, collector).Inc()
+	}
+	exporter.consecutiveFailures.WithLabelValues(cluster, collector).Set(float64(consecutiveFailures))
+	for _, healthState := range HealthStates {
+		value := 0.0
+		if healthState == state {
+			value = 1
+		}
+		exporter.collectorState.WithLabelValues(cluster, collector, healthState).Set(value)
+	}
+}
+
+// Handler returns the http.Handler which serves the metrics
+func (exporter *Exporter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.HandlerFor(exporter.registry, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// Serve serves the metrics on listener until ctx is cancelled
+func (exporter *Exporter) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{
+		Handler:           exporter.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Errorf("failed to shut down metrics server: %s", err.Error())
+		}
+	}()
+	log.Infof("Serving metrics on %s%s", listener.Addr(), metricsPath)
+	err := server.Serve(listener)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("metrics server failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/metrics"
+)
+
+func scrape(exporter *metrics.Exporter) string {
+	recorder := httptest.NewRecorder()
+	exporter.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+	Expect(recorder.Code).To(Equal(http.StatusOK))
+	return recorder.Body.String()
+}
+
+var _ = Describe("Exporter", func() {
+	var exporter *metrics.Exporter
+
+	BeforeEach(func() {
+		exporter = metrics.NewExporter()
+	})
+
+	When("GNSS records are observed", func() {
+		It("should export the latest values", func() {
+			exporter.ObserveRecord("gm", "gpsNav", &devices.GPSDetails{
+				NavStatus:      devices.GPSNavStatus{GPSFix: 5},
+				NavClock:       devices.GPSNavClock{TimeAcc: 12, FreqAcc: 150},
+				AntennaDetails: []*devices.GPSAntennaDetails{{BlockID: 1, Status: 2, Power: 1}},
+			}, nil)
+			exporter.ObserveRecord("gm", "gpsNav", &devices.GPSDetails{
+				NavStatus: devices.GPSNavStatus{GPSFix: 3},
+				NavClock:  devices.GPSNavClock{TimeAcc: 8, FreqAcc: 100},
+			}, nil)
+			body := scrape(exporter)
+			Expect(body).To(ContainSubstring(`vse_sync_gnss_fix_type{cluster="gm"} 3`))
+			Expect(body).To(ContainSubstring(`vse_sync_gnss_time_accuracy_nanoseconds{cluster="gm"} 8`))
+			Expect(body).To(ContainSubstring(`vse_sync_gnss_antenna_status{block="1",cluster="gm"} 2`))
+			Expect(body).To(ContainSubstring(`vse_sync_records_total{cluster="gm",tag="gpsNav"} 2`))
+		})
+	})
+	When("DPLL records are observed", func() {
+		It("should export the state of each DPLL by interface", func() {
+			tags := map[string]string{"interface": "ens7f0"}
+			exporter.ObserveRecord("gm", "dpll-info-fs", &devices.DevFilesystemDPLLInfo{
+				PPSState: "4", EECState: "2", PPSOffset: -1250,
+			}, tags)
+			body := scrape(exporter)
+			Expect(body).To(ContainSubstring(`vse_sync_dpll_state{cluster="gm",dpll="pps",interface="ens7f0"} 4`))
+			Expect(body).To(ContainSubstring(`vse_sync_dpll_state{cluster="gm",dpll="eec",interface="ens7f0"} 2`))
+			Expect(body).To(ContainSubstring(`vse_sync_dpll_time_error{cluster="gm",interface="ens7f0"} -12.5`))
+		})
+	})
+	When("PMC records are observed", func() {
+		It("should export the grandmaster settings of each ptp4l instance", func() {
+			exporter.ObserveRecord("gm", "pmc-info", &devices.PMCInfo{
+				ClockClass: 6, ClockAccuracy: "0x21", CurrentUtcOffset: 37, TimeTraceable: 1,
+			}, map[string]string{"instance": "ptp4l.0", "interfaces": "ens7f0,ens7f1"})
+			exporter.ObserveRecord("gm", "pmc-info", &devices.PMCInfo{
+				ClockClass: 248, ClockAccuracy: "0xfe", CurrentUtcOffset: 37,
+			}, map[string]string{"instance": "ptp4l.1", "interfaces": "ens5f0"})
+			body := scrape(exporter)
+			Expect(body).To(ContainSubstring(
+				`vse_sync_pmc_clock_class{cluster="gm",instance="ptp4l.0",interfaces="ens7f0,ens7f1"} 6`))
+			Expect(body).To(ContainSubstring(
+				`vse_sync_pmc_clock_class{cluster="gm",instance="ptp4l.1",interfaces="ens5f0"} 248`))
+			Expect(body).To(ContainSubstring(
+				`vse_sync_pmc_clock_accuracy{cluster="gm",instance="ptp4l.0",interfaces="ens7f0,ens7f1"} 33`))
+			Expect(body).To(ContainSubstring(
+				`vse_sync_pmc_current_utc_offset_seconds{cluster="gm",instance="ptp4l.0",interfaces="ens7f0,ens7f1"} 37`))
+		})
+	})
+	When("polls are observed", func() {
+		It("should export the health of the collector", func() {
+			exporter.ObservePoll("gm", "GNSS", "healthy", false, 0)
+			exporter.ObservePoll("gm", "GNSS", "failing", true, 1)
+			body := scrape(exporter)
+			Expect(body).To(ContainSubstring(`vse_sync_collector_polls_total{cluster="gm",collector="GNSS"} 2`))
+			Expect(body).To(ContainSubstring(`vse_sync_collector_poll_errors_total{cluster="gm",collector="GNSS"} 1`))
+			Expect(body).To(ContainSubstring(`vse_sync_collector_state{cluster="gm",collector="GNSS",state="failing"} 1`))
+			Expect(body).To(ContainSubstring(`vse_sync_collector_state{cluster="gm",collector="GNSS",state="healthy"} 0`))
+		})
+	})
+})
+
+func TestMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Metrics Suite")
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors"
+)
+
+// Result counts what happened to each capture which was replayed
+type Result struct {
+	// Replayed holds the number of captures which were re-processed for each collector
+	Replayed map[string]int
+	// Failed is the number of captures which could not be re-processed
+	Failed int
+	// FailedOnNode is the number of captures where the command had failed when it was run
+	FailedOnNode int
+	// Unrecognised is the number of captures which were not run by any of the collectors
+	Unrecognised int
+}
+
+// Replay reads captured outputs from reader and passes the records the collectors
+// would have written for them to the callback. Captures which fail to be processed
+// are logged and skipped so that the rest of the run can still be regenerated.
+func Replay(
+	ctx context.Context,
+	reader io.Reader,
+	ptpInterfaces []string,
+	callback callbacks.Callback,
+) (*Result, error) {
+	replayer, err := collectors.NewCaptureReplayer(ptpInterfaces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up replay: %w", err)
+	}
+	result := &Result{Replayed: make(map[string]int)}
+	captures := clients.NewCaptureReader(reader)
+	for {
+		capture, err := captures.Next()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return result, err //nolint:wrapcheck // this returns a wrapped error
+		}
+		if capture.Error != "" {
+			log.Debugf("skipping capture from %s which failed on the node: %s", capture.Time, capture.Error)
+			result.FailedOnNode++
+			continue
+		}
+		collectorName, err := replayer.Replay(ctx, capture, callback)
+		switch {
+		case err != nil:
+			log.Warn(err)
+			result.Failed++
+		case collectorName == "":
+			log.Debugf("capture from %s was not run by a collector: %v", capture.Time, capture.Command)
+			result.Unrecognised++
+		default:
+			result.Replayed[collectorName]++
+		}
+	}
+}
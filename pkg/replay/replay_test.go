@@ -0,0 +1,353 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package replay_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/replay"
+)
+
+type testFile struct {
+	bytes.Buffer
+}
+
+func (t *testFile) Close() error {
+	return nil
+}
+
+const pmcOutput = `<date>
+1686916187.0584
+</date>
+<PMC>
+sending: GET GRANDMASTER_SETTINGS_NP
+	507c6f.fffe.30fbe8-0 seq 0 RESPONSE MANAGEMENT GRANDMASTER_SETTINGS_NP
+		clockClass              6
+		clockAccuracy           0x21
+		offsetScaledLogVariance 0x4e5d
+		currentUtcOffset        37
+		leap61                  0
+		leap59                  0
+		currentUtcOffsetValid   1
+		ptpTimescale            1
+		timeTraceable           1
+		frequencyTraceable      1
+		timeSource              0x20
+</PMC>
+<dataSets>
+sending: GET PARENT_DATA_SET
+	507c6f.fffe.30fbe8-0 seq 0 RESPONSE MANAGEMENT PARENT_DATA_SET
+		parentPortIdentity                    507c6f.fffe.1fb1f0-1
+		parentStats                           0
+		observedParentOffsetScaledLogVariance 0xffff
+		observedParentClockPhaseChangeRate    0x7fffffff
+		grandmasterPriority1                  128
+		gm.ClockClass                         6
+		gm.ClockAccuracy                      0x21
+		gm.OffsetScaledLogVariance            0x4e5d
+		grandmasterPriority2                  128
+		grandmasterIdentity                   507c6f.fffe.1fb1f0
+sending: GET CURRENT_DATA_SET
+	507c6f.fffe.30fbe8-0 seq 1 RESPONSE MANAGEMENT CURRENT_DATA_SET
+		stepsRemoved     1
+		offsetFromMaster -2.0
+		meanPathDelay    612.0
+sending: GET TIME_STATUS_NP
+	507c6f.fffe.30fbe8-0 seq 2 RESPONSE MANAGEMENT TIME_STATUS_NP
+		master_offset              -2
+		ingress_time               1686916187058400000
+		cumulativeScaledRateOffset +0.000000000
+		scaledLastGmPhaseChange    0
+		gmTimeBaseIndicator        0
+		lastGmPhaseChange          0x0000'0000000000000000.0000
+		gmPresent                  true
+		gmIdentity                 507c6f.fffe.1fb1f0
+sending: GET PORT_DATA_SET
+	507c6f.fffe.30fbe8-1 seq 3 RESPONSE MANAGEMENT PORT_DATA_SET
+		portIdentity            507c6f.fffe.30fbe8-1
+		portState               SLAVE
+		logMinDelayReqInterval  -4
+		peerMeanPathDelay       0
+		logAnnounceInterval     -3
+		announceReceiptTimeout  3
+		logSyncInterval         -4
+		delayMechanism          1
+		logMinPdelayReqInterval -4
+		versionNumber           2
+sending: GET TIME_PROPERTIES_DATA_SET
+	507c6f.fffe.30fbe8-0 seq 4 RESPONSE MANAGEMENT TIME_PROPERTIES_DATA_SET
+		currentUtcOffset      37
+		leap61                0
+		leap59                0
+		currentUtcOffsetValid 1
+		ptpTimescale          1
+		timeTraceable         1
+		frequencyTraceable    1
+		timeSource            0x20
+</dataSets>
+`
+
+const dpllOutput = `<date>
+1686916187.0584
+</date>
+<dpll_0_state>
+2
+</dpll_0_state>
+<dpll_1_state>
+3
+</dpll_1_state>
+<dpll_1_offset>
+-34
+</dpll_1_offset>
+`
+
+func writeCaptures(captures ...*clients.CapturedExec) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	for _, capture := range captures {
+		Expect(encoder.Encode(capture)).To(Succeed())
+	}
+	return buf
+}
+
+var _ = Describe("Replay", func() {
+	var output *testFile
+	var callback callbacks.Callback
+
+	BeforeEach(func() {
+		output = &testFile{}
+		callback = callbacks.NewFileCallback(output, callbacks.AnalyserJSON)
+	})
+
+	When("captures of the collector scripts are replayed", func() {
+		It("should write the records the collectors would have written", func() {
+			dpllScript, err := devices.GetDevDPLLFilesystemCommand("ens7f0")
+			Expect(err).NotTo(HaveOccurred())
+			pmcScript, err := devices.GetPMCCommand(devices.DefaultPTP4lConfig)
+			Expect(err).NotTo(HaveOccurred())
+			captures := writeCaptures(
+				&clients.CapturedExec{Command: []string{"/usr/bin/sh"}, Stdin: pmcScript, Stdout: pmcOutput},
+				&clients.CapturedExec{Command: []string{"/usr/bin/sh"}, Stdin: dpllScript, Stdout: dpllOutput},
+			)
+
+			result, err := replay.Replay(context.Background(), captures, []string{"ens7f0"}, callback)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Replayed).To(Equal(map[string]int{"PMC": 1, "DPLL": 1}))
+
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+			Expect(lines).To(HaveLen(7))
+			Expect(lines[0]).To(ContainSubstring(`"id":"phc/gm-settings"`))
+			Expect(lines[0]).To(ContainSubstring(`"clock_class":6`))
+			Expect(lines[1]).To(ContainSubstring(`"id":"ptp4l/parent-data-set"`))
+			Expect(lines[2]).To(ContainSubstring(`"id":"ptp4l/current-data-set"`))
+			Expect(lines[3]).To(ContainSubstring(`"id":"ptp4l/time-status"`))
+			Expect(lines[4]).To(ContainSubstring(`"id":"ptp4l/time-properties"`))
+			Expect(lines[5]).To(ContainSubstring(`"id":"ptp4l/port-data-set"`))
+			Expect(lines[5]).To(ContainSubstring(`"portState":"SLAVE"`))
+			Expect(lines[6]).To(ContainSubstring(`"id":"dpll/time-error"`))
+			Expect(lines[6]).To(ContainSubstring(`"tags":{"interface":"ens7f0"}`))
+		})
+		It("should tag the PMC records with the ptp4l instance found when the collector started", func() {
+			instancesScript, err := devices.GetPTP4lInstancesCommand()
+			Expect(err).NotTo(HaveOccurred())
+			pmcScript, err := devices.GetPMCCommand("/var/run/ptp4l.1.config")
+			Expect(err).NotTo(HaveOccurred())
+			captures := writeCaptures(
+				&clients.CapturedExec{
+					Command: []string{"/usr/bin/sh"},
+					Stdin:   instancesScript,
+					Stdout: "<configs>\n/var/run/ptp4l.0.config\n[global]\n[ens7f0]\n" +
+						"/var/run/ptp4l.1.config\n[global]\n[ens5f0]\n[ens5f1]\n</configs>\n",
+				},
+				&clients.CapturedExec{Command: []string{"/usr/bin/sh"}, Stdin: pmcScript, Stdout: pmcOutput},
+			)
+
+			result, err := replay.Replay(context.Background(), captures, []string{}, callback)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Replayed).To(Equal(map[string]int{"PMC": 2}))
+
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+			Expect(lines).To(HaveLen(6))
+			for _, line := range lines {
+				Expect(line).To(ContainSubstring(`"tags":{"instance":"ptp4l.1","interfaces":"ens5f0,ens5f1"}`))
+			}
+		})
+	})
+	When("captures of older PMC scripts are replayed", func() {
+		It("should write the grandmaster settings of captures which only queried them", func() {
+			legacyScript := "echo '<date>';date +%s.%N;echo '</date>';" +
+				"echo '<PMC>';pmc -u -f /var/run/ptp4l.0.config  'GET GRANDMASTER_SETTINGS_NP';echo '</PMC>';"
+			legacyOutput, _, _ := strings.Cut(pmcOutput, "<dataSets>")
+			captures := writeCaptures(
+				&clients.CapturedExec{Command: []string{"/usr/bin/sh"}, Stdin: legacyScript, Stdout: legacyOutput},
+			)
+
+			result, err := replay.Replay(context.Background(), captures, []string{}, callback)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Replayed).To(Equal(map[string]int{"PMC": 1}))
+			Expect(result.Unrecognised).To(BeZero())
+
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+			Expect(lines).To(HaveLen(1))
+			Expect(lines[0]).To(ContainSubstring(`"id":"phc/gm-settings"`))
+			Expect(lines[0]).To(ContainSubstring(`"clock_class":6`))
+		})
+	})
+	When("a capture of the PMC script is missing some of the data sets", func() {
+		It("should write those which were answered", func() {
+			pmcScript, err := devices.GetPMCCommand(devices.DefaultPTP4lConfig)
+			Expect(err).NotTo(HaveOccurred())
+			gmOnly, _, _ := strings.Cut(pmcOutput, "<dataSets>")
+			captures := writeCaptures(&clients.CapturedExec{
+				Command: []string{"/usr/bin/sh"},
+				Stdin:   pmcScript,
+				Stdout:  gmOnly + "<dataSets>\nsending: GET PARENT_DATA_SET\n</dataSets>\n",
+			})
+
+			result, err := replay.Replay(context.Background(), captures, []string{}, callback)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Replayed).To(Equal(map[string]int{"PMC": 1}))
+
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+			Expect(lines).To(HaveLen(1))
+			Expect(lines[0]).To(ContainSubstring(`"id":"phc/gm-settings"`))
+		})
+	})
+	When("captures of the netlink scripts are replayed", func() {
+		It("should only use the pod to pick the interface if the capture was made in one", func() {
+			clockIDScript, err := devices.GetClockIDCommand("ens7f0")
+			Expect(err).NotTo(HaveOccurred())
+			clockIDOutput := "<date>\n1686916187.0584\n</date>\n" +
+				"<dpll-netlink-serial-number>\n507c6fffff1fb1f0\n</dpll-netlink-serial-number>\n"
+			captures := writeCaptures(
+				&clients.CapturedExec{
+					Command: []string{"/usr/bin/sh"},
+					Stdin:   clockIDScript,
+					Stdout:  clockIDOutput,
+					Pod:     contexts.GetNetlinkPodName("ens7f0"),
+				},
+				&clients.CapturedExec{Command: []string{"/usr/bin/sh"}, Stdin: clockIDScript, Stdout: clockIDOutput},
+				&clients.CapturedExec{
+					Command: []string{"/usr/bin/sh"},
+					Stdin:   clockIDScript,
+					Stdout:  clockIDOutput,
+					Pod:     contexts.GetNetlinkPodName("ens5f0"),
+				},
+			)
+
+			result, err := replay.Replay(context.Background(), captures, []string{"ens7f0"}, callback)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Replayed).To(Equal(map[string]int{"DPLL": 2}))
+			Expect(result.Unrecognised).To(Equal(1))
+			Expect(result.Failed).To(BeZero())
+		})
+	})
+	When("netlink captures are replayed for more than one interface", func() {
+		var clockIDCaptures func(pod bool) []*clients.CapturedExec
+		var netlinkScript, netlinkOutput string
+		BeforeEach(func() {
+			var err error
+			netlinkScript, err = devices.GetDevDPLLNetlinkCommand()
+			Expect(err).NotTo(HaveOccurred())
+			netlinkOutput = "<date>\n1686916187.0584\n</date>\n<dpll-netlink>\n" +
+				"[{'clock-id': 5799633565433967088, 'id': 0, 'lock-status': 'locked', 'type': 'pps'}, " +
+				"{'clock-id': 5799633565433967089, 'id': 1, 'lock-status': 'holdover', 'type': 'pps'}]" +
+				"\n</dpll-netlink>\n"
+			clockIDCaptures = func(pod bool) []*clients.CapturedExec {
+				captures := make([]*clients.CapturedExec, 0)
+				for ptpInterface, serial := range map[string]string{"ens7f0": "507c6fffff1fb1f0", "ens5f0": "507c6fffff1fb1f1"} {
+					script, err := devices.GetClockIDCommand(ptpInterface)
+					Expect(err).NotTo(HaveOccurred())
+					capture := &clients.CapturedExec{
+						Command: []string{"/usr/bin/sh"},
+						Stdin:   script,
+						Stdout: "<date>\n1686916187.0584\n</date>\n" +
+							"<dpll-netlink-serial-number>\n" + serial + "\n</dpll-netlink-serial-number>\n",
+					}
+					if pod {
+						capture.Interface = ptpInterface
+					}
+					captures = append(captures, capture)
+				}
+				return captures
+			}
+		})
+		It("should use the clock ID of the interface recorded with each capture", func() {
+			captures := clockIDCaptures(true)
+			for _, ptpInterface := range []string{"ens7f0", "ens5f0"} {
+				captures = append(captures, &clients.CapturedExec{
+					Command:   []string{"/usr/bin/sh"},
+					Stdin:     netlinkScript,
+					Stdout:    netlinkOutput,
+					Interface: ptpInterface,
+				})
+			}
+
+			result, err := replay.Replay(context.Background(), writeCaptures(captures...), []string{"ens7f0", "ens5f0"}, callback)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Replayed).To(Equal(map[string]int{"DPLL": 4}))
+			Expect(result.Failed).To(BeZero())
+
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]).To(ContainSubstring(`"state":"2"`))
+			Expect(lines[0]).To(ContainSubstring(`"tags":{"interface":"ens7f0"}`))
+			Expect(lines[1]).To(ContainSubstring(`"state":"4"`))
+			Expect(lines[1]).To(ContainSubstring(`"tags":{"interface":"ens5f0"}`))
+		})
+		It("should fail captures which do not record the interface or pod", func() {
+			captures := append(clockIDCaptures(false), &clients.CapturedExec{
+				Command: []string{"/usr/bin/sh"},
+				Stdin:   netlinkScript,
+				Stdout:  netlinkOutput,
+			})
+
+			result, err := replay.Replay(context.Background(), writeCaptures(captures...), []string{"ens7f0", "ens5f0"}, callback)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Replayed).To(Equal(map[string]int{"DPLL": 2}))
+			Expect(result.Failed).To(Equal(1))
+			Expect(output.String()).To(BeEmpty())
+		})
+	})
+	When("captures were not run by a collector or failed", func() {
+		It("should skip them", func() {
+			pmcScript, err := devices.GetPMCCommand(devices.DefaultPTP4lConfig)
+			Expect(err).NotTo(HaveOccurred())
+			captures := writeCaptures(
+				&clients.CapturedExec{Command: []string{"/usr/bin/sh"}, Stdin: "echo '<x>';ls;echo '</x>';", Stdout: "<x>\n\n</x>\n"},
+				&clients.CapturedExec{Command: []string{"/usr/bin/sh"}, Stdin: pmcScript, Error: "timed out"},
+				&clients.CapturedExec{Command: []string{"/usr/bin/sh"}, Stdin: pmcScript, Stdout: "garbage"},
+			)
+
+			result, err := replay.Replay(context.Background(), captures, []string{}, callback)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Replayed).To(BeEmpty())
+			Expect(result.Unrecognised).To(Equal(1))
+			Expect(result.FailedOnNode).To(Equal(1))
+			Expect(result.Failed).To(Equal(1))
+			Expect(output.String()).To(BeEmpty())
+		})
+	})
+	When("the capture file is not valid", func() {
+		It("should return an error", func() {
+			_, err := replay.Replay(context.Background(), strings.NewReader("{not json"), []string{}, callback)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+func TestReplay(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Replay Suite")
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
+)
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// resolveClusterNames gives every cluster without a name one based on the
+// current context of its kubeconfig, making sure each name is unique.
+func resolveClusterNames(clusters []ClusterConfig) {
+	used := make(map[string]bool)
+	for _, cluster := range clusters {
+		if cluster.Name != "" {
+			used[cluster.Name] = true
+		}
+	}
+	for i := range clusters {
+		if clusters[i].Name != "" {
+			continue
+		}
+		name, err := clients.GetClusterName(clusters[i].KubeConfig)
+		if err != nil {
+			log.Warnf("could not find cluster name: %s", err.Error())
+			name = "cluster"
+		}
+		// Names are used in file paths so strip anything awkward such as the ':' in "api-host:6443"
+		name = strings.Trim(unsafeNameChars.ReplaceAllString(name, "-"), "-")
+		uniqueName := name
+		for suffix := 1; used[uniqueName]; suffix++ {
+			uniqueName = fmt.Sprintf("%s-%d", name, suffix)
+		}
+		used[uniqueName] = true
+		clusters[i].Name = uniqueName
+	}
+}
+
+// addSuffixToFilename inserts suffix between the name and extension of filename
+func addSuffixToFilename(filename, suffix string) string {
+	if filename == "" {
+		return filename
+	}
+	ext := filepath.Ext(filename)
+	return fmt.Sprintf("%s.%s%s", strings.TrimSuffix(filename, ext), suffix, ext)
+}
+
+// Run collects from every cluster in the run config until the requested duration has passed,
+// a stop condition is met or the process is interrupted. Any error exits the process.
+func Run(runCfg *RunConfig) {
+	// Allow ourselves to handle shut down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	collection, err := NewCollection(runCfg)
+	utils.IfErrorExitOrPanic(err)
+	err = collection.Run(ctx)
+	utils.IfErrorExitOrPanic(err)
+}
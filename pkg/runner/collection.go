@@ -0,0 +1,306 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/metrics"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
+)
+
+const (
+	clusterTempDirPerm = 0755
+)
+
+// ClusterStatus describes the health of each collector running against a cluster
+type ClusterStatus struct {
+	Collectors map[string]CollectorHealth `json:"collectors"`
+	Name       string                     `json:"name,omitempty"`
+	NodeName   string                     `json:"node"`
+}
+
+// CollectionStatus describes the progress of a Collection
+type CollectionStatus struct {
+	StartTime time.Time       `json:"startTime"`
+	EndTime   time.Time       `json:"endTime"`
+	Records   map[string]int  `json:"records"`
+	Summary   *RunSummary     `json:"summary,omitempty"`
+	StoppedBy string          `json:"stoppedBy,omitempty"`
+	Clusters  []ClusterStatus `json:"clusters"`
+	Running   bool            `json:"running"`
+}
+
+// Collection is a single collection run which creates a CollectorRunner for each
+// cluster in the run config and runs them at the same time. All clusters write to the
+// same output, when there is more than one cluster each record is tagged with the name
+// of the cluster it came from and the logs output file and temp dir are split per cluster.
+type Collection struct {
+	startTime       time.Time
+	endTime         time.Time
+	config          *RunConfig
+	registry        *collectors.CollectorRegistry
+	callback        *callbacks.CountingCallback
+	stopper         *stopper
+	metrics         *metrics.Exporter
+	metricsListener net.Listener
+	summaryOut      io.Writer
+	clusters        []ClusterConfig
+	runners         []*CollectorRunner
+	lock            sync.Mutex
+	started         bool
+	running         bool
+}
+
+// NewCollection opens the output and connects to each cluster in the run config
+func NewCollection(runCfg *RunConfig) (*Collection, error) {
+	stopConditions, err := runCfg.getStopConditions()
+	if err != nil {
+		return nil, err
+	}
+	registry, err := runCfg.getRegistry()
+	if err != nil {
+		return nil, err
+	}
+	clusters := runCfg.GetClusters()
+	resolveClusterNames(clusters)
+
+	outputFormat := callbacks.Raw
+	if runCfg.Output.UseAnalyserJSON {
+		outputFormat = callbacks.AnalyserJSON
+	}
+	fileCallback, err := callbacks.SetupCallback(runCfg.Output.File, outputFormat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output: %w", err)
+	}
+	collection := &Collection{
+		config:     runCfg,
+		registry:   registry,
+		summaryOut: os.Stdout,
+		callback:   callbacks.NewCountingCallback(fileCallback),
+		stopper:    newStopper(stopConditions, nil),
+		clusters:   clusters,
+		runners:    make([]*CollectorRunner, 0, len(clusters)),
+	}
+
+	if runCfg.Output.File == "" || runCfg.Output.File == "-" {
+		// The summary record is already on stdout so don't mix a table in with the output
+		collection.summaryOut = nil
+	}
+	err = collection.setup()
+	if err != nil {
+		if cleanUpErr := collection.callback.CleanUp(); cleanUpErr != nil {
+			log.Errorf("failed to close output: %s", cleanUpErr.Error())
+		}
+		if collection.metricsListener != nil {
+			collection.metricsListener.Close()
+		}
+		return nil, err
+	}
+	return collection, nil
+}
+
+// setup opens the metrics listener if one was requested and connects to each cluster
+func (collection *Collection) setup() error {
+	if collection.config.MetricsListen != "" {
+		listener, err := net.Listen("tcp", collection.config.MetricsListen)
+		if err != nil {
+			return fmt.Errorf("failed to listen for metrics: %w", err)
+		}
+		collection.metricsListener = listener
+		collection.metrics = metrics.NewExporter()
+	}
+	for i := range collection.clusters {
+		err := collection.addRunner(&collection.clusters[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRunner creates and connects the CollectorRunner for a cluster
+func (collection *Collection) addRunner(cluster *ClusterConfig) error {
+	runCfg := collection.config
+	collectionRunner := NewCollectorRunner(runCfg, cluster, collection.registry)
+	collectionRunner.stopper = collection.stopper
+	collectionRunner.metrics = collection.metrics
+	if len(collection.clusters) > 1 {
+		collectionRunner.logsOutputFile = addSuffixToFilename(runCfg.Output.LogsFile, cluster.Name)
+		collectionRunner.captureFile = addSuffixToFilename(runCfg.Output.CaptureFile, cluster.Name)
+		collectionRunner.tempDir = filepath.Join(runCfg.TempDir, cluster.Name)
+		err := os.MkdirAll(collectionRunner.tempDir, clusterTempDirPerm)
+		if err != nil {
+			return fmt.Errorf("failed to create temp dir for cluster %s: %w", cluster.Name, err)
+		}
+	}
+	err := collectionRunner.connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to cluster %s: %w", cluster.Name, err)
+	}
+	collection.runners = append(collection.runners, collectionRunner)
+	return nil
+}
+
+// Run collects from every cluster until the requested duration has passed, a stop
+// condition is met or ctx is cancelled. The output starts with a RunManifest and
+// finishes with a RunEnd record and is closed once the run has finished.
+// A Collection can only be run once.
+func (collection *Collection) Run(ctx context.Context) error {
+	collection.lock.Lock()
+	if collection.started {
+		collection.lock.Unlock()
+		return errors.New("collection has already been run")
+	}
+	collection.started = true
+	collection.running = true
+	collection.startTime = time.Now()
+	collection.lock.Unlock()
+
+	// The stop conditions end the run early by cancelling runCtx
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	collection.stopper.cancel = cancel
+
+	if collection.metrics != nil {
+		// Keep serving the metrics until everything has been written
+		metricsCtx, stopMetrics := context.WithCancel(context.Background())
+		defer stopMetrics()
+		go func() {
+			if err := collection.metrics.Serve(metricsCtx, collection.metricsListener); err != nil {
+				log.Error(err)
+			}
+		}()
+	}
+
+	callback := collection.callback
+	collectorNames := collection.config.getCollectorNames(collection.registry)
+	manifest := newRunManifest(collection.config, collectorNames, collection.clusters, collection.startTime)
+	err := callback.Call(manifest, RunManifestTag)
+	if err != nil {
+		return fmt.Errorf("failed to write run manifest: %w", err)
+	}
+
+	runErrors := make([]error, 0)
+	var errorsLock sync.Mutex
+	var wg sync.WaitGroup
+	for _, collectionRunner := range collection.runners {
+		var clusterCallback callbacks.Callback = callbacks.NewObservingCallback(callback, collectionRunner.observeRecord)
+		if len(collection.runners) > 1 {
+			clusterCallback = callbacks.NewTaggedCallback(
+				clusterCallback, map[string]string{"cluster": collectionRunner.cluster.Name},
+			)
+		}
+		log.Infof("Starting collection from cluster %s", collectionRunner.cluster.Name)
+		collectionRunner := collectionRunner
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := collectionRunner.Run(runCtx, clusterCallback); err != nil {
+				// Without every cluster the run is not worth continuing
+				cancel()
+				errorsLock.Lock()
+				defer errorsLock.Unlock()
+				runErrors = append(runErrors, fmt.Errorf("cluster %s: %w", collectionRunner.cluster.Name, err))
+			}
+		}()
+	}
+	wg.Wait()
+
+	collection.lock.Lock()
+	collection.running = false
+	collection.endTime = time.Now()
+	collection.lock.Unlock()
+
+	runEnd := &RunEnd{
+		StartTime:   collection.startTime,
+		EndTime:     collection.endTime,
+		Duration:    collection.endTime.Sub(collection.startTime).String(),
+		Records:     callback.GetCounts(),
+		StoppedBy:   collection.stopper.getReason(),
+		Interrupted: ctx.Err() != nil,
+		Clusters:    make([]ClusterPollCounts, 0, len(collection.runners)),
+	}
+	for _, collectionRunner := range collection.runners {
+		runEnd.Clusters = append(runEnd.Clusters, collectionRunner.getPollCounts())
+	}
+	err = callback.Call(runEnd, RunEndTag)
+	if err != nil {
+		runErrors = append(runErrors, fmt.Errorf("failed to write run end: %w", err))
+	}
+	summary := collection.getSummary()
+	err = callback.Call(summary, RunSummaryTag)
+	if err != nil {
+		runErrors = append(runErrors, fmt.Errorf("failed to write run summary: %w", err))
+	}
+	if collection.summaryOut != nil {
+		if err = summary.Write(collection.summaryOut); err != nil {
+			log.Error(err)
+		}
+	}
+	err = callback.CleanUp()
+	if err != nil {
+		runErrors = append(runErrors, fmt.Errorf("failed to close output: %w", err))
+	}
+	if len(runErrors) > 0 {
+		return utils.MakeCompositeError("collection failed", runErrors)
+	}
+	return nil
+}
+
+// SetSummaryWriter sets where the human readable summary is written at the end of the run,
+// nil stops it being written. By default it is written to stdout unless the output is.
+func (collection *Collection) SetSummaryWriter(out io.Writer) {
+	collection.summaryOut = out
+}
+
+// getSummary returns a summary of the run so far
+func (collection *Collection) getSummary() *RunSummary {
+	collection.lock.Lock()
+	endTime := collection.endTime
+	if collection.running {
+		endTime = time.Now()
+	}
+	summary := &RunSummary{
+		Duration: endTime.Sub(collection.startTime).String(),
+		Clusters: make([]*ClusterSummary, 0, len(collection.runners)),
+	}
+	collection.lock.Unlock()
+	for _, collectionRunner := range collection.runners {
+		summary.Clusters = append(summary.Clusters, collectionRunner.summary.getSummary(collectionRunner.cluster.Name))
+	}
+	return summary
+}
+
+// GetStatus returns the progress of the collection and the health of each collector
+func (collection *Collection) GetStatus() *CollectionStatus {
+	collection.lock.Lock()
+	status := &CollectionStatus{
+		StartTime: collection.startTime,
+		EndTime:   collection.endTime,
+		Running:   collection.running,
+		Records:   collection.callback.GetCounts(),
+		StoppedBy: collection.stopper.getReason(),
+		Clusters:  make([]ClusterStatus, 0, len(collection.runners)),
+	}
+	collection.lock.Unlock()
+	if status.Running || !status.EndTime.IsZero() {
+		status.Summary = collection.getSummary()
+	}
+	for _, collectionRunner := range collection.runners {
+		status.Clusters = append(status.Clusters, collectionRunner.getStatus())
+	}
+	return status
+}
@@ -31,6 +31,22 @@ func isIn(name string, arr []string) bool {
 	return false
 }
 
+// isInFold is isIn ignoring case, as used when matching the collector names given by the user
+func isInFold(name string, arr []string) bool {
+	_, found := findInFold(name, arr)
+	return found
+}
+
+// findInFold returns the value in arr which matches name ignoring case
+func findInFold(name string, arr []string) (string, bool) {
+	for _, arrVal := range arr {
+		if strings.EqualFold(name, arrVal) {
+			return arrVal, true
+		}
+	}
+	return "", false
+}
+
 func removeDuplicates(arr []string) []string {
 	res := make([]string, 0)
 	for _, name := range arr {
@@ -42,20 +58,21 @@ func removeDuplicates(arr []string) []string {
 }
 
 // GetCollectorsToRun returns a slice containing the names of the
-// collectors to be run it will enfore that required colletors
+// collectors in registry to be run it will enfore that required colletors
 // are returned
-func GetCollectorsToRun(selectedCollectors []string) []string {
+func GetCollectorsToRun(registry *collectors.CollectorRegistry, selectedCollectors []string) []string {
+	optionalNames := registry.GetOptionalNames()
 	collectorNames := make([]string, 0)
-	collectorNames = append(collectorNames, RequiredCollectorNames...)
+	collectorNames = append(collectorNames, registry.GetRequiredNames()...)
 	for _, name := range selectedCollectors {
 		switch {
 		case strings.EqualFold(name, "all"):
-			collectorNames = append(collectorNames, OptionalCollectorNames...)
+			collectorNames = append(collectorNames, optionalNames...)
 		case strings.EqualFold(name, "defaults"):
-			collectorNames = append(collectorNames, OptionalCollectorNames...)
+			collectorNames = append(collectorNames, optionalNames...)
 		case isIn(name, collectorNames):
 			continue
-		case isIn(name, OptionalCollectorNames):
+		case isIn(name, optionalNames):
 			collectorNames = append(collectorNames, name)
 		default:
 			log.Errorf("Unknown collector %s. Ignored", name)
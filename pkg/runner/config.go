@@ -0,0 +1,407 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
+)
+
+// CollectorSettings holds the values which can be set for an individual collector.
+// The Options a collector accepts are listed by collectors describe.
+type CollectorSettings struct {
+	Options      map[string]string `json:"options,omitempty"`
+	PollInterval int               `json:"pollInterval,omitempty"`
+	PollTimeout  int               `json:"pollTimeout,omitempty"`
+}
+
+// OutputConfig describes where the collected data should be written to
+type OutputConfig struct {
+	File                 string `json:"file,omitempty"`
+	LogsFile             string `json:"logsFile,omitempty"`
+	CaptureFile          string `json:"captureFile,omitempty"`
+	UseAnalyserJSON      bool   `json:"analyserFormat,omitempty"`
+	IncludeLogTimestamps bool   `json:"logTimestamps,omitempty"`
+}
+
+// ClusterConfig describes a single cluster to collect from.
+// Any values which are not set are taken from the top level of the RunConfig.
+type ClusterConfig struct {
+	Name          string   `json:"name,omitempty"`
+	KubeConfig    string   `json:"kubeconfig,omitempty"`
+	NodeName      string   `json:"node,omitempty"`
+	PTPInterfaces []string `json:"interfaces,omitempty"`
+}
+
+// RunConfig describes a collection run. It can be loaded from a YAML or JSON
+// run profile and then have command line flags layered on top of it.
+// PTPInterface holds the single interface key of older run profiles, it is moved into PTPInterfaces when parsed.
+type RunConfig struct {
+	CollectorSettings       map[string]*CollectorSettings `json:"collectorSettings,omitempty"`
+	Clusters                []ClusterConfig               `json:"clusters,omitempty"`
+	Output                  OutputConfig                  `json:"output"`
+	KubeConfig              string                        `json:"kubeconfig,omitempty"`
+	NodeName                string                        `json:"node,omitempty"`
+	Duration                string                        `json:"duration,omitempty"`
+	TempDir                 string                        `json:"tempDir,omitempty"`
+	MetricsListen           string                        `json:"metricsListen,omitempty"`
+	SSH                     string                        `json:"ssh,omitempty"`
+	SSHIdentityFile         string                        `json:"sshIdentityFile,omitempty"`
+	SSHKnownHostsFile       string                        `json:"sshKnownHostsFile,omitempty"`
+	PTPInterface            string                        `json:"interface,omitempty"`
+	PTPInterfaces           []string                      `json:"interfaces,omitempty"`
+	Collectors              []string                      `json:"collectors,omitempty"`
+	CollectorSpecs          []string                      `json:"collectorSpecs,omitempty"`
+	StopOn                  []string                      `json:"stopOn,omitempty"`
+	PollInterval            int                           `json:"pollInterval,omitempty"`
+	PollTimeout             int                           `json:"pollTimeout,omitempty"`
+	MaxBackoff              int                           `json:"maxBackoff,omitempty"`
+	DisableAfterFailures    int                           `json:"disableAfterFailures,omitempty"`
+	DevInfoAnnounceInterval int                           `json:"announceInterval,omitempty"`
+	KeepDebugFiles          bool                          `json:"keepDebugFiles,omitempty"`
+	PersistentSessions      bool                          `json:"persistentSessions,omitempty"`
+	Local                   bool                          `json:"local,omitempty"`
+}
+
+// localClusterName is the name given to the host when collecting locally
+const localClusterName = "local"
+
+// LoadRunConfig reads a YAML or JSON run profile from path on top of the values already in runCfg.
+// Any values not present in the file are left untouched.
+func LoadRunConfig(path string, runCfg *RunConfig) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return utils.NewMissingInputError(fmt.Errorf("failed to read run config %s: %w", path, err))
+	}
+	err = ParseRunConfig(content, runCfg)
+	if err != nil {
+		return utils.NewMissingInputError(fmt.Errorf("failed to parse run config %s: %w", path, err))
+	}
+	return nil
+}
+
+// ParseRunConfig reads a YAML or JSON run profile on top of the values already in runCfg
+func ParseRunConfig(content []byte, runCfg *RunConfig) error {
+	err := yaml.UnmarshalStrict(content, runCfg)
+	if err != nil {
+		return fmt.Errorf("invalid run config: %w", err)
+	}
+	if runCfg.PTPInterface != "" {
+		runCfg.addLegacyInterface()
+	}
+	return nil
+}
+
+// addLegacyInterface moves the interface of an older run profile in front of the other interfaces
+func (runCfg *RunConfig) addLegacyInterface() {
+	legacy := runCfg.PTPInterface
+	runCfg.PTPInterface = ""
+	for _, ptpInterface := range runCfg.PTPInterfaces {
+		if ptpInterface == legacy {
+			return
+		}
+	}
+	runCfg.PTPInterfaces = append([]string{legacy}, runCfg.PTPInterfaces...)
+}
+
+// GetDuration returns the requested duration of the run
+func (runCfg *RunConfig) GetDuration() (time.Duration, error) {
+	requestedDuration, err := time.ParseDuration(runCfg.Duration)
+	if err != nil {
+		return requestedDuration, fmt.Errorf("failed to parse duration %s: %w", runCfg.Duration, err)
+	}
+	if requestedDuration.Nanoseconds() < 0 {
+		return requestedDuration, errors.New("requested duration must be positive")
+	}
+	return requestedDuration, nil
+}
+
+// GetCollectorSettings returns the settings for the named collector,
+// if there are none an empty settings object is returned
+func (runCfg *RunConfig) GetCollectorSettings(collectorName string) *CollectorSettings {
+	for name, settings := range runCfg.CollectorSettings {
+		if strings.EqualFold(name, collectorName) && settings != nil {
+			return settings
+		}
+	}
+	return &CollectorSettings{}
+}
+
+// GetPollTimeout returns the longest a single poll of the named collector is allowed to take,
+// zero means there is no limit
+func (runCfg *RunConfig) GetPollTimeout(collectorName string) time.Duration {
+	timeout := runCfg.PollTimeout
+	if settings := runCfg.GetCollectorSettings(collectorName); settings.PollTimeout > 0 {
+		timeout = settings.PollTimeout
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// GetMaxBackoff returns the longest time a failing collector will wait between polls, zero means no limit
+func (runCfg *RunConfig) GetMaxBackoff() time.Duration {
+	return time.Duration(runCfg.MaxBackoff) * time.Second
+}
+
+// getStopConditions parses the conditions which end the run before the requested duration has passed
+func (runCfg *RunConfig) getStopConditions() ([]stopCondition, error) {
+	conditions := make([]stopCondition, 0, len(runCfg.StopOn))
+	for _, spec := range runCfg.StopOn {
+		cond, err := parseStopCondition(spec)
+		if err != nil {
+			return conditions, err
+		}
+		conditions = append(conditions, cond)
+	}
+	return conditions, nil
+}
+
+// validateStopConditions checks that the stop conditions parse and that
+// a samples condition only counts the records of a collector which will be run
+func (runCfg *RunConfig) validateStopConditions(collectorNames []string) error {
+	conditions, err := runCfg.getStopConditions()
+	if err != nil {
+		return err
+	}
+	for _, cond := range conditions {
+		samples, ok := cond.(*samplesStop)
+		if ok && !isInFold(samples.collectorName, collectorNames) {
+			return fmt.Errorf("stop condition %s counts the %s collector which is not run", samples, samples.collectorName)
+		}
+	}
+	return nil
+}
+
+// runsOnHost reports if the commands are run directly on a PTP host, either
+// locally or over SSH, rather than through the kubernetes API
+func (runCfg *RunConfig) runsOnHost() bool {
+	return runCfg.Local || runCfg.SSH != ""
+}
+
+// getHostName returns the name of the host the commands are run on
+func (runCfg *RunConfig) getHostName() string {
+	if runCfg.SSH == "" {
+		return localClusterName
+	}
+	_, host, found := strings.Cut(runCfg.SSH, "@")
+	if !found {
+		host = runCfg.SSH
+	}
+	if hostOnly, _, err := net.SplitHostPort(host); err == nil {
+		host = hostOnly
+	}
+	return strings.Trim(unsafeNameChars.ReplaceAllString(host, "-"), "-")
+}
+
+// describeHost describes where the commands of a local or ssh run are run, for other runs it is empty
+func (runCfg *RunConfig) describeHost() string {
+	switch {
+	case runCfg.Local:
+		return "this host"
+	case runCfg.SSH != "":
+		return fmt.Sprintf("%s over ssh", runCfg.SSH)
+	default:
+		return ""
+	}
+}
+
+// GetClusters returns the clusters to collect from. If no clusters are listed
+// a single cluster is described by the top level kubeconfig, node and interfaces.
+// When collecting from a host the only cluster is that host.
+func (runCfg *RunConfig) GetClusters() []ClusterConfig {
+	if runCfg.runsOnHost() {
+		return []ClusterConfig{{
+			Name:          runCfg.getHostName(),
+			NodeName:      runCfg.NodeName,
+			PTPInterfaces: runCfg.PTPInterfaces,
+		}}
+	}
+	if len(runCfg.Clusters) == 0 {
+		return []ClusterConfig{{
+			KubeConfig:    runCfg.KubeConfig,
+			NodeName:      runCfg.NodeName,
+			PTPInterfaces: runCfg.PTPInterfaces,
+		}}
+	}
+	clusters := make([]ClusterConfig, 0, len(runCfg.Clusters))
+	for _, cluster := range runCfg.Clusters {
+		if cluster.KubeConfig == "" {
+			cluster.KubeConfig = runCfg.KubeConfig
+		}
+		if cluster.NodeName == "" {
+			cluster.NodeName = runCfg.NodeName
+		}
+		if len(cluster.PTPInterfaces) == 0 {
+			cluster.PTPInterfaces = runCfg.PTPInterfaces
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+// validateClusters checks each cluster has a kubeconfig and at least one interface
+// and that no two clusters share a name. A run on a host must not name any clusters.
+func (runCfg *RunConfig) validateClusters() error {
+	if runCfg.Local && runCfg.SSH != "" {
+		return utils.NewMissingInputError(errors.New("a run can not be both local and over ssh"))
+	}
+	if runCfg.runsOnHost() && (len(runCfg.Clusters) > 0 || runCfg.KubeConfig != "") {
+		return utils.NewMissingInputError(errors.New("a local or ssh run can not also collect from clusters"))
+	}
+	names := make(map[string]bool)
+	for _, cluster := range runCfg.GetClusters() {
+		if cluster.KubeConfig == "" && !runCfg.runsOnHost() {
+			return utils.NewMissingInputError(errors.New("a kubeconfig must be provided"))
+		}
+		if len(cluster.PTPInterfaces) == 0 {
+			return utils.NewMissingInputError(errors.New("at least one PTP interface must be provided"))
+		}
+		for _, ptpInterface := range cluster.PTPInterfaces {
+			if ptpInterface == "" {
+				return utils.NewMissingInputError(errors.New("PTP interface names must not be empty"))
+			}
+		}
+		if cluster.Name == "" {
+			continue
+		}
+		if names[cluster.Name] {
+			return utils.NewMissingInputError(fmt.Errorf("cluster name %s is used more than once", cluster.Name))
+		}
+		names[cluster.Name] = true
+	}
+	return nil
+}
+
+// usesLogsCollector checks if the logs collector will be ran
+func (runCfg *RunConfig) usesLogsCollector() bool {
+	if runCfg.runsOnHost() {
+		return false
+	}
+	for _, name := range runCfg.Collectors {
+		if strings.EqualFold(name, collectors.LogsCollectorName) || strings.EqualFold(name, All) {
+			return true
+		}
+	}
+	return false
+}
+
+// kubeAPICollectors read the kubernetes API rather than running commands on the node
+var kubeAPICollectors = []string{collectors.LogsCollectorName, collectors.Ts2phcCollectorName}
+
+// selectedKubeAPICollector returns the first collector asked for by name which reads the kubernetes API
+func (runCfg *RunConfig) selectedKubeAPICollector() (string, bool) {
+	for _, name := range runCfg.Collectors {
+		for _, kubeAPICollector := range kubeAPICollectors {
+			if strings.EqualFold(name, kubeAPICollector) {
+				return kubeAPICollector, true
+			}
+		}
+	}
+	return "", false
+}
+
+// getCollectorNames returns the collectors to run. The Logs and Ts2phc collectors are left
+// out of local and ssh runs as they read the logs through the kubernetes API.
+func (runCfg *RunConfig) getCollectorNames(registry *collectors.CollectorRegistry) []string {
+	collectorNames := GetCollectorsToRun(registry, runCfg.Collectors)
+	if !runCfg.runsOnHost() {
+		return collectorNames
+	}
+	hostNames := make([]string, 0, len(collectorNames))
+	for _, name := range collectorNames {
+		if !isIn(name, kubeAPICollectors) {
+			hostNames = append(hostNames, name)
+		}
+	}
+	return hostNames
+}
+
+// getRegistry returns the collectors which can be selected for the run, the built in
+// collectors along with those declared in the collector spec files
+func (runCfg *RunConfig) getRegistry() (*collectors.CollectorRegistry, error) {
+	registry, err := collectors.LoadRegistry(runCfg.CollectorSpecs)
+	if err != nil {
+		return nil, utils.NewMissingInputError(err)
+	}
+	return registry, nil
+}
+
+// Validate checks that the combination of values is enough to start a run.
+// The collector spec files are checked but the collectors they declare are only
+// added to the registry of the run, so validating a run config changes nothing.
+func (runCfg *RunConfig) Validate() error {
+	if err := runCfg.validateClusters(); err != nil {
+		return err
+	}
+	registry, err := runCfg.getRegistry()
+	if err != nil {
+		return err
+	}
+	if runCfg.PollTimeout < 0 {
+		return utils.NewMissingInputError(errors.New("poll timeout must be positive"))
+	}
+	if runCfg.MaxBackoff < 0 {
+		return utils.NewMissingInputError(errors.New("max backoff must be positive"))
+	}
+	if runCfg.DisableAfterFailures < 0 {
+		return utils.NewMissingInputError(errors.New("disable after failures must be positive"))
+	}
+	if _, err := runCfg.GetDuration(); err != nil {
+		return utils.NewMissingInputError(err)
+	}
+	if err := runCfg.validateStopConditions(runCfg.getCollectorNames(registry)); err != nil {
+		return utils.NewMissingInputError(err)
+	}
+	if name, selected := runCfg.selectedKubeAPICollector(); selected && runCfg.runsOnHost() {
+		return utils.NewMissingInputError(
+			fmt.Errorf("the %s collector reads the kubernetes API so can not be used in a local or ssh run", name),
+		)
+	}
+	if runCfg.usesLogsCollector() && runCfg.Output.LogsFile == "" {
+		return utils.NewMissingInputError(
+			errors.New("if Logs collector is selected you must also provide a log output file"),
+		)
+	}
+	for name, settings := range runCfg.CollectorSettings {
+		if settings != nil && settings.PollInterval < 0 {
+			return utils.NewMissingInputError(fmt.Errorf("poll interval for %s must be positive", name))
+		}
+		if settings != nil && settings.PollTimeout < 0 {
+			return utils.NewMissingInputError(fmt.Errorf("poll timeout for %s must be positive", name))
+		}
+	}
+	if err := runCfg.validateCollectorOptions(registry); err != nil {
+		return utils.NewMissingInputError(err)
+	}
+	return nil
+}
+
+// validateCollectorOptions checks that options are only set for
+// collectors which accept them and to values which they accept
+func (runCfg *RunConfig) validateCollectorOptions(registry *collectors.CollectorRegistry) error {
+	for name, settings := range runCfg.CollectorSettings {
+		if settings == nil || len(settings.Options) == 0 {
+			continue
+		}
+		collectorName, found := findInFold(name, registry.GetNames())
+		if !found {
+			return fmt.Errorf("options are set for %s which is not a collector", name)
+		}
+		info, err := registry.GetInfo(collectorName)
+		if err != nil {
+			return fmt.Errorf("failed to check the options of %s: %w", name, err)
+		}
+		if err := info.CheckOptions(settings.Options); err != nil {
+			return fmt.Errorf("invalid collector settings: %w", err)
+		}
+	}
+	return nil
+}
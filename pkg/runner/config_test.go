@@ -0,0 +1,323 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/runner"
+)
+
+var _ = Describe("LoadRunConfig", func() {
+	var runCfg *runner.RunConfig
+	BeforeEach(func() {
+		runCfg = &runner.RunConfig{
+			Duration:     "1000s",
+			PollInterval: 1,
+			TempDir:      ".",
+		}
+	})
+
+	When("a valid run config file is loaded", func() {
+		It("should override the values present in the file", func() {
+			err := runner.LoadRunConfig("test_files/run.yaml", runCfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runCfg.KubeConfig).To(Equal("/path/to/kubeconfig"))
+			Expect(runCfg.PTPInterfaces).To(Equal([]string{"ens7f0", "ens8f0"}))
+			Expect(runCfg.Collectors).To(Equal([]string{"GNSS", "DPLL"}))
+			Expect(runCfg.Output.File).To(Equal("output.json"))
+			Expect(runCfg.Output.UseAnalyserJSON).To(BeTrue())
+
+			duration, err := runCfg.GetDuration()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(duration).To(Equal(2 * time.Hour))
+			Expect(runCfg.Validate()).To(Succeed())
+		})
+		It("should keep the values not present in the file", func() {
+			err := runner.LoadRunConfig("test_files/run.yaml", runCfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runCfg.PollInterval).To(Equal(1))
+			Expect(runCfg.TempDir).To(Equal("."))
+		})
+		It("should return the settings for each collector", func() {
+			err := runner.LoadRunConfig("test_files/run.yaml", runCfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runCfg.GetCollectorSettings("gnss").PollInterval).To(Equal(2))
+			Expect(runCfg.GetCollectorSettings("DevInfo").PollInterval).To(Equal(30))
+			Expect(runCfg.GetCollectorSettings("dpll").Options).To(HaveKeyWithValue("source", "netlink"))
+			Expect(runCfg.GetCollectorSettings("PMC").PollInterval).To(Equal(0))
+		})
+		It("should return the poll timeout for each collector", func() {
+			runCfg.PollTimeout = 30
+			err := runner.LoadRunConfig("test_files/run.yaml", runCfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runCfg.GetPollTimeout("GNSS")).To(Equal(5 * time.Second))
+			Expect(runCfg.GetPollTimeout("PMC")).To(Equal(30 * time.Second))
+		})
+	})
+	When("the run config file has a setting which is not known", func() {
+		It("should return an error", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "run.yaml")
+			content := "collectorSettings:\n  DevInfo:\n    pollEvery: 30\n"
+			Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+			err := runner.LoadRunConfig(path, runCfg)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	When("the run config file was written for a single interface", func() {
+		It("should accept the interface key", func() {
+			runCfg.PTPInterfaces = []string{"ens8f0"}
+			Expect(runner.ParseRunConfig([]byte("interface: ens7f0\n"), runCfg)).To(Succeed())
+			Expect(runCfg.PTPInterfaces).To(Equal([]string{"ens7f0", "ens8f0"}))
+			Expect(runCfg.PTPInterface).To(BeEmpty())
+		})
+	})
+	When("the run config file does not exist", func() {
+		It("should return an error", func() {
+			err := runner.LoadRunConfig("test_files/missing.yaml", runCfg)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("RunConfig.GetClusters", func() {
+	When("no clusters are listed", func() {
+		It("should return a single cluster from the top level values", func() {
+			runCfg := &runner.RunConfig{
+				KubeConfig:    "/path/to/kubeconfig",
+				NodeName:      "node-a",
+				PTPInterfaces: []string{"ens7f0"},
+			}
+			Expect(runCfg.GetClusters()).To(Equal([]runner.ClusterConfig{{
+				KubeConfig:    "/path/to/kubeconfig",
+				NodeName:      "node-a",
+				PTPInterfaces: []string{"ens7f0"},
+			}}))
+		})
+	})
+	When("clusters are listed", func() {
+		It("should fill in any missing values from the top level", func() {
+			runCfg := &runner.RunConfig{}
+			err := runner.LoadRunConfig("test_files/clusters.yaml", runCfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runCfg.Validate()).To(Succeed())
+
+			clusters := runCfg.GetClusters()
+			Expect(clusters).To(HaveLen(2))
+			Expect(clusters[0].Name).To(Equal("gm"))
+			Expect(clusters[0].NodeName).To(Equal("gm-node"))
+			Expect(clusters[0].PTPInterfaces).To(Equal([]string{"ens7f0"}))
+			Expect(clusters[1].Name).To(Equal("bc"))
+			Expect(clusters[1].NodeName).To(Equal(""))
+			Expect(clusters[1].PTPInterfaces).To(Equal([]string{"ens2f0", "ens2f1"}))
+		})
+	})
+})
+
+var _ = Describe("RunConfig.Validate", func() {
+	When("two clusters have the same name", func() {
+		It("should return an error", func() {
+			runCfg := &runner.RunConfig{
+				PTPInterfaces: []string{"ens7f0"},
+				Duration:      "10s",
+				Clusters: []runner.ClusterConfig{
+					{Name: "gm", KubeConfig: "/path/to/gm/kubeconfig"},
+					{Name: "gm", KubeConfig: "/path/to/bc/kubeconfig"},
+				},
+			}
+			Expect(runCfg.Validate()).NotTo(Succeed())
+			runCfg.Clusters[1].Name = "bc"
+			Expect(runCfg.Validate()).To(Succeed())
+		})
+	})
+	When("a cluster has no kubeconfig", func() {
+		It("should return an error", func() {
+			runCfg := &runner.RunConfig{
+				PTPInterfaces: []string{"ens7f0"},
+				Duration:      "10s",
+				Clusters:      []runner.ClusterConfig{{Name: "gm"}},
+			}
+			Expect(runCfg.Validate()).NotTo(Succeed())
+		})
+	})
+	When("the logs collector is requested without a logs output file", func() {
+		It("should return an error", func() {
+			runCfg := &runner.RunConfig{
+				KubeConfig:    "/path/to/kubeconfig",
+				PTPInterfaces: []string{"ens7f0"},
+				Duration:      "10s",
+				Collectors:    []string{"all"},
+			}
+			Expect(runCfg.Validate()).NotTo(Succeed())
+			runCfg.Output.LogsFile = "logs.txt"
+			Expect(runCfg.Validate()).To(Succeed())
+		})
+	})
+	When("collecting locally", func() {
+		It("should not need a kubeconfig or a logs output file", func() {
+			runCfg := &runner.RunConfig{
+				Local:         true,
+				PTPInterfaces: []string{"ens7f0"},
+				Duration:      "10s",
+				Collectors:    []string{"all"},
+			}
+			Expect(runCfg.Validate()).To(Succeed())
+			Expect(runCfg.GetClusters()).To(Equal([]runner.ClusterConfig{{
+				Name:          "local",
+				PTPInterfaces: []string{"ens7f0"},
+			}}))
+		})
+		It("should return an error if a kubeconfig or the logs collector is also requested", func() {
+			runCfg := &runner.RunConfig{
+				Local:         true,
+				KubeConfig:    "/path/to/kubeconfig",
+				PTPInterfaces: []string{"ens7f0"},
+				Duration:      "10s",
+			}
+			Expect(runCfg.Validate()).NotTo(Succeed())
+			runCfg.KubeConfig = ""
+			runCfg.Collectors = []string{"Logs"}
+			runCfg.Output.LogsFile = "logs.txt"
+			Expect(runCfg.Validate()).NotTo(Succeed())
+			runCfg.Collectors = []string{"Ts2phc"}
+			Expect(runCfg.Validate()).To(MatchError(ContainSubstring("the Ts2phc collector reads the kubernetes API")))
+		})
+	})
+	When("collecting over ssh", func() {
+		It("should name the only cluster after the host", func() {
+			runCfg := &runner.RunConfig{
+				SSH:           "core@ptp-host.example.com:2222",
+				PTPInterfaces: []string{"ens7f0"},
+				Duration:      "10s",
+			}
+			Expect(runCfg.Validate()).To(Succeed())
+			Expect(runCfg.GetClusters()).To(Equal([]runner.ClusterConfig{{
+				Name:          "ptp-host.example.com",
+				PTPInterfaces: []string{"ens7f0"},
+			}}))
+			runCfg.Local = true
+			Expect(runCfg.Validate()).NotTo(Succeed())
+		})
+	})
+	When("collector spec files are given", func() {
+		It("should let the declared collectors be selected for the run only", func() {
+			runCfg := &runner.RunConfig{
+				KubeConfig:     "/path/to/kubeconfig",
+				PTPInterfaces:  []string{"ens7f0"},
+				Duration:       "10s",
+				Collectors:     []string{"PHCTime", "TxTimeout"},
+				CollectorSpecs: []string{"test_files/collectors.yaml"},
+			}
+			Expect(runCfg.Validate()).To(Succeed())
+			out := &bytes.Buffer{}
+			Expect(runner.DryRun(runCfg, out)).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("PHCTime:ens7f0"))
+			Expect(out.String()).To(ContainSubstring("TxTimeout"))
+
+			registry := collectors.GetRegistry()
+			Expect(runner.GetCollectorsToRun(registry, []string{"all"})).NotTo(ContainElement("PHCTime"))
+			Expect(registry.Find("phc/time")).Error().To(HaveOccurred())
+		})
+		It("should return an error if a built in collector is redefined", func() {
+			runCfg := &runner.RunConfig{
+				KubeConfig:     "/path/to/kubeconfig",
+				PTPInterfaces:  []string{"ens7f0"},
+				Duration:       "10s",
+				CollectorSpecs: []string{"test_files/builtin_collectors.yaml"},
+			}
+			Expect(runCfg.Validate()).To(MatchError(ContainSubstring("built in")))
+		})
+		It("should return an error if the file does not exist", func() {
+			runCfg := &runner.RunConfig{
+				KubeConfig:     "/path/to/kubeconfig",
+				PTPInterfaces:  []string{"ens7f0"},
+				Duration:       "10s",
+				CollectorSpecs: []string{"test_files/missing.yaml"},
+			}
+			Expect(runCfg.Validate()).NotTo(Succeed())
+		})
+	})
+	When("the duration is negative", func() {
+		It("should return an error", func() {
+			runCfg := &runner.RunConfig{
+				KubeConfig:    "/path/to/kubeconfig",
+				PTPInterfaces: []string{"ens7f0"},
+				Duration:      "-10s",
+			}
+			Expect(runCfg.Validate()).NotTo(Succeed())
+		})
+	})
+	When("a stop condition is not valid", func() {
+		It("should return an error", func() {
+			runCfg := &runner.RunConfig{
+				KubeConfig:    "/path/to/kubeconfig",
+				PTPInterfaces: []string{"ens7f0"},
+				Duration:      "10s",
+				Collectors:    []string{"GNSS"},
+				StopOn:        []string{"dpll-state=holdover", "samples=GNSS"},
+			}
+			Expect(runCfg.Validate()).NotTo(Succeed())
+			runCfg.StopOn[1] = "samples=GNSS:100"
+			Expect(runCfg.Validate()).To(Succeed())
+		})
+	})
+	When("a samples stop condition counts a collector which is not run", func() {
+		It("should return an error", func() {
+			runCfg := &runner.RunConfig{
+				KubeConfig:    "/path/to/kubeconfig",
+				PTPInterfaces: []string{"ens7f0"},
+				Duration:      "10s",
+				Collectors:    []string{"DPLL"},
+				StopOn:        []string{"samples=GNSS:100"},
+			}
+			Expect(runCfg.Validate()).NotTo(Succeed())
+			runCfg.StopOn[0] = "samples=dpll:100"
+			Expect(runCfg.Validate()).To(Succeed())
+		})
+	})
+	When("options are set for a collector", func() {
+		var runCfg *runner.RunConfig
+		BeforeEach(func() {
+			runCfg = &runner.RunConfig{
+				KubeConfig:    "/path/to/kubeconfig",
+				PTPInterfaces: []string{"ens7f0"},
+				Duration:      "10s",
+				CollectorSettings: map[string]*runner.CollectorSettings{
+					"dpll": {Options: map[string]string{"source": "filesystem"}},
+				},
+			}
+		})
+		It("should accept the options the collector lists", func() {
+			Expect(runCfg.Validate()).To(Succeed())
+		})
+		It("should return an error for an option the collector does not have", func() {
+			runCfg.CollectorSettings["dpll"].Options["example"] = "value"
+			Expect(runCfg.Validate()).To(MatchError(ContainSubstring("does not have an option named example")))
+		})
+		It("should return an error for a value the option does not accept", func() {
+			runCfg.CollectorSettings["dpll"].Options["source"] = "sysfs"
+			Expect(runCfg.Validate()).To(MatchError(ContainSubstring("must be one of filesystem, netlink")))
+		})
+		It("should return an error for a collector which does not accept options", func() {
+			runCfg.CollectorSettings["GNSS"] = &runner.CollectorSettings{Options: map[string]string{"source": "netlink"}}
+			Expect(runCfg.Validate()).To(MatchError(ContainSubstring("collector GNSS does not have an option")))
+		})
+		It("should return an error for a collector which does not exist", func() {
+			runCfg.CollectorSettings["PHCTime"] = &runner.CollectorSettings{Options: map[string]string{"source": "netlink"}}
+			Expect(runCfg.Validate()).To(MatchError(ContainSubstring("PHCTime which is not a collector")))
+		})
+	})
+})
+
+func TestRunner(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Runner Suite")
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors"
+)
+
+// writePlannedCommand writes a command in a form which can be read
+// by a reviewer and pasted into a shell on the node. If host is set
+// the command is run directly on that host rather than in a container.
+func writePlannedCommand(out io.Writer, instanceName string, command *collectors.PlannedCommand, host string) {
+	fmt.Fprintf(out, "\n# %s: %s\n", instanceName, command.Description)
+	if host != "" {
+		fmt.Fprintf(out, "#   sent to /usr/bin/sh on %s\n", host)
+		fmt.Fprintln(out, command.Script)
+		return
+	}
+	if command.Script == "" {
+		fmt.Fprintf(
+			out, "#   container %s of pod %s in namespace %s\n",
+			command.Container, command.Pod, command.Namespace,
+		)
+		return
+	}
+	fmt.Fprintf(
+		out, "#   sent to /usr/bin/sh in container %s of pod %s in namespace %s\n",
+		command.Container, command.Pod, command.Namespace,
+	)
+	fmt.Fprintln(out, command.Script)
+}
+
+// writePlan writes the commands of every collector instance which would run against a cluster
+func writePlan(
+	out io.Writer,
+	registry *collectors.CollectorRegistry,
+	collectorNames []string,
+	cluster *ClusterConfig,
+	host string,
+) error {
+	node := cluster.NodeName
+	if node == "" {
+		node = "the only node running linuxptp-daemon"
+	}
+	if host != "" {
+		fmt.Fprintf(out, "\n# Every command is run on %s\n", host)
+	} else {
+		fmt.Fprintf(out, "\n# Cluster %s (kubeconfig %s) on %s\n", cluster.Name, cluster.KubeConfig, node)
+	}
+
+	for _, collectorName := range collectorNames {
+		instances := map[string]string{collectorName: cluster.PTPInterfaces[0]}
+		instanceNames := []string{collectorName}
+		if registry.IsPerInterface(collectorName) {
+			instances = make(map[string]string, len(cluster.PTPInterfaces))
+			instanceNames = make([]string, 0, len(cluster.PTPInterfaces))
+			for _, ptpInterface := range cluster.PTPInterfaces {
+				instanceName := fmt.Sprintf("%s:%s", collectorName, ptpInterface)
+				instances[instanceName] = ptpInterface
+				instanceNames = append(instanceNames, instanceName)
+			}
+		}
+		for _, instanceName := range instanceNames {
+			plan, err := registry.GetPlan(collectorName, instances[instanceName])
+			if err != nil {
+				return fmt.Errorf("failed to plan %s: %w", instanceName, err)
+			}
+			for i := range plan {
+				writePlannedCommand(out, instanceName, &plan[i], host)
+			}
+		}
+	}
+	return nil
+}
+
+// DryRun writes the collectors which would be run against each cluster in the run config
+// and the exact scripts they would send to each container, without connecting to the clusters.
+func DryRun(runCfg *RunConfig, out io.Writer) error {
+	registry, err := runCfg.getRegistry()
+	if err != nil {
+		return err
+	}
+	clusters := runCfg.GetClusters()
+	resolveClusterNames(clusters)
+	collectorNames := runCfg.getCollectorNames(registry)
+
+	fmt.Fprintln(out, "# Dry run, nothing has been run on the clusters")
+	fmt.Fprintf(out, "# Collectors: %v\n", collectorNames)
+	for i := range clusters {
+		err := writePlan(out, registry, collectorNames, &clusters[i], runCfg.describeHost())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/runner"
+)
+
+var _ = Describe("DryRun", func() {
+	When("the collectors are planned", func() {
+		It("should print the scripts of each collector instance without connecting", func() {
+			runCfg := &runner.RunConfig{
+				Clusters: []runner.ClusterConfig{{
+					Name:          "gm",
+					KubeConfig:    "/does/not/exist",
+					PTPInterfaces: []string{"ens7f0", "ens5f0"},
+				}},
+				Collectors: []string{"PMC"},
+			}
+			out := &bytes.Buffer{}
+			Expect(runner.DryRun(runCfg, out)).To(Succeed())
+
+			Expect(out.String()).To(ContainSubstring("# Cluster gm"))
+			Expect(out.String()).To(ContainSubstring("# DevInfo:ens7f0: fetch the device info"))
+			Expect(out.String()).To(ContainSubstring("echo '<devID>';cat /sys/class/net/ens5f0/device/device;echo '</devID>';"))
+			Expect(out.String()).To(ContainSubstring("# PMC: fetch the grandmaster settings"))
+			Expect(out.String()).To(ContainSubstring("'GET GRANDMASTER_SETTINGS_NP'"))
+			Expect(out.String()).NotTo(ContainSubstring("GNSS"))
+		})
+	})
+	When("user defined collectors are selected", func() {
+		It("should print their scripts with the interface filled in", func() {
+			runCfg := &runner.RunConfig{
+				KubeConfig:     "/does/not/exist",
+				PTPInterfaces:  []string{"ens7f0"},
+				Duration:       "10s",
+				Collectors:     []string{"PHCTime"},
+				CollectorSpecs: []string{"test_files/collectors.yaml"},
+			}
+			Expect(runCfg.Validate()).To(Succeed())
+			out := &bytes.Buffer{}
+			Expect(runner.DryRun(runCfg, out)).To(Succeed())
+
+			Expect(out.String()).To(ContainSubstring("# PHCTime:ens7f0: fetch the values of user defined collector PHCTime"))
+			Expect(out.String()).To(ContainSubstring("echo '<phc>';phc_ctl ens7f0 get;echo '</phc>';"))
+			Expect(out.String()).NotTo(ContainSubstring("TxTimeout"))
+		})
+	})
+	When("the run is local", func() {
+		It("should print the scripts which would be run on this host and leave out the Logs collector", func() {
+			runCfg := &runner.RunConfig{
+				Local:         true,
+				PTPInterfaces: []string{"ens7f0"},
+				Collectors:    []string{"all"},
+			}
+			out := &bytes.Buffer{}
+			Expect(runner.DryRun(runCfg, out)).To(Succeed())
+
+			Expect(out.String()).To(ContainSubstring("# Every command is run on this host"))
+			Expect(out.String()).To(ContainSubstring("#   sent to /usr/bin/sh on this host"))
+			Expect(out.String()).To(ContainSubstring("'GET GRANDMASTER_SETTINGS_NP'"))
+			Expect(out.String()).NotTo(ContainSubstring("linuxptp-daemon-container"))
+			Expect(out.String()).NotTo(ContainSubstring("Logs"))
+		})
+	})
+})
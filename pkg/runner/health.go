@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+)
+
+type HealthState string
+
+const (
+	CollectorHealthTag = "collector-health"
+
+	Healthy  HealthState = "healthy"
+	Failing  HealthState = "failing"
+	Disabled HealthState = "disabled"
+
+	// backoffLimitShift stops the backoff multiplier from overflowing
+	backoffLimitShift = 16
+)
+
+// CollectorHealth is a snapshot of the health of a collector,
+// it is written to the output whenever the state changes.
+type CollectorHealth struct {
+	LastSuccess         time.Time   `json:"lastSuccess"`
+	LastFailure         time.Time   `json:"lastFailure"`
+	Collector           string      `json:"collector"`
+	State               HealthState `json:"state"`
+	PreviousState       HealthState `json:"previousState"`
+	LastError           string      `json:"lastError,omitempty"`
+	ConsecutiveFailures int         `json:"consecutiveFailures"`
+	Polls               int         `json:"polls"`
+	Errors              int         `json:"errors"`
+	ErrorRate           float64     `json:"errorRate"`
+}
+
+func (health *CollectorHealth) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	formatted := callbacks.AnalyserFormatType{
+		ID:   "collector/health",
+		Data: health,
+	}
+	return []*callbacks.AnalyserFormatType{&formatted}, nil
+}
+
+// healthTracker keeps track of the health of a single collector instance and
+// decides when it should next be polled.
+// Once a collector is failing it is backed off exponentially; each consecutive failure
+// doubles the time until the next poll up to maxBackoff. If disableAfter is more than
+// zero the collector is disabled after that many consecutive failures.
+type healthTracker struct {
+	health       CollectorHealth
+	nextPoll     time.Time
+	lock         sync.Mutex
+	pollInterval time.Duration
+	maxBackoff   time.Duration
+	disableAfter int
+}
+
+func newHealthTracker(
+	collectorName string,
+	pollInterval time.Duration,
+	maxBackoff time.Duration,
+	disableAfter int,
+) *healthTracker {
+	return &healthTracker{
+		health: CollectorHealth{
+			Collector:     collectorName,
+			State:         Healthy,
+			PreviousState: Healthy,
+		},
+		pollInterval: pollInterval,
+		maxBackoff:   maxBackoff,
+		disableAfter: disableAfter,
+	}
+}
+
+// getBackoff returns how long to wait after the last failure before polling again
+func (tracker *healthTracker) getBackoff() time.Duration {
+	failures := tracker.health.ConsecutiveFailures
+	if failures <= 1 {
+		return tracker.pollInterval
+	}
+	if failures > backoffLimitShift {
+		failures = backoffLimitShift
+	}
+	backoff := tracker.pollInterval * time.Duration(1<<(failures-1))
+	if tracker.maxBackoff > 0 && backoff > tracker.maxBackoff {
+		return tracker.maxBackoff
+	}
+	return backoff
+}
+
+// record updates the health with the result of a poll.
+// If the state changes a snapshot of the new health is returned.
+func (tracker *healthTracker) record(errs []error, now time.Time) *CollectorHealth {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	health := &tracker.health
+	if health.State == Disabled {
+		return nil
+	}
+	health.Polls++
+	newState := Healthy
+	if len(errs) == 0 {
+		health.LastSuccess = now
+		health.ConsecutiveFailures = 0
+		tracker.nextPoll = time.Time{}
+	} else {
+		errStrings := make([]string, 0, len(errs))
+		for _, err := range errs {
+			errStrings = append(errStrings, err.Error())
+		}
+		health.Errors++
+		health.ConsecutiveFailures++
+		health.LastFailure = now
+		health.LastError = strings.Join(errStrings, "; ")
+		tracker.nextPoll = now.Add(tracker.getBackoff())
+		newState = Failing
+		if tracker.disableAfter > 0 && health.ConsecutiveFailures >= tracker.disableAfter {
+			newState = Disabled
+		}
+	}
+	health.ErrorRate = float64(health.Errors) / float64(health.Polls)
+
+	if newState == health.State {
+		return nil
+	}
+	health.PreviousState = health.State
+	health.State = newState
+	snapshot := *health
+	return &snapshot
+}
+
+// shouldPoll reports if the collector is due to be polled, it returns false while backing off
+func (tracker *healthTracker) shouldPoll(now time.Time) bool {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	return tracker.health.State != Disabled && !now.Before(tracker.nextPoll)
+}
+
+// isDisabled reports if the collector has been disabled
+func (tracker *healthTracker) isDisabled() bool {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	return tracker.health.State == Disabled
+}
+
+// getHealth returns a snapshot of the current health
+func (tracker *healthTracker) getHealth() CollectorHealth {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	return tracker.health
+}
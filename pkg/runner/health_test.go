@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner //nolint:testpackage // testing internal functions
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("healthTracker", func() {
+	start := time.Date(2023, time.August, 1, 12, 0, 0, 0, time.UTC)
+	pollErr := []error{errors.New("failed to fetch gpsNav")}
+
+	When("a collector starts failing", func() {
+		It("should report the transition and back off exponentially", func() {
+			tracker := newHealthTracker("GNSS", time.Second, 10*time.Second, 0)
+			Expect(tracker.record(nil, start)).To(BeNil())
+
+			health := tracker.record(pollErr, start.Add(time.Second))
+			Expect(health).NotTo(BeNil())
+			Expect(health.State).To(Equal(Failing))
+			Expect(health.PreviousState).To(Equal(Healthy))
+			Expect(health.LastSuccess).To(Equal(start))
+			Expect(health.ErrorRate).To(Equal(0.5))
+			Expect(tracker.shouldPoll(start.Add(2 * time.Second))).To(BeTrue())
+
+			Expect(tracker.record(pollErr, start.Add(2*time.Second))).To(BeNil())
+			Expect(tracker.shouldPoll(start.Add(3 * time.Second))).To(BeFalse())
+			Expect(tracker.shouldPoll(start.Add(4 * time.Second))).To(BeTrue())
+
+			Expect(tracker.record(pollErr, start.Add(4*time.Second))).To(BeNil())
+			Expect(tracker.shouldPoll(start.Add(7 * time.Second))).To(BeFalse())
+			Expect(tracker.shouldPoll(start.Add(8 * time.Second))).To(BeTrue())
+		})
+		It("should not back off for longer than the max backoff", func() {
+			tracker := newHealthTracker("GNSS", time.Second, 3*time.Second, 0)
+			for i := 0; i < 10; i++ {
+				tracker.record(pollErr, start)
+			}
+			Expect(tracker.shouldPoll(start.Add(3 * time.Second))).To(BeTrue())
+		})
+	})
+	When("a failing collector recovers", func() {
+		It("should report the transition and poll normally", func() {
+			tracker := newHealthTracker("GNSS", time.Second, 10*time.Second, 0)
+			tracker.record(pollErr, start)
+			tracker.record(pollErr, start.Add(time.Second))
+			health := tracker.record(nil, start.Add(3*time.Second))
+			Expect(health).NotTo(BeNil())
+			Expect(health.State).To(Equal(Healthy))
+			Expect(health.PreviousState).To(Equal(Failing))
+			Expect(health.ConsecutiveFailures).To(Equal(0))
+			Expect(tracker.shouldPoll(start.Add(3 * time.Second))).To(BeTrue())
+		})
+	})
+	When("a collector fails more times than the threshold", func() {
+		It("should be disabled", func() {
+			tracker := newHealthTracker("GNSS", time.Second, 10*time.Second, 3)
+			tracker.record(pollErr, start)
+			tracker.record(pollErr, start.Add(time.Second))
+			health := tracker.record(pollErr, start.Add(3*time.Second))
+			Expect(health).NotTo(BeNil())
+			Expect(health.State).To(Equal(Disabled))
+			Expect(tracker.isDisabled()).To(BeTrue())
+			Expect(tracker.shouldPoll(start.Add(time.Hour))).To(BeFalse())
+			Expect(tracker.record(nil, start.Add(time.Hour))).To(BeNil())
+		})
+	})
+})
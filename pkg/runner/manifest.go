@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner
+
+import (
+	"os"
+	"time"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
+)
+
+const (
+	RunManifestTag = "run-manifest"
+	RunEndTag      = "run-end"
+)
+
+// RunManifest is written at the start of every output so that the
+// output can be tied back to the run which produced it.
+type RunManifest struct {
+	StartTime  time.Time       `json:"startTime"`
+	Config     *RunConfig      `json:"config"`
+	Version    string          `json:"version"`
+	Args       []string        `json:"args"`
+	Clusters   []ClusterConfig `json:"clusters"`
+	Collectors []string        `json:"collectors"`
+}
+
+func (manifest *RunManifest) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	formatted := callbacks.AnalyserFormatType{
+		ID:   "run/manifest",
+		Data: manifest,
+	}
+	return []*callbacks.AnalyserFormatType{&formatted}, nil
+}
+
+// PollCounts holds the number of polls of a collector and how many of those had errors
+type PollCounts struct {
+	Polls  int `json:"polls"`
+	Errors int `json:"errors"`
+}
+
+// ClusterPollCounts holds the PollCounts for each collector run against a cluster
+type ClusterPollCounts struct {
+	Polls map[string]*PollCounts `json:"polls"`
+	Name  string                 `json:"name"`
+}
+
+// RunEnd is written at the end of every output to record
+// when and how the run finished and how much data it produced
+type RunEnd struct {
+	StartTime   time.Time           `json:"startTime"`
+	EndTime     time.Time           `json:"endTime"`
+	Records     map[string]int      `json:"records"`
+	Duration    string              `json:"duration"`
+	StoppedBy   string              `json:"stoppedBy,omitempty"`
+	Clusters    []ClusterPollCounts `json:"clusters"`
+	Interrupted bool                `json:"interrupted"`
+}
+
+func (runEnd *RunEnd) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	formatted := callbacks.AnalyserFormatType{
+		ID:   "run/end",
+		Data: runEnd,
+	}
+	return []*callbacks.AnalyserFormatType{&formatted}, nil
+}
+
+func newRunManifest(
+	runCfg *RunConfig,
+	collectorNames []string,
+	clusters []ClusterConfig,
+	startTime time.Time,
+) *RunManifest {
+	return &RunManifest{
+		StartTime:  startTime,
+		Config:     runCfg,
+		Version:    utils.GetVersion(),
+		Args:       os.Args[1:],
+		Clusters:   clusters,
+		Collectors: collectorNames,
+	}
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner //nolint:testpackage // the host context is set directly instead of connecting
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
+)
+
+type analyserLine struct {
+	ID   string          `json:"id"`
+	Data json.RawMessage `json:"data"`
+}
+
+// readAnalyserLines returns each line of an output written in the analyser format
+func readAnalyserLines(filename string) []analyserLine {
+	file, err := os.Open(filename)
+	Expect(err).NotTo(HaveOccurred())
+	defer file.Close()
+	lines := make([]analyserLine, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := analyserLine{}
+		Expect(json.Unmarshal(scanner.Bytes(), &line)).To(Succeed())
+		lines = append(lines, line)
+	}
+	Expect(scanner.Err()).NotTo(HaveOccurred())
+	return lines
+}
+
+var _ = Describe("RunManifest", func() {
+	When("it is formatted for the analysers", func() {
+		It("should use the run/manifest id", func() {
+			manifest := &RunManifest{
+				Version:    "v0.0.1",
+				Collectors: []string{"DevInfo", "GNSS"},
+				Clusters:   []ClusterConfig{{Name: "gm", NodeName: "node-a", PTPInterfaces: []string{"ens7f0"}}},
+			}
+			formatted, err := manifest.GetAnalyserFormat()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(formatted).To(HaveLen(1))
+			Expect(formatted[0].ID).To(Equal("run/manifest"))
+			Expect(formatted[0].Data).To(Equal(manifest))
+		})
+	})
+})
+
+var _ = Describe("RunEnd", func() {
+	When("it is formatted for the analysers", func() {
+		It("should use the run/end id", func() {
+			runEnd := &RunEnd{
+				EndTime: time.Now(),
+				Records: map[string]int{"gpsNav": 10},
+			}
+			formatted, err := runEnd.GetAnalyserFormat()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(formatted).To(HaveLen(1))
+			Expect(formatted[0].ID).To(Equal("run/end"))
+			Expect(formatted[0].Data).To(Equal(runEnd))
+		})
+	})
+})
+
+var _ = Describe("Collection output", func() {
+	When("a collection has run", func() {
+		It("should start with the run manifest and record the number of each record in the run end", func() {
+			outFile := filepath.Join(GinkgoT().TempDir(), "collected.log")
+			runCfg := &RunConfig{
+				Local:                   true,
+				Duration:                "1s",
+				PollInterval:            1,
+				DevInfoAnnounceInterval: 1,
+				PTPInterfaces:           []string{"ens7f0", "ens7f1"},
+				Collectors:              []string{collectors.DevInfoCollectorName, collectors.DPLLCollectorName},
+				Output:                  OutputConfig{File: outFile, UseAnalyserJSON: true},
+			}
+			collection, err := NewCollection(runCfg)
+			Expect(err).NotTo(HaveOccurred())
+			collection.SetSummaryWriter(nil)
+			for _, collectionRunner := range collection.runners {
+				collectionRunner.hostCtx = newFakeHostContext()
+			}
+			Expect(collection.Run(context.Background())).To(Succeed())
+
+			lines := readAnalyserLines(outFile)
+			Expect(lines).NotTo(BeEmpty())
+			Expect(lines[0].ID).To(Equal("run/manifest"))
+			manifest := RunManifest{}
+			Expect(json.Unmarshal(lines[0].Data, &manifest)).To(Succeed())
+			Expect(manifest.Collectors).To(Equal([]string{collectors.DevInfoCollectorName, collectors.DPLLCollectorName}))
+			Expect(manifest.Version).To(Equal(utils.GetVersion()))
+			Expect(manifest.Clusters).To(HaveLen(1))
+			Expect(manifest.Clusters[0].PTPInterfaces).To(Equal([]string{"ens7f0", "ens7f1"}))
+
+			written := make(map[string]int)
+			runEnd := RunEnd{}
+			for _, line := range lines {
+				written[line.ID]++
+				if line.ID == "run/end" {
+					Expect(json.Unmarshal(line.Data, &runEnd)).To(Succeed())
+				}
+			}
+			Expect(written).To(HaveKeyWithValue("run/end", 1))
+			Expect(written["devInfo"]).To(BeNumerically(">=", 2))
+			Expect(written["dpll/time-error"]).To(BeNumerically(">=", 2))
+			Expect(runEnd.Records).To(Equal(map[string]int{
+				RunManifestTag:        1,
+				collectors.DeviceInfo: written["devInfo"],
+				collectors.DPLLInfo:   written["dpll/time-error"],
+			}))
+			Expect(runEnd.Clusters).To(HaveLen(1))
+			Expect(runEnd.Clusters[0].Polls).To(HaveKey("DPLL:ens7f0"))
+			Expect(runEnd.Clusters[0].Polls["DPLL:ens7f0"].Polls).To(BeNumerically(">=", 1))
+		})
+	})
+})
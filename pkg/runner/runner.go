@@ -3,10 +3,12 @@
 package runner
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
-	"os/signal"
-	"syscall"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -14,6 +16,8 @@ import (
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/clients"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/contexts"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/metrics"
 	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/utils"
 )
 
@@ -22,74 +26,149 @@ const (
 	pollResultsQueueSize = 10
 )
 
-// getQuitChannel creates and returns a channel for notifying
-// that a exit signal has been received
-func getQuitChannel() chan os.Signal {
-	// Allow ourselves to handle shut down gracefully
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	return quit
-}
-
 type CollectorRunner struct {
-	endTime                time.Time
-	quit                   chan os.Signal
-	collectorQuitChannel   map[string]chan os.Signal
-	pollResults            chan collectors.PollResult
-	erroredPolls           chan collectors.PollResult
-	collectorInstances     map[string]collectors.Collector
-	collectorNames         []string
-	runningCollectorsWG    utils.WaitGroupCount
-	runningAnnouncersWG    utils.WaitGroupCount
-	pollInterval           int
-	devInfoAnnouceInterval int
-	onlyAnnouncers         bool
+	runEnded            chan struct{}
+	collectorsDone      chan struct{}
+	allDone             chan struct{}
+	config              *RunConfig
+	cluster             *ClusterConfig
+	registry            *collectors.CollectorRegistry
+	clientset           *clients.Clientset
+	hostCtx             clients.ExecContext
+	sessions            *clients.PersistentSessions
+	captureWriter       *clients.CaptureWriter
+	callback            callbacks.Callback
+	healthTrackers      map[string]*healthTracker
+	healthLock          sync.Mutex
+	stopper             *stopper
+	metrics             *metrics.Exporter
+	summary             *summariser
+	pollTimeouts        map[string]time.Duration
+	pollResults         chan collectors.PollResult
+	erroredPolls        chan collectors.PollResult
+	collectorInstances  map[string]collectors.Collector
+	instanceCollectors  map[string]string
+	collectorNames      []string
+	logsOutputFile      string
+	captureFile         string
+	tempDir             string
+	runningCollectorsWG utils.WaitGroupCount
+	runningAnnouncersWG utils.WaitGroupCount
+	onlyAnnouncers      bool
 }
 
-func NewCollectorRunner(selectedCollectors []string) *CollectorRunner {
+// NewCollectorRunner returns a CollectorRunner which will collect from a single cluster
+// using the collectors in registry
+func NewCollectorRunner(
+	runCfg *RunConfig,
+	cluster *ClusterConfig,
+	registry *collectors.CollectorRegistry,
+) *CollectorRunner {
 	return &CollectorRunner{
-		collectorInstances:   make(map[string]collectors.Collector),
-		collectorNames:       GetCollectorsToRun(selectedCollectors),
-		quit:                 getQuitChannel(),
-		pollResults:          make(chan collectors.PollResult, pollResultsQueueSize),
-		erroredPolls:         make(chan collectors.PollResult, pollResultsQueueSize),
-		collectorQuitChannel: make(map[string]chan os.Signal, 1),
-		onlyAnnouncers:       false,
+		config:             runCfg,
+		cluster:            cluster,
+		registry:           registry,
+		logsOutputFile:     runCfg.Output.LogsFile,
+		captureFile:        runCfg.Output.CaptureFile,
+		tempDir:            runCfg.TempDir,
+		collectorInstances: make(map[string]collectors.Collector),
+		instanceCollectors: make(map[string]string),
+		collectorNames:     runCfg.getCollectorNames(registry),
+		pollResults:        make(chan collectors.PollResult, pollResultsQueueSize),
+		erroredPolls:       make(chan collectors.PollResult, pollResultsQueueSize),
+		pollTimeouts:       make(map[string]time.Duration),
+		healthTrackers:     make(map[string]*healthTracker),
+		summary:            newSummariser(),
+		runEnded:           make(chan struct{}),
+		collectorsDone:     make(chan struct{}),
+		allDone:            make(chan struct{}),
+		onlyAnnouncers:     false,
 	}
 }
 
-// initialise will call theconstructor for each
-// value in collector name, it will panic if a collector name is not known.
-func (runner *CollectorRunner) initialise( //nolint:funlen // allow a slightly long function
-	callback callbacks.Callback,
+// getConstructor returns a CollectionConstructor for the named collector
+// with any collector specific settings from the run config applied.
+func (runner *CollectorRunner) getConstructor(
+	collectorName string,
 	ptpInterface string,
+	callback callbacks.Callback,
 	clientset *clients.Clientset,
-	pollInterval int,
-	requestedDuration time.Duration,
-	devInfoAnnouceInterval int,
-	logsOutputFile string,
-	includeLogTimestamps bool,
-	tempDir string,
-	keepDebugFiles bool,
-) {
-	runner.pollInterval = pollInterval
-	runner.endTime = time.Now().Add(requestedDuration)
-	runner.devInfoAnnouceInterval = devInfoAnnouceInterval
-
+) *collectors.CollectionConstructor {
 	constructor := &collectors.CollectionConstructor{
 		Callback:               callback,
 		PTPInterface:           ptpInterface,
+		NodeName:               runner.cluster.NodeName,
 		Clientset:              clientset,
-		PollInterval:           pollInterval,
-		DevInfoAnnouceInterval: devInfoAnnouceInterval,
+		HostExecContext:        runner.hostCtx,
+		Sessions:               runner.sessions,
+		CaptureWriter:          runner.captureWriter,
+		PollInterval:           runner.config.PollInterval,
+		DevInfoAnnouceInterval: runner.config.DevInfoAnnounceInterval,
 		ErroredPolls:           runner.erroredPolls,
-		LogsOutputFile:         logsOutputFile,
-		IncludeLogTimestamps:   includeLogTimestamps,
-		TempDir:                tempDir,
-		KeepDebugFiles:         keepDebugFiles,
+		LogsOutputFile:         runner.logsOutputFile,
+		IncludeLogTimestamps:   runner.config.Output.IncludeLogTimestamps,
+		TempDir:                runner.tempDir,
+		KeepDebugFiles:         runner.config.KeepDebugFiles,
 	}
 
-	registry := collectors.GetRegistry()
+	settings := runner.config.GetCollectorSettings(collectorName)
+	if settings.PollInterval > 0 {
+		// Each collector only reads the interval which applies to it so it is safe to set both
+		constructor.PollInterval = settings.PollInterval
+		constructor.DevInfoAnnouceInterval = settings.PollInterval
+	}
+	constructor.Options = settings.Options
+	return constructor
+}
+
+// addCollector builds a collector and stores it under instanceName
+func (runner *CollectorRunner) addCollector(
+	instanceName string,
+	collectorName string,
+	builderFunc func(*collectors.CollectionConstructor) (collectors.Collector, error),
+	constructor *collectors.CollectionConstructor,
+) error {
+	newCollector, err := builderFunc(constructor)
+	var missingRequirements *utils.RequirementsNotMetError
+	if errors.As(err, &missingRequirements) {
+		// Requirements are missing so don't add the collector to collectorInstance
+		// so that it doesn't get ran
+		log.Warning(err.Error())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create collector %s: %w", instanceName, err)
+	}
+	runner.collectorInstances[instanceName] = newCollector
+	runner.instanceCollectors[instanceName] = collectorName
+	runner.pollTimeouts[instanceName] = runner.config.GetPollTimeout(collectorName)
+	// Only instances which will be polled are counted by the stop conditions
+	runner.stopper.addInstance(collectorName, runner.getInstanceName(instanceName))
+	runner.healthLock.Lock()
+	defer runner.healthLock.Unlock()
+	runner.healthTrackers[instanceName] = newHealthTracker(
+		instanceName,
+		newCollector.GetPollInterval(),
+		runner.config.GetMaxBackoff(),
+		runner.config.DisableAfterFailures,
+	)
+	log.Debugf("Added collector %T, %v", newCollector, newCollector)
+	return nil
+}
+
+// initialise will call theconstructor for each
+// value in collector name, it will panic if a collector name is not known.
+// Collectors which are per interface are constructed once for each PTP interface
+// and their outputs are tagged with the interface name.
+func (runner *CollectorRunner) initialise(
+	callback callbacks.Callback,
+	clientset *clients.Clientset,
+	requestedDuration time.Duration,
+) error {
+	runEnded := runner.runEnded
+	time.AfterFunc(requestedDuration, func() { close(runEnded) })
+
+	registry := runner.registry
 
 	for _, collectorName := range runner.collectorNames {
 		builderFunc, err := registry.GetBuilderFunc(collectorName)
@@ -98,20 +177,40 @@ func (runner *CollectorRunner) initialise( //nolint:funlen // allow a slightly l
 			continue
 		}
 
-		newCollector, err := builderFunc(constructor)
-		var missingRequirements *utils.RequirementsNotMetError
-		if errors.As(err, &missingRequirements) {
-			// Requirements are missing so don't add the collector to collectorInstance
-			// so that it doesn't get ran
-			log.Warning(err.Error())
-		} else {
-			utils.IfErrorExitOrPanic(err)
-			runner.collectorInstances[collectorName] = newCollector
-			log.Debugf("Added collector %T, %v", newCollector, newCollector)
+		if !registry.IsPerInterface(collectorName) {
+			collectorCallback := runner.stopper.wrapCallback(callback, collectorName, runner.getInstanceName(collectorName))
+			constructor := runner.getConstructor(
+				collectorName, runner.cluster.PTPInterfaces[0], collectorCallback, clientset,
+			)
+			if err := runner.addCollector(collectorName, collectorName, builderFunc, constructor); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, ptpInterface := range runner.cluster.PTPInterfaces {
+			instanceName := fmt.Sprintf("%s:%s", collectorName, ptpInterface)
+			interfaceCallback := runner.stopper.wrapCallback(
+				callbacks.NewTaggedCallback(callback, map[string]string{"interface": ptpInterface}),
+				collectorName,
+				runner.getInstanceName(instanceName),
+			)
+			constructor := runner.getConstructor(collectorName, ptpInterface, interfaceCallback, clientset)
+			if err := runner.addCollector(instanceName, collectorName, builderFunc, constructor); err != nil {
+				return err
+			}
 		}
 	}
 	log.Debugf("Collectors %v", runner.collectorInstances)
 	runner.setOnlyAnnouncers()
+	return nil
+}
+
+// getInstanceName returns a name for the collector instance which is unique across all clusters
+func (runner *CollectorRunner) getInstanceName(instanceName string) string {
+	if runner.cluster.Name == "" {
+		return instanceName
+	}
+	return fmt.Sprintf("%s/%s", runner.cluster.Name, instanceName)
 }
 
 func (runner *CollectorRunner) setOnlyAnnouncers() {
@@ -125,163 +224,348 @@ func (runner *CollectorRunner) setOnlyAnnouncers() {
 	runner.onlyAnnouncers = onlyAnnouncers
 }
 
-func (runner *CollectorRunner) shouldKeepPolling(
-	collector collectors.Collector,
-) bool {
+// pollingDone returns a channel which is closed when the collector should stop polling.
+// Announcers keep going until all other collectors have finished,
+// everything else stops when the requested duration has passed.
+func (runner *CollectorRunner) pollingDone(collector collectors.Collector) <-chan struct{} {
 	if collector.IsAnnouncer() && !runner.onlyAnnouncers {
-		return runner.runningCollectorsWG.GetCount() > 0
-	} else {
-		return time.Since(runner.endTime) <= 0
+		return runner.collectorsDone
+	}
+	return runner.runEnded
+}
+
+// poll runs a single poll of the collector, cancelling it if it takes longer than the collectors poll timeout.
+// The time the poll was scheduled for is recorded against the sample so that late polls can be identified.
+// The result is used to update the collectors health before being passed on to the main loop.
+func (runner *CollectorRunner) poll(
+	ctx context.Context,
+	collectorName string,
+	collector collectors.Collector,
+	scheduled time.Time,
+	runningPolls *utils.WaitGroupCount,
+) {
+	defer runningPolls.Done()
+	ctx = callbacks.WithSample(ctx, callbacks.NewSampleMetadata(scheduled))
+	if timeout := runner.pollTimeouts[collectorName]; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	result := make(chan collectors.PollResult, 1)
+	pollWG := utils.WaitGroupCount{}
+	pollWG.Add(1)
+	pollStart := time.Now()
+	collector.Poll(ctx, result, &pollWG)
+	pollRes := <-result
+	pollEnd := time.Now()
+
+	runner.updateHealth(collectorName, pollRes)
+	runner.summary.recordPoll(
+		collectorName, collector.GetPollInterval(), pollEnd.Sub(pollStart), len(pollRes.Errors) > 0, pollEnd,
+	)
+	if runner.metrics != nil {
+		health := runner.healthTrackers[collectorName].getHealth()
+		runner.metrics.ObservePoll(
+			runner.cluster.Name, collectorName, string(health.State), len(pollRes.Errors) > 0, health.ConsecutiveFailures,
+		)
+	}
+	runner.pollResults <- pollRes
+}
+
+// updateHealth records the result of a poll against the collector
+// and reports any change in its health
+func (runner *CollectorRunner) updateHealth(collectorName string, pollRes collectors.PollResult) {
+	health := runner.healthTrackers[collectorName].record(pollRes.Errors, time.Now())
+	if health == nil {
+		return
+	}
+	switch health.State {
+	case Healthy:
+		log.Infof("Collector %s has recovered after %d polls with errors", collectorName, health.Errors)
+	case Failing:
+		log.Warnf("Collector %s is failing, backing off: %s", collectorName, health.LastError)
+	case Disabled:
+		log.Errorf(
+			"Collector %s has been disabled after %d consecutive failures: %s",
+			collectorName, health.ConsecutiveFailures, health.LastError,
+		)
+		runner.stopper.removeInstance(runner.instanceCollectors[collectorName], runner.getInstanceName(collectorName))
+	}
+	err := runner.callback.Call(health, CollectorHealthTag)
+	if err != nil {
+		log.Errorf("callback failed to write health of %s: %s", collectorName, err.Error())
 	}
 }
 
 func (runner *CollectorRunner) poller(
+	ctx context.Context,
 	collectorName string,
 	collector collectors.Collector,
-	quit chan os.Signal,
 	wg *utils.WaitGroupCount,
 ) {
 	defer wg.Done()
-	var lastPoll time.Time
 	pollInterval := collector.GetPollInterval()
+	schedule := newPollSchedule(time.Now(), pollInterval)
+	health := runner.healthTrackers[collectorName]
+	done := runner.pollingDone(collector)
 	runningPolls := utils.WaitGroupCount{}
 	log.Debugf("Collector with poll interval %f ", pollInterval.Seconds())
-	for runner.shouldKeepPolling(collector) {
-		// If pollResults were to block we do not want to keep spawning polls
-		// so we shouldn't allow too many polls to be running simultaneously
-		if runningPolls.GetCount() >= maxRunningPolls {
-			runningPolls.Wait()
-		}
-		log.Debugf("Collector GoRoutine: %s", collectorName)
+
+	// The first poll happens straight away
+	scheduled := time.Now()
+	pollTimer := time.NewTimer(0)
+	defer pollTimer.Stop()
+	for {
 		select {
-		case <-quit:
+		case <-ctx.Done():
 			log.Infof("Killed shutting down collector %s waiting for running polls to finish", collectorName)
 			runningPolls.Wait()
 			return
-		default:
-			log.Debug(
-				"Collector GoRoutine:",
-				collectorName,
-				lastPoll, pollInterval,
-				lastPoll.IsZero(),
-				time.Since(lastPoll),
-				time.Since(lastPoll) > pollInterval,
-				lastPoll.IsZero() || time.Since(lastPoll) > pollInterval,
-			)
-			if lastPoll.IsZero() || time.Since(lastPoll) > pollInterval {
-				lastPoll = time.Now()
+		case <-done:
+			runningPolls.Wait()
+			log.Debugf("Collector finished %s", collectorName)
+			return
+		case <-pollTimer.C:
+			if health.isDisabled() {
+				runningPolls.Wait()
+				log.Debugf("Collector %s is disabled, no longer polling", collectorName)
+				return
+			}
+			if health.shouldPoll(time.Now()) {
+				// If pollResults were to block we do not want to keep spawning polls
+				// so we shouldn't allow too many polls to be running simultaneously
+				if runningPolls.GetCount() >= maxRunningPolls {
+					runningPolls.Wait()
+				}
 				log.Debugf("poll %s", collectorName)
 				runningPolls.Add(1)
-				go collector.Poll(runner.pollResults, &runningPolls)
+				go runner.poll(ctx, collectorName, collector, scheduled, &runningPolls)
+			} else {
+				log.Debugf("Collector %s is backing off, skipping poll", collectorName)
 			}
-			time.Sleep(time.Microsecond)
+			scheduled = schedule.next(time.Now())
+			pollTimer.Reset(time.Until(scheduled))
 		}
 	}
-	runningPolls.Wait()
-	log.Debugf("Collector finished %s", collectorName)
 }
 
-// start configures all collectors to start collecting all their data keys
-func (runner *CollectorRunner) start() {
+// start configures all collectors to start collecting all their data keys.
+// If any collector fails to start those already started are cleaned up and nothing is polled.
+func (runner *CollectorRunner) start(ctx context.Context) error {
+	started := make([]collectors.Collector, 0, len(runner.collectorInstances))
 	for collectorName, collector := range runner.collectorInstances {
 		log.Debugf("start collector %v", collector)
 		err := collector.Start()
-		utils.IfErrorExitOrPanic(err)
-
+		if err != nil {
+			for _, startedCollector := range started {
+				if cleanUpErr := startedCollector.CleanUp(); cleanUpErr != nil {
+					log.Errorf("failed to clean up collector: %s", cleanUpErr.Error())
+				}
+			}
+			return fmt.Errorf("failed to start collector %s: %w", collectorName, err)
+		}
+		started = append(started, collector)
+	}
+	for collectorName, collector := range runner.collectorInstances {
 		log.Debugf("Spawning  collector: %v", collector)
 		collectorName := collectorName
 		collector := collector
-		quit := make(chan os.Signal, 1)
 		if collector.IsAnnouncer() {
-			runner.collectorQuitChannel[collectorName] = quit
 			runner.runningAnnouncersWG.Add(1)
-			go runner.poller(collectorName, collector, quit, &runner.runningAnnouncersWG)
+			go runner.poller(ctx, collectorName, collector, &runner.runningAnnouncersWG)
 		} else {
-			runner.collectorQuitChannel[collectorName] = quit
 			runner.runningCollectorsWG.Add(1)
-			go runner.poller(collectorName, collector, quit, &runner.runningCollectorsWG)
+			go runner.poller(ctx, collectorName, collector, &runner.runningCollectorsWG)
 		}
 	}
+	go func() {
+		runner.runningCollectorsWG.Wait()
+		close(runner.collectorsDone)
+		runner.runningAnnouncersWG.Wait()
+		close(runner.allDone)
+	}()
+	return nil
 }
 
 // cleanup calls cleanup on each collector
-func (runner *CollectorRunner) cleanUpAll() {
+func (runner *CollectorRunner) cleanUpAll() error {
+	cleanUpErrors := make([]error, 0)
 	for collectorName, collector := range runner.collectorInstances {
 		log.Debugf("cleanup %s", collectorName)
 		err := collector.CleanUp()
-		utils.IfErrorExitOrPanic(err)
+		if err != nil {
+			cleanUpErrors = append(cleanUpErrors, fmt.Errorf("failed to clean up collector %s: %w", collectorName, err))
+		}
+	}
+	if len(cleanUpErrors) > 0 {
+		return utils.MakeCompositeError("", cleanUpErrors)
 	}
+	return nil
 }
 
-// Run manages set of collectors.
+// connect creates the clientset for the cluster and, if no node was requested,
+// finds the node the linuxptp-daemon is running on so that it can be recorded.
+// A local or ssh run has no clientset, the commands are run directly on the host.
+func (runner *CollectorRunner) connect() error {
+	if runner.config.SSH != "" {
+		sshCtx, err := clients.NewSSHExecContext(runner.config.SSH, clients.SSHOptions{
+			IdentityFile:   runner.config.SSHIdentityFile,
+			KnownHostsFile: runner.config.SSHKnownHostsFile,
+		})
+		if err != nil {
+			return err //nolint:wrapcheck // this returns a wrapped error
+		}
+		runner.hostCtx = sshCtx
+		if runner.cluster.NodeName == "" {
+			runner.cluster.NodeName = sshCtx.GetHost()
+		}
+		return nil
+	}
+	if runner.config.Local {
+		runner.hostCtx = clients.NewLocalExecContext()
+		if runner.cluster.NodeName == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				log.Warnf("could not find the hostname: %s", err.Error())
+				return nil
+			}
+			runner.cluster.NodeName = hostname
+		}
+		return nil
+	}
+	clientset, err := clients.NewClientset(runner.cluster.KubeConfig)
+	if err != nil {
+		return err
+	}
+	runner.clientset = clientset
+	if runner.cluster.NodeName == "" {
+		nodeName, err := contexts.GetPTPDaemonNodeName(clientset, "")
+		if err != nil {
+			log.Warnf("could not find the node for cluster %s: %s", runner.cluster.Name, err.Error())
+			return nil
+		}
+		runner.cluster.NodeName = nodeName
+	}
+	return nil
+}
+
+// getPollCounts returns the number of polls and errored polls for each collector
+func (runner *CollectorRunner) getPollCounts() ClusterPollCounts {
+	runner.healthLock.Lock()
+	defer runner.healthLock.Unlock()
+	polls := make(map[string]*PollCounts, len(runner.healthTrackers))
+	for collectorName, tracker := range runner.healthTrackers {
+		health := tracker.getHealth()
+		polls[collectorName] = &PollCounts{Polls: health.Polls, Errors: health.Errors}
+	}
+	return ClusterPollCounts{
+		Name:  runner.cluster.Name,
+		Polls: polls,
+	}
+}
+
+// observeRecord passes the records written for the cluster on to the summary and metrics
+func (runner *CollectorRunner) observeRecord(output callbacks.OutputType, tag string, tags map[string]string) {
+	runner.summary.observeRecord(output, tags)
+	if runner.metrics != nil {
+		runner.metrics.ObserveRecord(runner.cluster.Name, tag, output, tags)
+	}
+}
+
+// getStatus returns the health of each collector run against the cluster
+func (runner *CollectorRunner) getStatus() ClusterStatus {
+	runner.healthLock.Lock()
+	defer runner.healthLock.Unlock()
+	collectorHealth := make(map[string]CollectorHealth, len(runner.healthTrackers))
+	for collectorName, tracker := range runner.healthTrackers {
+		collectorHealth[collectorName] = tracker.getHealth()
+	}
+	return ClusterStatus{
+		Name:       runner.cluster.Name,
+		NodeName:   runner.cluster.NodeName,
+		Collectors: collectorHealth,
+	}
+}
+
+// Run manages set of collectors for a single cluster.
 // It first initialises them,
 // then polls them on the correct cadence and
-// finally cleans up the collectors when exiting
-func (runner *CollectorRunner) Run( //nolint:funlen // allow a slightly long function
-	kubeConfig string,
-	outputFile string,
-	requestedDuration time.Duration,
-	pollInterval int,
-	devInfoAnnouceInterval int,
-	ptpInterface string,
-	useAnalyserJSON bool,
-	logsOutputFile string,
-	includeLogTimestamps bool,
-	tempDir string,
-	keepDebugFiles bool,
-) {
-	clientset, err := clients.GetClientset(kubeConfig)
-	utils.IfErrorExitOrPanic(err)
-
-	outputFormat := callbacks.Raw
-	if useAnalyserJSON {
-		outputFormat = callbacks.AnalyserJSON
-	}
-
-	callback, err := callbacks.SetupCallback(outputFile, outputFormat)
-	utils.IfErrorExitOrPanic(err)
-	runner.initialise(
-		callback,
-		ptpInterface,
-		clientset,
-		pollInterval,
-		requestedDuration,
-		devInfoAnnouceInterval,
-		logsOutputFile,
-		includeLogTimestamps,
-		tempDir,
-		keepDebugFiles,
-	)
-	runner.start()
+// finally cleans up the collectors when exiting.
+// Cancelling ctx stops the collectors and any polls which are running.
+// The callback is not cleaned up as it may be shared with other clusters.
+func (runner *CollectorRunner) Run(ctx context.Context, callback callbacks.Callback) error {
+	requestedDuration, err := runner.config.GetDuration()
+	if err != nil {
+		return err
+	}
+
+	if runner.captureFile != "" {
+		runner.captureWriter, err = clients.OpenCaptureFile(runner.captureFile)
+		if err != nil {
+			return err //nolint:wrapcheck // this returns a wrapped error
+		}
+		defer func() {
+			if closeErr := runner.captureWriter.Close(); closeErr != nil {
+				log.Error(closeErr)
+			}
+		}()
+	}
+
+	if closer, ok := runner.hostCtx.(io.Closer); ok {
+		defer func() {
+			if closeErr := closer.Close(); closeErr != nil {
+				log.Error(closeErr)
+			}
+		}()
+	}
+
+	if runner.config.PersistentSessions {
+		// Deferred after the host so that the sessions are closed before the connection to it
+		runner.sessions = clients.NewPersistentSessions()
+		defer func() {
+			if closeErr := runner.sessions.Close(); closeErr != nil {
+				log.Error(closeErr)
+			}
+		}()
+	}
 
-	// Use wg count to know if any collectors are running.
-	for (runner.runningCollectorsWG.GetCount() + runner.runningAnnouncersWG.GetCount()) > 0 {
-		log.Debugf("Main Loop ")
+	runner.callback = callback
+	err = runner.initialise(callback, runner.clientset, requestedDuration)
+	if err != nil {
+		return err
+	}
+	err = runner.start(ctx)
+	if err != nil {
+		return err
+	}
+
+	killed := ctx.Done()
+	for {
 		select {
-		case sig := <-runner.quit:
+		case <-killed:
+			// The pollers see the cancellation themselves, keep
+			// processing poll results until they have finished.
 			log.Info("Killed shutting down")
-			// Forward signal to collector QuitChannels
-			for collectorName, quit := range runner.collectorQuitChannel {
-				log.Infof("Killed shutting down: %s", collectorName)
-				quit <- sig
-			}
-			runner.runningCollectorsWG.Wait()
-			runner.runningAnnouncersWG.Wait()
+			killed = nil
 		case pollRes := <-runner.pollResults:
-			log.Infof("Received %v", pollRes)
-			if len(pollRes.Errors) > 0 {
-				log.Warnf("Poll %s had issues: %v. Will retry next poll", pollRes.CollectorName, pollRes.Errors)
-				// If erroredPolls blocks it could cause pollResults to fill and
-				// block the execution of the collectors.
-				runner.erroredPolls <- pollRes
+			runner.handlePollResult(pollRes)
+		case <-runner.allDone:
+			for len(runner.pollResults) > 0 {
+				runner.handlePollResult(<-runner.pollResults)
 			}
-		default:
-			log.Debug("Sleeping main func")
-			time.Sleep(time.Millisecond)
+			log.Info("Doing Cleanup")
+			return runner.cleanUpAll()
 		}
 	}
-	log.Info("Doing Cleanup")
-	runner.cleanUpAll()
-	err = callback.CleanUp()
-	utils.IfErrorExitOrPanic(err)
+}
+
+func (runner *CollectorRunner) handlePollResult(pollRes collectors.PollResult) {
+	log.Infof("Received %v", pollRes)
+	if len(pollRes.Errors) > 0 {
+		// Changes in health are reported by updateHealth so only log each failure when debugging
+		log.Debugf("Poll %s had issues: %v", pollRes.CollectorName, pollRes.Errors)
+		// If erroredPolls blocks it could cause pollResults to fill and
+		// block the execution of the collectors.
+		runner.erroredPolls <- pollRes
+	}
 }
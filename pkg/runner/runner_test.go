@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner //nolint:testpackage // the host context is set directly instead of connecting
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors"
+)
+
+var scriptKeys = regexp.MustCompile(`echo '<([^/>]+)>'`)
+
+// fakeHostContext answers every key of a fetcher script with a canned value
+// so that the collectors can be built and polled without a PTP host
+type fakeHostContext struct {
+	values map[string]string
+}
+
+func (execCtx *fakeHostContext) ExecCommand(ctx context.Context, command []string) (stdout, stderr string, err error) {
+	return "", "", fmt.Errorf("unexpected command %v", command)
+}
+
+func (execCtx *fakeHostContext) ExecCommandStdIn(
+	ctx context.Context,
+	command []string,
+	buffIn bytes.Buffer,
+) (stdout, stderr string, err error) {
+	out := strings.Builder{}
+	for _, match := range scriptKeys.FindAllStringSubmatch(buffIn.String(), -1) {
+		fmt.Fprintf(&out, "<%s>\n%s\n</%s>\n", match[1], execCtx.values[match[1]], match[1])
+	}
+	return out.String(), "", nil
+}
+
+// newFakeHostContext returns a host which answers the DevInfo, DPLL and PMC start up
+// scripts with an E810 NIC which exposes the DPLL filesystem and a single ptp4l instance
+func newFakeHostContext() *fakeHostContext {
+	return &fakeHostContext{values: map[string]string{
+		"date":     "1686916187.0584",
+		"gnss":     "gnss0",
+		"devID":    "0x1593",
+		"vendorID": "0x8086",
+		"ethtoolOut": strings.Join([]string{
+			"driver: ice", "version: 1.11.20.7", "firmware-version: 4.20 0x8001778b 1.3346.0", "bus-info: 0000:86:00.0",
+		}, "\n"),
+		"paths":         "dpll_0_state\ndpll_1_state\ndpll_1_offset",
+		"dpll_0_state":  "3",
+		"dpll_1_state":  "3",
+		"dpll_1_offset": "0",
+		"configs":       "/var/run/ptp4l.0.config\n[global]\n[ens7f0]",
+	}}
+}
+
+// lockedBuffer is written to by every collector of a runner at once like the output file
+type lockedBuffer struct {
+	buf  bytes.Buffer
+	lock sync.Mutex
+}
+
+func (out *lockedBuffer) Write(p []byte) (int, error) {
+	out.lock.Lock()
+	defer out.lock.Unlock()
+	return out.buf.Write(p) //nolint:wrapcheck // the buffer is passed through unchanged
+}
+
+func (out *lockedBuffer) Close() error {
+	return nil
+}
+
+var _ = Describe("CollectorRunner", func() {
+	When("the runners of two clusters run at the same time", func() {
+		It("should build and poll the collectors of both", func() {
+			hostCtx := newFakeHostContext()
+			runCfg := &RunConfig{
+				Local:                   true,
+				Duration:                "1s",
+				PollInterval:            1,
+				DevInfoAnnounceInterval: 1,
+				Collectors:              []string{collectors.DevInfoCollectorName, collectors.DPLLCollectorName, "PMC"},
+			}
+			interfaces := [][]string{{"ens7f0", "ens7f1", "ens7f2"}, {"ens5f0", "ens5f1", "ens5f2"}}
+
+			counters := make([]*callbacks.CountingCallback, len(interfaces))
+			runErrors := make([]error, len(interfaces))
+			wg := sync.WaitGroup{}
+			for i := range interfaces {
+				runner := NewCollectorRunner(runCfg, &ClusterConfig{
+					Name:          fmt.Sprintf("cluster%d", i),
+					PTPInterfaces: interfaces[i],
+				}, collectors.GetRegistry())
+				runner.hostCtx = hostCtx
+				counters[i] = callbacks.NewCountingCallback(callbacks.NewFileCallback(&lockedBuffer{}, callbacks.Raw))
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					runErrors[i] = runner.Run(context.Background(), counters[i])
+				}(i)
+			}
+			wg.Wait()
+
+			for i := range interfaces {
+				Expect(runErrors[i]).NotTo(HaveOccurred())
+				Expect(counters[i].GetCounts()).To(HaveKeyWithValue(collectors.DeviceInfo, BeNumerically(">=", 3)))
+				Expect(counters[i].GetCounts()).To(HaveKeyWithValue(collectors.DPLLInfo, BeNumerically(">=", 3)))
+			}
+		})
+	})
+})
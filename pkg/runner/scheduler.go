@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner
+
+import (
+	"time"
+)
+
+// pollSchedule calculates the deadlines at which a collector should be polled.
+// Deadlines are always a whole number of intervals after start so they do not drift
+// however long each poll takes to be dispatched.
+type pollSchedule struct {
+	start    time.Time
+	interval time.Duration
+	count    int64
+}
+
+func newPollSchedule(start time.Time, interval time.Duration) *pollSchedule {
+	return &pollSchedule{
+		start:    start,
+		interval: interval,
+	}
+}
+
+// next returns the next deadline after now. If deadlines have been missed
+// (for example because polls were being throttled) they are skipped rather
+// than being polled in a burst to catch up.
+func (schedule *pollSchedule) next(now time.Time) time.Time {
+	schedule.count++
+	if schedule.interval <= 0 {
+		return now
+	}
+	deadline := schedule.start.Add(time.Duration(schedule.count) * schedule.interval)
+	if deadline.Before(now) {
+		schedule.count = int64(now.Sub(schedule.start)/schedule.interval) + 1
+		deadline = schedule.start.Add(time.Duration(schedule.count) * schedule.interval)
+	}
+	return deadline
+}
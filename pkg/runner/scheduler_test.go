@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner //nolint:testpackage // testing internal functions
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("pollSchedule", func() {
+	start := time.Date(2023, time.August, 1, 12, 0, 0, 0, time.UTC)
+
+	When("polls are dispatched late", func() {
+		It("should not drift", func() {
+			schedule := newPollSchedule(start, time.Second)
+			Expect(schedule.next(start.Add(10 * time.Millisecond))).To(Equal(start.Add(time.Second)))
+			Expect(schedule.next(start.Add(1100 * time.Millisecond))).To(Equal(start.Add(2 * time.Second)))
+			Expect(schedule.next(start.Add(2900 * time.Millisecond))).To(Equal(start.Add(3 * time.Second)))
+		})
+	})
+	When("deadlines have been missed", func() {
+		It("should skip to the next deadline after now", func() {
+			schedule := newPollSchedule(start, time.Second)
+			Expect(schedule.next(start.Add(4500 * time.Millisecond))).To(Equal(start.Add(5 * time.Second)))
+			Expect(schedule.next(start.Add(5 * time.Second))).To(Equal(start.Add(6 * time.Second)))
+		})
+	})
+})
@@ -0,0 +1,308 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+)
+
+const (
+	samplesCondition          = "samples"
+	dpllStateCondition        = "dpll-state"
+	clockClassChangeCondition = "clock-class-change"
+	gnssFixLostCondition      = "gnss-fix-lost"
+
+	ppsDPLL = "pps"
+	eecDPLL = "eec"
+
+	// minGNSSFix is the lowest UBX fix type which uses satellites, 0 is no fix and 1 is dead reckoning only
+	minGNSSFix = 2
+)
+
+// stopCondition is checked against every record written by a collector
+// and ends the run once it has been met
+type stopCondition interface {
+	// addInstance is called for every collector instance once it has been built
+	addInstance(collectorName, instance string)
+	// removeInstance is called when an instance stops polling, it returns true if
+	// the condition is met by the instances which are left
+	removeInstance(collectorName, instance string) bool
+	// observe returns true once the condition has been met, tags are those the record is written with
+	observe(collectorName, instance string, output callbacks.OutputType, tags map[string]string) bool
+	String() string
+}
+
+// parseStopCondition parses a stop condition of the form <name>[=<value>]:
+//
+//	samples=<collector>:<count>  each instance of the collector has written count records, PMC writes
+//	                             several records each poll so it is met after fewer than count polls
+//	dpll-state=[pps:|eec:]<state> a DPLL changes into state, for example holdover or locked
+//	clock-class-change           the clockClass reported by PMC changes
+//	gnss-fix-lost                the GNSS receiver goes from having a fix to not having one
+func parseStopCondition(spec string) (stopCondition, error) { //nolint:ireturn // the condition types are internal
+	name, value, _ := strings.Cut(strings.TrimSpace(spec), "=")
+	switch name {
+	case samplesCondition:
+		collectorName, countStr, found := strings.Cut(value, ":")
+		count, err := strconv.Atoi(countStr)
+		if !found || collectorName == "" || err != nil || count <= 0 {
+			return nil, fmt.Errorf("stop condition %s must be of the form samples=<collector>:<count>", spec)
+		}
+		return &samplesStop{collectorName: collectorName, count: count, counts: make(map[string]int)}, nil
+	case dpllStateCondition:
+		dpll, state, found := strings.Cut(value, ":")
+		if !found {
+			dpll, state = ppsDPLL, value
+		}
+		stateValue, ok := devices.GetDPLLStateValue(state)
+		if !ok || (dpll != ppsDPLL && dpll != eecDPLL) {
+			return nil, fmt.Errorf("stop condition %s must be of the form dpll-state=[pps:|eec:]<state>", spec)
+		}
+		return &dpllStateStop{dpll: dpll, state: state, stateValue: stateValue, previous: make(map[string]string)}, nil
+	case clockClassChangeCondition:
+		if value != "" {
+			return nil, fmt.Errorf("stop condition %s does not take a value", name)
+		}
+		return &clockClassStop{previous: make(map[string]int)}, nil
+	case gnssFixLostCondition:
+		if value != "" {
+			return nil, fmt.Errorf("stop condition %s does not take a value", name)
+		}
+		return &gnssFixLostStop{hadFix: make(map[string]bool)}, nil
+	default:
+		return nil, fmt.Errorf("unknown stop condition %s", spec)
+	}
+}
+
+// noInstances can be embedded by conditions which do not need to know about the collector instances
+type noInstances struct{}
+
+func (noInstances) addInstance(string, string) {}
+
+func (noInstances) removeInstance(string, string) bool {
+	return false
+}
+
+// samplesStop is met once every instance of a collector has written count records. Every record is
+// counted so a collector which writes more than one record each poll, like PMC, needs fewer polls.
+type samplesStop struct {
+	counts        map[string]int
+	collectorName string
+	count         int
+}
+
+func (cond *samplesStop) addInstance(collectorName, instance string) {
+	if strings.EqualFold(collectorName, cond.collectorName) {
+		cond.counts[instance] = 0
+	}
+}
+
+func (cond *samplesStop) removeInstance(collectorName, instance string) bool {
+	if !strings.EqualFold(collectorName, cond.collectorName) {
+		return false
+	}
+	delete(cond.counts, instance)
+	return cond.met()
+}
+
+func (cond *samplesStop) observe(collectorName, instance string, _ callbacks.OutputType, _ map[string]string) bool {
+	if !strings.EqualFold(collectorName, cond.collectorName) {
+		return false
+	}
+	// Records from instances which were never added or have been removed are not counted
+	if _, found := cond.counts[instance]; !found {
+		return false
+	}
+	cond.counts[instance]++
+	return cond.met()
+}
+
+// met returns true if there are instances left and each has written enough records
+func (cond *samplesStop) met() bool {
+	if len(cond.counts) == 0 {
+		return false
+	}
+	for _, count := range cond.counts {
+		if count < cond.count {
+			return false
+		}
+	}
+	return true
+}
+
+func (cond *samplesStop) String() string {
+	return fmt.Sprintf("%s=%s:%d", samplesCondition, cond.collectorName, cond.count)
+}
+
+// dpllStateStop is met when a DPLL changes into the requested state
+type dpllStateStop struct {
+	noInstances
+	previous   map[string]string
+	dpll       string
+	state      string
+	stateValue string
+}
+
+func (cond *dpllStateStop) observe(_, instance string, output callbacks.OutputType, _ map[string]string) bool {
+	var ppsState, eecState string
+	switch info := output.(type) {
+	case *devices.DevFilesystemDPLLInfo:
+		ppsState, eecState = info.PPSState, info.EECState
+	case *devices.DevNetlinkDPLLInfo:
+		ppsState, eecState = info.PPSState, info.EECState
+	default:
+		return false
+	}
+	state := ppsState
+	if cond.dpll == eecDPLL {
+		state = eecState
+	}
+	previous, seen := cond.previous[instance]
+	cond.previous[instance] = state
+	return seen && previous != state && state == cond.stateValue
+}
+
+func (cond *dpllStateStop) String() string {
+	return fmt.Sprintf("%s=%s:%s", dpllStateCondition, cond.dpll, cond.state)
+}
+
+// clockClassStop is met when the clockClass differs from the previous one
+type clockClassStop struct {
+	noInstances
+	previous map[string]int
+}
+
+func (cond *clockClassStop) observe(_, instance string, output callbacks.OutputType, tags map[string]string) bool {
+	info, ok := output.(*devices.PMCInfo)
+	if !ok {
+		return false
+	}
+	// Each ptp4l instance has its own grandmaster so their clock classes are tracked separately
+	if ptp4lInstance, found := tags["instance"]; found {
+		instance = fmt.Sprintf("%s:%s", instance, ptp4lInstance)
+	}
+	previous, seen := cond.previous[instance]
+	cond.previous[instance] = info.ClockClass
+	return seen && previous != info.ClockClass
+}
+
+func (cond *clockClassStop) String() string {
+	return clockClassChangeCondition
+}
+
+// gnssFixLostStop is met when a receiver which had a fix reports that it no longer has one
+type gnssFixLostStop struct {
+	noInstances
+	hadFix map[string]bool
+}
+
+func (cond *gnssFixLostStop) observe(_, instance string, output callbacks.OutputType, _ map[string]string) bool {
+	info, ok := output.(*devices.GPSDetails)
+	if !ok {
+		return false
+	}
+	hasFix := info.NavStatus.GPSFix >= minGNSSFix
+	hadFix := cond.hadFix[instance]
+	cond.hadFix[instance] = hasFix
+	return hadFix && !hasFix
+}
+
+func (cond *gnssFixLostStop) String() string {
+	return gnssFixLostCondition
+}
+
+// stopper checks the records written by the collectors against the stop
+// conditions and cancels the run as soon as any of them are met
+type stopper struct {
+	cancel     context.CancelFunc
+	reason     string
+	conditions []stopCondition
+	lock       sync.Mutex
+}
+
+func newStopper(conditions []stopCondition, cancel context.CancelFunc) *stopper {
+	return &stopper{conditions: conditions, cancel: cancel}
+}
+
+// wrapCallback returns a callback which reports the records written
+// by an instance of a collector to the stopper
+func (stop *stopper) wrapCallback(
+	callback callbacks.Callback,
+	collectorName string,
+	instance string,
+) callbacks.Callback { //nolint:ireturn // the callback is returned unwrapped if there are no conditions
+	if stop == nil || len(stop.conditions) == 0 {
+		return callback
+	}
+	return callbacks.NewObservingCallback(callback, func(output callbacks.OutputType, _ string, tags map[string]string) {
+		stop.observe(collectorName, instance, output, tags)
+	})
+}
+
+// addInstance tells the conditions about an instance of a collector which will be polled
+func (stop *stopper) addInstance(collectorName, instance string) {
+	if stop == nil {
+		return
+	}
+	stop.lock.Lock()
+	defer stop.lock.Unlock()
+	for _, cond := range stop.conditions {
+		cond.addInstance(collectorName, instance)
+	}
+}
+
+// removeInstance tells the conditions that an instance will no longer be polled,
+// for example because it has been disabled, which may mean that a condition is now met
+func (stop *stopper) removeInstance(collectorName, instance string) {
+	if stop == nil {
+		return
+	}
+	stop.lock.Lock()
+	defer stop.lock.Unlock()
+	if stop.reason != "" {
+		return
+	}
+	for _, cond := range stop.conditions {
+		if cond.removeInstance(collectorName, instance) {
+			stop.stop(cond, instance)
+			return
+		}
+	}
+}
+
+func (stop *stopper) observe(collectorName, instance string, output callbacks.OutputType, tags map[string]string) {
+	stop.lock.Lock()
+	defer stop.lock.Unlock()
+	if stop.reason != "" {
+		return
+	}
+	for _, cond := range stop.conditions {
+		if cond.observe(collectorName, instance, output, tags) {
+			stop.stop(cond, instance)
+			return
+		}
+	}
+}
+
+// stop records the condition which was met and cancels the run, the lock must be held
+func (stop *stopper) stop(cond stopCondition, instance string) {
+	stop.reason = cond.String()
+	log.Infof("Stop condition %s met by %s, stopping collection", stop.reason, instance)
+	stop.cancel()
+}
+
+// getReason returns the stop condition which ended the run or an empty string if none have been met
+func (stop *stopper) getReason() string {
+	stop.lock.Lock()
+	defer stop.lock.Unlock()
+	return stop.reason
+}
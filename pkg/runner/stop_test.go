@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner //nolint:testpackage // testing internal functions
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+)
+
+var _ = Describe("parseStopCondition", func() {
+	DescribeTable("should reject invalid conditions",
+		func(spec string) {
+			_, err := parseStopCondition(spec)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("unknown condition", "forever"),
+		Entry("samples without a count", "samples=GNSS"),
+		Entry("samples with a zero count", "samples=GNSS:0"),
+		Entry("unknown DPLL state", "dpll-state=sideways"),
+		Entry("unknown DPLL", "dpll-state=ptp:locked"),
+		Entry("value on a flag condition", "gnss-fix-lost=yes"),
+	)
+})
+
+var _ = Describe("stopCondition", func() {
+	When("counting samples", func() {
+		It("should be met once every instance of the collector has enough samples", func() {
+			cond, err := parseStopCondition("samples=dpll:2")
+			Expect(err).NotTo(HaveOccurred())
+			cond.addInstance("DPLL", "DPLL:ens7f0")
+			cond.addInstance("DPLL", "DPLL:ens8f0")
+			cond.addInstance("GNSS", "GNSS")
+
+			dpllInfo := &devices.DevNetlinkDPLLInfo{}
+			Expect(cond.observe("DPLL", "DPLL:ens7f0", dpllInfo, nil)).To(BeFalse())
+			Expect(cond.observe("DPLL", "DPLL:ens7f0", dpllInfo, nil)).To(BeFalse())
+			Expect(cond.observe("GNSS", "GNSS", &devices.GPSDetails{}, nil)).To(BeFalse())
+			Expect(cond.observe("DPLL", "DPLL:ens8f0", dpllInfo, nil)).To(BeFalse())
+			Expect(cond.observe("DPLL", "DPLL:ens8f0", dpllInfo, nil)).To(BeTrue())
+		})
+		It("should not wait for an instance which has been removed", func() {
+			cond, err := parseStopCondition("samples=DPLL:2")
+			Expect(err).NotTo(HaveOccurred())
+			cond.addInstance("DPLL", "DPLL:ens7f0")
+			cond.addInstance("DPLL", "DPLL:ens8f0")
+
+			dpllInfo := &devices.DevNetlinkDPLLInfo{}
+			Expect(cond.observe("DPLL", "DPLL:ens7f0", dpllInfo, nil)).To(BeFalse())
+			Expect(cond.observe("DPLL", "DPLL:ens7f0", dpllInfo, nil)).To(BeFalse())
+			Expect(cond.removeInstance("DPLL", "DPLL:ens8f0")).To(BeTrue())
+			Expect(cond.observe("DPLL", "DPLL:ens8f0", dpllInfo, nil)).To(BeFalse())
+		})
+		It("should count each record rather than each poll", func() {
+			cond, err := parseStopCondition("samples=PMC:6")
+			Expect(err).NotTo(HaveOccurred())
+			cond.addInstance("PMC", "PMC")
+
+			// A single poll of a ptp4l instance with one port writes all six records
+			records := []callbacks.OutputType{
+				&devices.PMCInfo{},
+				&devices.PMCParentDataSet{},
+				&devices.PMCCurrentDataSet{},
+				&devices.PMCTimeStatus{},
+				&devices.PMCTimePropertiesDataSet{},
+			}
+			tags := map[string]string{"instance": "ptp4l.0", "interfaces": "ens7f0"}
+			for _, record := range records {
+				Expect(cond.observe("PMC", "PMC", record, tags)).To(BeFalse())
+			}
+			Expect(cond.observe("PMC", "PMC", &devices.PMCPortDataSet{}, tags)).To(BeTrue())
+		})
+		It("should not count an instance which was never added", func() {
+			cond, err := parseStopCondition("samples=DPLL:1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cond.observe("DPLL", "DPLL:ens7f0", &devices.DevNetlinkDPLLInfo{}, nil)).To(BeFalse())
+			Expect(cond.removeInstance("DPLL", "DPLL:ens7f0")).To(BeFalse())
+		})
+	})
+	When("waiting for a DPLL state", func() {
+		It("should only be met when the DPLL changes into the state", func() {
+			cond, err := parseStopCondition("dpll-state=locked")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cond.observe("DPLL", "DPLL:ens7f0", &devices.DevNetlinkDPLLInfo{PPSState: "2"}, nil)).To(BeFalse())
+			Expect(cond.observe("DPLL", "DPLL:ens7f0", &devices.DevNetlinkDPLLInfo{PPSState: "4"}, nil)).To(BeFalse())
+			Expect(cond.observe("DPLL", "DPLL:ens8f0", &devices.DevFilesystemDPLLInfo{PPSState: "2"}, nil)).To(BeFalse())
+			Expect(cond.observe("DPLL", "DPLL:ens7f0", &devices.DevNetlinkDPLLInfo{PPSState: "2"}, nil)).To(BeTrue())
+		})
+		It("should watch the EEC DPLL when asked to", func() {
+			cond, err := parseStopCondition("dpll-state=eec:holdover")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cond.observe("DPLL", "DPLL:ens7f0", &devices.DevFilesystemDPLLInfo{EECState: "2"}, nil)).To(BeFalse())
+			Expect(cond.observe("DPLL", "DPLL:ens7f0", &devices.DevFilesystemDPLLInfo{
+				EECState: "2", PPSState: "4",
+			}, nil)).To(BeFalse())
+			Expect(cond.observe("DPLL", "DPLL:ens7f0", &devices.DevFilesystemDPLLInfo{EECState: "4"}, nil)).To(BeTrue())
+		})
+	})
+	When("watching the clockClass", func() {
+		It("should be met when the clockClass changes", func() {
+			cond, err := parseStopCondition("clock-class-change")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cond.observe("PMC", "PMC", &devices.PMCInfo{ClockClass: 6}, nil)).To(BeFalse())
+			Expect(cond.observe("PMC", "PMC", &devices.PMCInfo{ClockClass: 6}, nil)).To(BeFalse())
+			Expect(cond.observe("PMC", "PMC", &devices.PMCInfo{ClockClass: 7}, nil)).To(BeTrue())
+		})
+		It("should track the clockClass of each ptp4l instance separately", func() {
+			cond, err := parseStopCondition("clock-class-change")
+			Expect(err).NotTo(HaveOccurred())
+			first := map[string]string{"instance": "ptp4l.0"}
+			second := map[string]string{"instance": "ptp4l.1"}
+			Expect(cond.observe("PMC", "PMC", &devices.PMCInfo{ClockClass: 6}, first)).To(BeFalse())
+			Expect(cond.observe("PMC", "PMC", &devices.PMCInfo{ClockClass: 248}, second)).To(BeFalse())
+			Expect(cond.observe("PMC", "PMC", &devices.PMCInfo{ClockClass: 6}, first)).To(BeFalse())
+			Expect(cond.observe("PMC", "PMC", &devices.PMCInfo{ClockClass: 248}, second)).To(BeFalse())
+			Expect(cond.observe("PMC", "PMC", &devices.PMCInfo{ClockClass: 7}, first)).To(BeTrue())
+		})
+	})
+	When("watching the GNSS fix", func() {
+		It("should be met when a fix is lost", func() {
+			cond, err := parseStopCondition("gnss-fix-lost")
+			Expect(err).NotTo(HaveOccurred())
+			noFix := &devices.GPSDetails{NavStatus: devices.GPSNavStatus{GPSFix: 0}}
+			timeFix := &devices.GPSDetails{NavStatus: devices.GPSNavStatus{GPSFix: 5}}
+			Expect(cond.observe("GNSS", "GNSS", noFix, nil)).To(BeFalse())
+			Expect(cond.observe("GNSS", "GNSS", timeFix, nil)).To(BeFalse())
+			Expect(cond.observe("GNSS", "GNSS", noFix, nil)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("stopper", func() {
+	It("should cancel the run and record the first condition to be met", func() {
+		cancelled := 0
+		clockClass, err := parseStopCondition("clock-class-change")
+		Expect(err).NotTo(HaveOccurred())
+		stop := newStopper([]stopCondition{clockClass}, func() { cancelled++ })
+
+		stop.observe("PMC", "PMC", &devices.PMCInfo{ClockClass: 6}, nil)
+		Expect(stop.getReason()).To(BeEmpty())
+		stop.observe("PMC", "PMC", &devices.PMCInfo{ClockClass: 248}, nil)
+		stop.observe("PMC", "PMC", &devices.PMCInfo{ClockClass: 6}, nil)
+		Expect(stop.getReason()).To(Equal("clock-class-change"))
+		Expect(cancelled).To(Equal(1))
+	})
+	It("should cancel the run when the instance it is waiting for is disabled", func() {
+		cancelled := 0
+		samples, err := parseStopCondition("samples=GNSS:1")
+		Expect(err).NotTo(HaveOccurred())
+		stop := newStopper([]stopCondition{samples}, func() { cancelled++ })
+		stop.addInstance("GNSS", "gm/GNSS")
+		stop.addInstance("GNSS", "bc/GNSS")
+
+		stop.observe("GNSS", "gm/GNSS", &devices.GPSDetails{}, nil)
+		Expect(stop.getReason()).To(BeEmpty())
+		stop.removeInstance("GNSS", "bc/GNSS")
+		Expect(stop.getReason()).To(Equal("samples=GNSS:1"))
+		Expect(cancelled).To(Equal(1))
+	})
+})
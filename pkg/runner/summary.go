@@ -0,0 +1,292 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/callbacks"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+)
+
+const (
+	RunSummaryTag = "run-summary"
+
+	// gapFactor is how many poll intervals can pass between successful polls before it is counted as a gap
+	gapFactor = 2
+
+	p50 = 50
+	p90 = 90
+	p99 = 99
+
+	tabPadding = 2
+)
+
+// ValueSummary holds the range and mean of a collected value
+type ValueSummary struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+	Count int     `json:"count"`
+}
+
+func (value *ValueSummary) add(sample float64) {
+	if value.Count == 0 || sample < value.Min {
+		value.Min = sample
+	}
+	if value.Count == 0 || sample > value.Max {
+		value.Max = sample
+	}
+	value.Count++
+	value.Mean += (sample - value.Mean) / float64(value.Count)
+}
+
+// LatencySummary holds percentiles of how long the polls of a collector took in milliseconds
+type LatencySummary struct {
+	P50 float64 `json:"p50Ms"`
+	P90 float64 `json:"p90Ms"`
+	P99 float64 `json:"p99Ms"`
+	Max float64 `json:"maxMs"`
+}
+
+// CollectorSummary describes how well a collector did over the run
+type CollectorSummary struct {
+	Latency      LatencySummary `json:"latency"`
+	Polls        int            `json:"polls"`
+	Errors       int            `json:"errors"`
+	Gaps         int            `json:"gaps"`
+	LongestGapMs float64        `json:"longestGapMs"`
+}
+
+// ClusterSummary holds the summary of each collector and the key values collected from a cluster.
+// ClockClasses counts each clockClass reported by PMC for each ptp4l instance.
+type ClusterSummary struct {
+	Collectors       map[string]*CollectorSummary `json:"collectors"`
+	GNSSTimeAccuracy *ValueSummary                `json:"gnssTimeAccuracy,omitempty"`
+	DPLLTimeError    map[string]*ValueSummary     `json:"dpllTimeError,omitempty"`
+	ClockClasses     map[string]map[string]int    `json:"clockClasses,omitempty"`
+	Name             string                       `json:"name,omitempty"`
+}
+
+// RunSummary is written at the very end of every output
+type RunSummary struct {
+	Clusters []*ClusterSummary `json:"clusters"`
+	Duration string            `json:"duration"`
+}
+
+func (summary *RunSummary) GetAnalyserFormat() ([]*callbacks.AnalyserFormatType, error) {
+	formatted := callbacks.AnalyserFormatType{
+		ID:   "run/summary",
+		Data: summary,
+	}
+	return []*callbacks.AnalyserFormatType{&formatted}, nil
+}
+
+// collectorStats are the raw values which a CollectorSummary is built from
+type collectorStats struct {
+	lastSuccess  time.Time
+	latencies    []time.Duration
+	longestGap   time.Duration
+	pollInterval time.Duration
+	polls        int
+	errors       int
+	gaps         int
+}
+
+// summariser collects the values needed to summarise the run of a single cluster
+type summariser struct {
+	collectors       map[string]*collectorStats
+	gnssTimeAccuracy *ValueSummary
+	dpllTimeError    map[string]*ValueSummary
+	clockClasses     map[string]map[string]int
+	lock             sync.Mutex
+}
+
+func newSummariser() *summariser {
+	return &summariser{
+		collectors:    make(map[string]*collectorStats),
+		dpllTimeError: make(map[string]*ValueSummary),
+		clockClasses:  make(map[string]map[string]int),
+	}
+}
+
+// recordPoll records how long a poll took and whether it succeeded.
+// A gap is counted whenever the time between successful polls is more than twice the poll interval.
+func (sum *summariser) recordPoll(collectorName string, pollInterval, latency time.Duration, failed bool, now time.Time) {
+	sum.lock.Lock()
+	defer sum.lock.Unlock()
+	stats, ok := sum.collectors[collectorName]
+	if !ok {
+		stats = &collectorStats{pollInterval: pollInterval}
+		sum.collectors[collectorName] = stats
+	}
+	stats.polls++
+	stats.latencies = append(stats.latencies, latency)
+	if failed {
+		stats.errors++
+		return
+	}
+	if !stats.lastSuccess.IsZero() {
+		sinceLast := now.Sub(stats.lastSuccess)
+		if stats.pollInterval > 0 && sinceLast > gapFactor*stats.pollInterval {
+			stats.gaps++
+			if sinceLast > stats.longestGap {
+				stats.longestGap = sinceLast
+			}
+		}
+	}
+	stats.lastSuccess = now
+}
+
+// observeRecord picks the key values out of the records written by the collectors
+func (sum *summariser) observeRecord(output callbacks.OutputType, tags map[string]string) {
+	sum.lock.Lock()
+	defer sum.lock.Unlock()
+	switch info := output.(type) {
+	case *devices.GPSDetails:
+		if sum.gnssTimeAccuracy == nil {
+			sum.gnssTimeAccuracy = &ValueSummary{}
+		}
+		sum.gnssTimeAccuracy.add(float64(info.NavClock.TimeAcc))
+	case *devices.DevFilesystemDPLLInfo:
+		iface := tags["interface"]
+		if _, ok := sum.dpllTimeError[iface]; !ok {
+			sum.dpllTimeError[iface] = &ValueSummary{}
+		}
+		sum.dpllTimeError[iface].add(info.GetTimeError())
+	case *devices.PMCInfo:
+		// Each ptp4l instance has its own grandmaster so their clock classes are counted separately
+		instance := tags["instance"]
+		if _, ok := sum.clockClasses[instance]; !ok {
+			sum.clockClasses[instance] = make(map[string]int)
+		}
+		sum.clockClasses[instance][strconv.Itoa(info.ClockClass)]++
+	}
+}
+
+// percentile returns the nearest rank percentile of the sorted latencies in milliseconds
+func percentile(sorted []time.Duration, percent int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(float64(percent)/100*float64(len(sorted)))) - 1 //nolint:gomnd // converting a percentage
+	if rank < 0 {
+		rank = 0
+	}
+	return toMilliseconds(sorted[rank])
+}
+
+func toMilliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
+
+// getSummary builds the summary of everything recorded so far
+func (sum *summariser) getSummary(clusterName string) *ClusterSummary {
+	sum.lock.Lock()
+	defer sum.lock.Unlock()
+	summary := &ClusterSummary{
+		Name:          clusterName,
+		Collectors:    make(map[string]*CollectorSummary, len(sum.collectors)),
+		DPLLTimeError: make(map[string]*ValueSummary, len(sum.dpllTimeError)),
+		ClockClasses:  make(map[string]map[string]int, len(sum.clockClasses)),
+	}
+	for collectorName, stats := range sum.collectors {
+		sorted := make([]time.Duration, len(stats.latencies))
+		copy(sorted, stats.latencies)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		collectorSummary := &CollectorSummary{
+			Polls:        stats.polls,
+			Errors:       stats.errors,
+			Gaps:         stats.gaps,
+			LongestGapMs: toMilliseconds(stats.longestGap),
+			Latency: LatencySummary{
+				P50: percentile(sorted, p50),
+				P90: percentile(sorted, p90),
+				P99: percentile(sorted, p99),
+			},
+		}
+		if len(sorted) > 0 {
+			collectorSummary.Latency.Max = toMilliseconds(sorted[len(sorted)-1])
+		}
+		summary.Collectors[collectorName] = collectorSummary
+	}
+	if sum.gnssTimeAccuracy != nil {
+		gnssTimeAccuracy := *sum.gnssTimeAccuracy
+		summary.GNSSTimeAccuracy = &gnssTimeAccuracy
+	}
+	for iface, value := range sum.dpllTimeError {
+		dpllTimeError := *value
+		summary.DPLLTimeError[iface] = &dpllTimeError
+	}
+	for instance, counts := range sum.clockClasses {
+		summary.ClockClasses[instance] = make(map[string]int, len(counts))
+		for clockClass, count := range counts {
+			summary.ClockClasses[instance][clockClass] = count
+		}
+	}
+	return summary
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeValue writes a row for a ValueSummary to the table
+func writeValue(table io.Writer, name string, value *ValueSummary) {
+	fmt.Fprintf(table, "%s\t%d\t%.3f\t%.3f\t%.3f\t\n", name, value.Count, value.Min, value.Max, value.Mean)
+}
+
+// Write prints the summary as a set of human readable tables
+func (summary *RunSummary) Write(out io.Writer) error {
+	table := tabwriter.NewWriter(out, 0, 0, tabPadding, ' ', 0)
+	fmt.Fprintf(table, "Run summary (duration %s)\n", summary.Duration)
+	for _, cluster := range summary.Clusters {
+		if cluster.Name != "" {
+			fmt.Fprintf(table, "\nCluster %s\n", cluster.Name)
+		}
+		fmt.Fprintln(table, "\nCOLLECTOR\tPOLLS\tERRORS\tP50 MS\tP90 MS\tP99 MS\tMAX MS\tGAPS\tLONGEST GAP MS\t")
+		for _, collectorName := range sortedKeys(cluster.Collectors) {
+			collector := cluster.Collectors[collectorName]
+			fmt.Fprintf(table, "%s\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%d\t%.0f\t\n",
+				collectorName, collector.Polls, collector.Errors,
+				collector.Latency.P50, collector.Latency.P90, collector.Latency.P99, collector.Latency.Max,
+				collector.Gaps, collector.LongestGapMs,
+			)
+		}
+		if cluster.GNSSTimeAccuracy != nil || len(cluster.DPLLTimeError) > 0 {
+			fmt.Fprintln(table, "\nVALUE\tCOUNT\tMIN\tMAX\tMEAN\t")
+			if cluster.GNSSTimeAccuracy != nil {
+				writeValue(table, "GNSS tAcc", cluster.GNSSTimeAccuracy)
+			}
+			for _, iface := range sortedKeys(cluster.DPLLTimeError) {
+				writeValue(table, fmt.Sprintf("DPLL time error %s", iface), cluster.DPLLTimeError[iface])
+			}
+		}
+		if len(cluster.ClockClasses) > 0 {
+			fmt.Fprintln(table, "\nPTP4L INSTANCE\tCLOCK CLASS\tCOUNT\t")
+			for _, instance := range sortedKeys(cluster.ClockClasses) {
+				counts := cluster.ClockClasses[instance]
+				for _, clockClass := range sortedKeys(counts) {
+					fmt.Fprintf(table, "%s\t%s\t%d\t\n", instance, clockClass, counts[clockClass])
+				}
+			}
+		}
+	}
+	err := table.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to write run summary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package runner //nolint:testpackage // testing internal functions
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/collectors/devices"
+)
+
+var _ = Describe("summariser", func() {
+	start := time.Date(2023, time.August, 1, 12, 0, 0, 0, time.UTC)
+
+	When("polls are recorded", func() {
+		It("should summarise the latency, errors and gaps", func() {
+			sum := newSummariser()
+			for i := 1; i <= 100; i++ {
+				sum.recordPoll("GNSS", time.Second, time.Duration(i)*time.Millisecond, false, start.Add(time.Duration(i)*time.Second))
+			}
+			sum.recordPoll("GNSS", time.Second, time.Millisecond, true, start.Add(101*time.Second))
+			sum.recordPoll("GNSS", time.Second, time.Millisecond, false, start.Add(105*time.Second))
+
+			summary := sum.getSummary("gm").Collectors["GNSS"]
+			Expect(summary.Polls).To(Equal(102))
+			Expect(summary.Errors).To(Equal(1))
+			Expect(summary.Gaps).To(Equal(1))
+			Expect(summary.LongestGapMs).To(Equal(5000.0))
+			Expect(summary.Latency.P50).To(Equal(49.0))
+			Expect(summary.Latency.P99).To(Equal(99.0))
+			Expect(summary.Latency.Max).To(Equal(100.0))
+		})
+	})
+	When("records are observed", func() {
+		It("should summarise the key values", func() {
+			sum := newSummariser()
+			sum.observeRecord(&devices.GPSDetails{NavClock: devices.GPSNavClock{TimeAcc: 4}}, nil)
+			sum.observeRecord(&devices.GPSDetails{NavClock: devices.GPSNavClock{TimeAcc: 10}}, nil)
+			iface := map[string]string{"interface": "ens7f0"}
+			sum.observeRecord(&devices.DevFilesystemDPLLInfo{PPSOffset: -300}, iface)
+			sum.observeRecord(&devices.DevFilesystemDPLLInfo{PPSOffset: 500}, iface)
+			first := map[string]string{"instance": "ptp4l.0", "interfaces": "ens7f0"}
+			second := map[string]string{"instance": "ptp4l.1", "interfaces": "ens5f0"}
+			sum.observeRecord(&devices.PMCInfo{ClockClass: 6}, first)
+			sum.observeRecord(&devices.PMCInfo{ClockClass: 6}, first)
+			sum.observeRecord(&devices.PMCInfo{ClockClass: 7}, first)
+			sum.observeRecord(&devices.PMCInfo{ClockClass: 248}, second)
+
+			summary := sum.getSummary("gm")
+			Expect(*summary.GNSSTimeAccuracy).To(Equal(ValueSummary{Min: 4, Max: 10, Mean: 7, Count: 2}))
+			Expect(*summary.DPLLTimeError["ens7f0"]).To(Equal(ValueSummary{Min: -3, Max: 5, Mean: 1, Count: 2}))
+			Expect(summary.ClockClasses).To(Equal(map[string]map[string]int{
+				"ptp4l.0": {"6": 2, "7": 1},
+				"ptp4l.1": {"248": 1},
+			}))
+
+			out := &bytes.Buffer{}
+			Expect((&RunSummary{Clusters: []*ClusterSummary{summary}, Duration: "1m0s"}).Write(out)).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("Cluster gm"))
+			Expect(out.String()).To(ContainSubstring("DPLL time error ens7f0"))
+			Expect(out.String()).To(MatchRegexp(`ptp4l\.1\s+248\s+1`))
+		})
+	})
+})
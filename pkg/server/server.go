@@ -0,0 +1,369 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/runner"
+)
+
+const (
+	sessionsPath      = "/sessions"
+	outputFileName    = "output.txt"
+	logsFileName      = "logs.txt"
+	captureFileName   = "capture.jsonl"
+	sessionDirPerm    = 0755
+	maxConfigSize     = 1 << 20
+	stopTimeout       = 30 * time.Second
+	shutdownTimeout   = 5 * time.Second
+	readHeaderTimeout = 10 * time.Second
+)
+
+var validSessionName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Server runs named collection sessions controlled through a HTTP API:
+//
+//	GET    /sessions               list the status of every session
+//	POST   /sessions/<name>        start a session, the body is a YAML or JSON run config
+//	GET    /sessions/<name>        get the status of a session
+//	POST   /sessions/<name>/stop   stop a running session
+//	DELETE /sessions/<name>        stop a session and remove its outputs
+//	GET    /sessions/<name>/output download the collected data
+//	GET    /sessions/<name>/logs   download the collected logs
+//	GET    /sessions/<name>/capture download the captured commands, if a capture file was requested
+type Server struct {
+	ctx          context.Context //nolint:containedctx // sessions outlive the request which starts them
+	sessions     map[string]*session
+	reserved     map[string]bool
+	newRunConfig func() *runner.RunConfig
+	dataDir      string
+	lock         sync.Mutex
+}
+
+// NewServer returns a Server which keeps the outputs of each session in a directory under dataDir.
+// newRunConfig returns the defaults which the run config of each session is applied on top of.
+func NewServer(ctx context.Context, dataDir string, newRunConfig func() *runner.RunConfig) *Server {
+	return &Server{
+		ctx:          ctx,
+		sessions:     make(map[string]*session),
+		reserved:     make(map[string]bool),
+		newRunConfig: newRunConfig,
+		dataDir:      dataDir,
+	}
+}
+
+// ListenAndServe serves the API on addr until ctx is cancelled, then stops all running sessions
+func ListenAndServe(ctx context.Context, addr, dataDir string, newRunConfig func() *runner.RunConfig) error {
+	srv := NewServer(ctx, dataDir, newRunConfig)
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Errorf("failed to shut down server: %s", err.Error())
+		}
+	}()
+
+	log.Infof("Listening on %s", addr)
+	err := httpServer.ListenAndServe()
+	srv.stopAll()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	return nil
+}
+
+// Handler returns the http.Handler for the API
+func (srv *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(sessionsPath, srv.handleSessions)
+	mux.HandleFunc(sessionsPath+"/", srv.handleSession)
+	return mux
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Errorf("failed to write response: %s", err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, errorResponse{Error: err.Error()})
+}
+
+func (srv *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	srv.lock.Lock()
+	sessions := make([]*session, 0, len(srv.sessions))
+	for _, sess := range srv.sessions {
+		sessions = append(sessions, sess)
+	}
+	srv.lock.Unlock()
+
+	statuses := make([]*SessionStatus, 0, len(sessions))
+	for _, sess := range sessions {
+		statuses = append(statuses, sess.getStatus())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func (srv *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, sessionsPath+"/"), "/")
+	if !validSessionName.MatchString(name) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid session name %q", name))
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodPost:
+		srv.startSession(w, r, name)
+	case action == "" && r.Method == http.MethodGet:
+		srv.withSession(w, name, func(sess *session) {
+			writeJSON(w, http.StatusOK, sess.getStatus())
+		})
+	case action == "" && r.Method == http.MethodDelete:
+		srv.deleteSession(w, name)
+	case action == "stop" && r.Method == http.MethodPost:
+		srv.withSession(w, name, func(sess *session) {
+			sess.stop(stopTimeout)
+			writeJSON(w, http.StatusOK, sess.getStatus())
+		})
+	case action == "output" && r.Method == http.MethodGet:
+		srv.withSession(w, name, func(sess *session) {
+			http.ServeFile(w, r, sess.config.Output.File)
+		})
+	case action == "logs" && r.Method == http.MethodGet:
+		srv.withSession(w, name, func(sess *session) {
+			http.ServeFile(w, r, sess.config.Output.LogsFile)
+		})
+	case action == "capture" && r.Method == http.MethodGet:
+		srv.withSession(w, name, func(sess *session) {
+			if sess.config.Output.CaptureFile == "" {
+				writeError(w, http.StatusNotFound, fmt.Errorf("session %s was not started with a capture file", name))
+				return
+			}
+			http.ServeFile(w, r, sess.config.Output.CaptureFile)
+		})
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown request %s %s", r.Method, r.URL.Path))
+	}
+}
+
+// withSession calls handler with the named session or responds with not found
+func (srv *Server) withSession(w http.ResponseWriter, name string, handler func(*session)) {
+	srv.lock.Lock()
+	sess, ok := srv.sessions[name]
+	srv.lock.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no session named %s", name))
+		return
+	}
+	handler(sess)
+}
+
+// serverOnlySettings returns the settings of a run config which choose the hosts that are
+// connected to, the credentials used or the files and ports opened on the server. The API
+// is not authenticated so these are only taken from the flags of the serve command.
+func serverOnlySettings(runCfg *runner.RunConfig) []string {
+	settings := make([]string, 0)
+	addIfSet := func(set bool, name string) {
+		if set {
+			settings = append(settings, name)
+		}
+	}
+	addIfSet(runCfg.Local, "local")
+	addIfSet(runCfg.SSH != "", "ssh")
+	addIfSet(runCfg.SSHIdentityFile != "", "sshIdentityFile")
+	addIfSet(runCfg.SSHKnownHostsFile != "", "sshKnownHostsFile")
+	addIfSet(runCfg.KubeConfig != "", "kubeconfig")
+	for _, cluster := range runCfg.Clusters {
+		if cluster.KubeConfig != "" {
+			settings = append(settings, "clusters.kubeconfig")
+			break
+		}
+	}
+	addIfSet(len(runCfg.CollectorSpecs) > 0, "collectorSpecs")
+	addIfSet(runCfg.MetricsListen != "", "metricsListen")
+	return settings
+}
+
+// getSessionConfig reads the run config for a session from the request body. The outputs
+// are always written to the session directory so that they can be downloaded.
+func (srv *Server) getSessionConfig(r *http.Request, dir string) (*runner.RunConfig, error) {
+	content, err := io.ReadAll(io.LimitReader(r.Body, maxConfigSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run config: %w", err)
+	}
+	requested := &runner.RunConfig{}
+	err = runner.ParseRunConfig(content, requested)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // this returns a wrapped error
+	}
+	if settings := serverOnlySettings(requested); len(settings) > 0 {
+		return nil, fmt.Errorf("%s can only be set by the flags of the serve command", strings.Join(settings, ", "))
+	}
+	runCfg := srv.newRunConfig()
+	err = runner.ParseRunConfig(content, runCfg)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // this returns a wrapped error
+	}
+	runCfg.Output.File = filepath.Join(dir, outputFileName)
+	runCfg.Output.LogsFile = filepath.Join(dir, logsFileName)
+	if runCfg.Output.CaptureFile != "" {
+		runCfg.Output.CaptureFile = filepath.Join(dir, captureFileName)
+	}
+	runCfg.TempDir = dir
+	err = runCfg.Validate()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // this returns a wrapped error
+	}
+	return runCfg, nil
+}
+
+// reserveName stops a session being started while another with the same name is being started or deleted
+func (srv *Server) reserveName(name string) bool {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	if _, ok := srv.sessions[name]; ok || srv.reserved[name] {
+		return false
+	}
+	srv.reserved[name] = true
+	return true
+}
+
+// releaseName lets the name be used by a new session
+func (srv *Server) releaseName(name string) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	delete(srv.reserved, name)
+}
+
+// addSession stores a started session and releases its name
+func (srv *Server) addSession(name string, sess *session) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	delete(srv.reserved, name)
+	srv.sessions[name] = sess
+}
+
+// removeSession takes the session out of the list and keeps its name reserved until it has been
+// stopped and its outputs removed, so that a new session with the same name can not share its directory
+func (srv *Server) removeSession(name string) (*session, bool) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	sess, ok := srv.sessions[name]
+	if ok {
+		delete(srv.sessions, name)
+		srv.reserved[name] = true
+	}
+	return sess, ok
+}
+
+// startSession connects to the clusters before responding so that
+// any problems with the run config are reported straight away
+func (srv *Server) startSession(w http.ResponseWriter, r *http.Request, name string) {
+	if !srv.reserveName(name) {
+		writeError(w, http.StatusConflict, fmt.Errorf("session %s already exists", name))
+		return
+	}
+	started := false
+	defer func() {
+		if !started {
+			srv.releaseName(name)
+		}
+	}()
+
+	dir := filepath.Join(srv.dataDir, name)
+	runCfg, err := srv.getSessionConfig(r, dir)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	// The directory must be new as it is removed along with the session
+	err = os.Mkdir(dir, sessionDirPerm)
+	if errors.Is(err, os.ErrExist) {
+		writeError(w, http.StatusConflict, fmt.Errorf("session directory %s already exists", dir))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to create session directory: %w", err))
+		return
+	}
+	collection, err := runner.NewCollection(runCfg)
+	if err != nil {
+		os.RemoveAll(dir)
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	// The summary is kept in the output and status rather than printed by the server
+	collection.SetSummaryWriter(nil)
+	sess := newSession(name, dir, runCfg, collection)
+	sess.start(srv.ctx)
+	srv.addSession(name, sess)
+	started = true
+	log.Infof("Started session %s", name)
+	writeJSON(w, http.StatusCreated, sess.getStatus())
+}
+
+func (srv *Server) deleteSession(w http.ResponseWriter, name string) {
+	sess, ok := srv.removeSession(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no session named %s", name))
+		return
+	}
+	defer srv.releaseName(name)
+	sess.stop(stopTimeout)
+	if sess.isActive() {
+		log.Warnf("session %s did not stop in time, leaving its outputs in place", name)
+	} else if err := os.RemoveAll(sess.dir); err != nil {
+		log.Errorf("failed to remove outputs of session %s: %s", name, err.Error())
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// stopAll stops every session which is still running. The sessions are stopped
+// without holding the lock as each can take up to stopTimeout.
+func (srv *Server) stopAll() {
+	srv.lock.Lock()
+	sessions := make([]*session, 0, len(srv.sessions))
+	for _, sess := range srv.sessions {
+		sessions = append(sessions, sess)
+	}
+	srv.lock.Unlock()
+	for _, sess := range sessions {
+		if sess.isActive() {
+			sess.stop(stopTimeout)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/runner"
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/server"
+)
+
+func newDefaultRunConfig() *runner.RunConfig {
+	return &runner.RunConfig{Duration: "10s"}
+}
+
+var _ = Describe("Server", func() {
+	var (
+		handler http.Handler
+		dataDir string
+	)
+
+	BeforeEach(func() {
+		dataDir = GinkgoT().TempDir()
+		handler = server.NewServer(context.Background(), dataDir, newDefaultRunConfig).Handler()
+	})
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return recorder
+	}
+
+	When("there are no sessions", func() {
+		It("should return an empty list", func() {
+			response := request(http.MethodGet, "/sessions", "")
+			Expect(response.Code).To(Equal(http.StatusOK))
+			statuses := make([]server.SessionStatus, 0)
+			Expect(json.Unmarshal(response.Body.Bytes(), &statuses)).To(Succeed())
+			Expect(statuses).To(BeEmpty())
+		})
+		It("should return not found for a session", func() {
+			Expect(request(http.MethodGet, "/sessions/holdover", "").Code).To(Equal(http.StatusNotFound))
+			Expect(request(http.MethodPost, "/sessions/holdover/stop", "").Code).To(Equal(http.StatusNotFound))
+			Expect(request(http.MethodGet, "/sessions/holdover/output", "").Code).To(Equal(http.StatusNotFound))
+			Expect(request(http.MethodDelete, "/sessions/holdover", "").Code).To(Equal(http.StatusNotFound))
+		})
+	})
+	When("the session name is not valid", func() {
+		It("should return bad request", func() {
+			Expect(request(http.MethodPost, "/sessions/-holdover", "").Code).To(Equal(http.StatusBadRequest))
+			Expect(request(http.MethodPost, "/sessions/", "").Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+	When("the run config is not valid", func() {
+		It("should return bad request and not create the session", func() {
+			response := request(http.MethodPost, "/sessions/holdover", "interfaces: [ens7f0]\n")
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+			Expect(response.Body.String()).To(ContainSubstring("kubeconfig"))
+
+			response = request(http.MethodPost, "/sessions/holdover", "notAField: true\n")
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+			Expect(request(http.MethodGet, "/sessions/holdover", "").Code).To(Equal(http.StatusNotFound))
+			_, err := os.Stat(filepath.Join(dataDir, "holdover"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+	When("the run config sets where to collect from or files to read on the server", func() {
+		It("should return bad request and not create the session", func() {
+			for _, body := range []string{
+				"local: true\n",
+				"ssh: root@ptp-host\n",
+				"sshIdentityFile: /root/.ssh/id_rsa\n",
+				"kubeconfig: /root/.kube/config\n",
+				"clusters:\n- name: gm\n  kubeconfig: /root/.kube/config\n",
+				"collectorSpecs: [/etc/collectors.yaml]\n",
+				"metricsListen: 0.0.0.0:9090\n",
+			} {
+				response := request(http.MethodPost, "/sessions/holdover", "interfaces: [ens7f0]\n"+body)
+				Expect(response.Code).To(Equal(http.StatusBadRequest), body)
+				Expect(response.Body.String()).To(ContainSubstring("can only be set by the flags of the serve command"))
+			}
+			Expect(request(http.MethodGet, "/sessions/holdover", "").Code).To(Equal(http.StatusNotFound))
+		})
+	})
+	When("the session directory already exists", func() {
+		It("should return conflict and leave the directory alone", func() {
+			handler = server.NewServer(context.Background(), dataDir, func() *runner.RunConfig {
+				return &runner.RunConfig{Duration: "10s", Local: true}
+			}).Handler()
+			existing := filepath.Join(dataDir, "pkg", "keep.txt")
+			Expect(os.MkdirAll(filepath.Dir(existing), 0755)).To(Succeed())
+			Expect(os.WriteFile(existing, []byte("keep"), 0600)).To(Succeed())
+
+			response := request(http.MethodPost, "/sessions/pkg", "interfaces: [ens7f0]\n")
+			Expect(response.Code).To(Equal(http.StatusConflict))
+			Expect(response.Body.String()).To(ContainSubstring("already exists"))
+			Expect(existing).To(BeAnExistingFile())
+			Expect(request(http.MethodGet, "/sessions/pkg", "").Code).To(Equal(http.StatusNotFound))
+		})
+	})
+	When("the request is unknown", func() {
+		It("should return not found", func() {
+			Expect(request(http.MethodPut, "/sessions/holdover", "").Code).To(Equal(http.StatusNotFound))
+			Expect(request(http.MethodGet, "/sessions/holdover/unknown", "").Code).To(Equal(http.StatusNotFound))
+			Expect(request(http.MethodPost, "/sessions", "").Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
+
+func TestServer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Server Suite")
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-partner-solutions/vse-sync-collection-tools/pkg/runner"
+)
+
+type SessionState string
+
+const (
+	Running  SessionState = "running"
+	Stopping SessionState = "stopping"
+	Finished SessionState = "finished"
+	Failed   SessionState = "failed"
+)
+
+// SessionStatus is returned by the API to describe a session
+type SessionStatus struct {
+	Config     *runner.RunConfig        `json:"config"`
+	Collection *runner.CollectionStatus `json:"collection"`
+	Name       string                   `json:"name"`
+	State      SessionState             `json:"state"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+// session is a named collection run started through the API,
+// its outputs are kept in dir after it has finished
+type session struct {
+	config     *runner.RunConfig
+	collection *runner.Collection
+	cancel     context.CancelFunc
+	done       chan struct{}
+	err        error
+	name       string
+	dir        string
+	state      SessionState
+	lock       sync.Mutex
+}
+
+func newSession(name, dir string, runCfg *runner.RunConfig, collection *runner.Collection) *session {
+	return &session{
+		name:       name,
+		dir:        dir,
+		config:     runCfg,
+		collection: collection,
+		state:      Running,
+		done:       make(chan struct{}),
+	}
+}
+
+// start runs the collection in the background until it finishes or ctx is cancelled
+func (sess *session) start(ctx context.Context) {
+	ctx, sess.cancel = context.WithCancel(ctx)
+	go func() {
+		defer close(sess.done)
+		defer sess.cancel()
+		err := sess.collection.Run(ctx)
+
+		sess.lock.Lock()
+		defer sess.lock.Unlock()
+		sess.state = Finished
+		if err != nil {
+			log.Errorf("session %s failed: %s", sess.name, err.Error())
+			sess.state = Failed
+			sess.err = err
+		}
+	}()
+}
+
+// stop cancels the collection and waits up to timeout for it to finish
+func (sess *session) stop(timeout time.Duration) {
+	sess.lock.Lock()
+	if sess.state == Running {
+		sess.state = Stopping
+	}
+	sess.lock.Unlock()
+	sess.cancel()
+
+	select {
+	case <-sess.done:
+	case <-time.After(timeout):
+		log.Warnf("session %s is still stopping after %s", sess.name, timeout)
+	}
+}
+
+// isActive reports if the collection is still running
+func (sess *session) isActive() bool {
+	select {
+	case <-sess.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (sess *session) getStatus() *SessionStatus {
+	sess.lock.Lock()
+	defer sess.lock.Unlock()
+	status := &SessionStatus{
+		Name:       sess.name,
+		State:      sess.state,
+		Config:     sess.config,
+		Collection: sess.collection.GetStatus(),
+	}
+	if sess.err != nil {
+		status.Error = sess.err.Error()
+	}
+	return status
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package utils
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// GetVersion returns the version of the module this binary was built from
+// along with the VCS revision if it was recorded at build time.
+func GetVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			if setting.Value == "true" {
+				modified = "-dirty"
+			}
+		}
+	}
+	if revision != "" {
+		version = fmt.Sprintf("%s (%s%s)", version, revision, modified)
+	}
+	return version
+}
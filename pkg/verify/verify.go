@@ -3,6 +3,7 @@
 package verify
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sort"
@@ -25,25 +26,27 @@ const (
 
 //nolint:ireturn // this needs to be an interface
 func getDevInfoValidations(
-	clientset *clients.Clientset,
-	interfaceName string,
+	ctx clients.ExecContext,
+	interfaceNames []string,
 ) []validations.Validation {
-	ctx, err := contexts.GetPTPDaemonContext(clientset)
-	utils.IfErrorExitOrPanic(err)
-	devInfo, err := devices.GetPTPDeviceInfo(interfaceName, ctx)
-	utils.IfErrorExitOrPanic(err)
-	devDetails := validations.NewDeviceDetails(&devInfo)
-	devFirmware := validations.NewDeviceFirmware(&devInfo)
-	devDriver := validations.NewDeviceDriver(&devInfo)
-	return []validations.Validation{devDetails, devFirmware, devDriver}
+	checks := make([]validations.Validation, 0)
+	for _, interfaceName := range interfaceNames {
+		devInfo, err := devices.GetPTPDeviceInfo(context.Background(), interfaceName, ctx)
+		utils.IfErrorExitOrPanic(err)
+		checks = append(
+			checks,
+			validations.NewDeviceDetails(&devInfo),
+			validations.NewDeviceFirmware(&devInfo),
+			validations.NewDeviceDriver(&devInfo),
+		)
+	}
+	return checks
 }
 
 func getGPSVersionValidations(
-	clientset *clients.Clientset,
+	ctx clients.ExecContext,
 ) []validations.Validation {
-	ctx, err := contexts.GetPTPDaemonContext(clientset)
-	utils.IfErrorExitOrPanic(err)
-	gnssVersions, err := devices.GetGPSVersions(ctx)
+	gnssVersions, err := devices.GetGPSVersions(context.Background(), ctx)
 	utils.IfErrorExitOrPanic(err)
 	return []validations.Validation{
 		validations.NewGNSS(&gnssVersions),
@@ -55,16 +58,14 @@ func getGPSVersionValidations(
 }
 
 func getGPSStatusValidation(
-	clientset *clients.Clientset,
+	ctx clients.ExecContext,
 ) []validations.Validation {
-	ctx, err := contexts.GetPTPDaemonContext(clientset)
-	utils.IfErrorExitOrPanic(err)
-
 	// If we need to do this for more validations then consider a generic
 	var antCheck *validations.GNSSAntStatus
 	var gpsDetails devices.GPSDetails
+	var err error
 	for i := 0; i < antPowerRetries; i++ {
-		gpsDetails, err = devices.GetGPSNav(ctx)
+		gpsDetails, err = devices.GetGPSNav(context.Background(), ctx)
 		if err != nil {
 			continue
 		}
@@ -80,13 +81,25 @@ func getGPSStatusValidation(
 	}
 }
 
-func getValidations(interfaceName, kubeConfig string) []validations.Validation {
+// getHostValidations returns the checks which can be run directly on the PTP host,
+// the checks which need the kubernetes API are skipped
+func getHostValidations(interfaceNames []string, ctx clients.ExecContext) []validations.Validation {
+	checks := make([]validations.Validation, 0)
+	checks = append(checks, getDevInfoValidations(ctx, interfaceNames)...)
+	checks = append(checks, getGPSVersionValidations(ctx)...)
+	checks = append(checks, getGPSStatusValidation(ctx)...)
+	return checks
+}
+
+func getValidations(interfaceNames []string, kubeConfig, nodeName string) []validations.Validation {
 	checks := make([]validations.Validation, 0)
 	clientset, err := clients.GetClientset(kubeConfig)
 	utils.IfErrorExitOrPanic(err)
-	checks = append(checks, getDevInfoValidations(clientset, interfaceName)...)
-	checks = append(checks, getGPSVersionValidations(clientset)...)
-	checks = append(checks, getGPSStatusValidation(clientset)...)
+	ctx, err := contexts.GetPTPDaemonContext(clientset, nodeName)
+	utils.IfErrorExitOrPanic(err)
+	checks = append(checks, getDevInfoValidations(ctx, interfaceNames)...)
+	checks = append(checks, getGPSVersionValidations(ctx)...)
+	checks = append(checks, getGPSStatusValidation(ctx)...)
 	checks = append(
 		checks,
 		validations.NewIsGrandMaster(clientset),
@@ -165,8 +178,15 @@ func report(results []*ValidationResult, useAnalyserJSON bool) {
 	}
 }
 
-func Verify(interfaceName, kubeConfig string, useAnalyserJSON bool) {
-	checks := getValidations(interfaceName, kubeConfig)
+// Verify checks the environment is ready for collection. If hostCtx is not nil
+// the checks are run through it instead of in the linuxptp-daemon container.
+func Verify(interfaceNames []string, kubeConfig, nodeName string, hostCtx clients.ExecContext, useAnalyserJSON bool) {
+	var checks []validations.Validation
+	if hostCtx != nil {
+		checks = getHostValidations(interfaceNames, hostCtx)
+	} else {
+		checks = getValidations(interfaceNames, kubeConfig, nodeName)
+	}
 
 	results := make([]*ValidationResult, 0)
 	for _, check := range checks {
@@ -41,6 +41,9 @@ func (f *fakeExecutor) Stream(options remotecommand.StreamOptions) error {
 }
 
 func (f *fakeExecutor) StreamWithContext(ctx context.Context, options remotecommand.StreamOptions) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("stream cancelled: %w", err)
+	}
 	stdout, stderr, reponseErr := f.responder(f.method, f.url, options)
 	_, err := options.Stdout.Write(stdout)
 	if err != nil {